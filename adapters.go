@@ -0,0 +1,70 @@
+package meddlerx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// these standard library handles are used as a Querier directly
+var (
+	_ Querier = (*sql.DB)(nil)
+	_ Querier = (*sql.Tx)(nil)
+	_ Querier = (*sql.Conn)(nil)
+)
+
+// LegacyQuerier is the context-free query interface of older database
+// wrappers, matching the DB interface of the original meddler.
+type LegacyQuerier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// AsQuerier converts a database handle to a Querier. Handles that already
+// implement Querier are returned as-is; this includes *sql.DB, *sql.Tx,
+// *sql.Conn, and wrappers that embed them, such as *sqlx.DB and *sqlx.Tx.
+// Handles that only implement the context-free LegacyQuerier methods are
+// adapted; contexts passed to the adapter are only checked for
+// cancellation before Query and Exec statements.
+//
+// pgx pools and connections do not produce *sql.Rows, so they cannot be
+// adapted directly: open them through database/sql instead, with
+// stdlib.OpenDBFromPool or stdlib.OpenDB from github.com/jackc/pgx/v5/stdlib.
+func AsQuerier(handle interface{}) (Querier, error) {
+	switch db := handle.(type) {
+	case nil:
+		return nil, fmt.Errorf("meddler.AsQuerier: nil database handle")
+	case Querier:
+		return db, nil
+	case LegacyQuerier:
+		return legacyQuerier{db}, nil
+	}
+	return nil, fmt.Errorf("meddler.AsQuerier: %T is not a supported database handle; "+
+		"pgx handles must be opened through database/sql with the pgx stdlib package", handle)
+}
+
+// legacyQuerier adapts a LegacyQuerier to Querier.
+type legacyQuerier struct {
+	db LegacyQuerier
+}
+
+func (q legacyQuerier) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return q.db.Query(query, args...)
+}
+
+func (q legacyQuerier) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	// a *sql.Row cannot be built holding an error, so cancellation is not
+	// checked here
+	return q.db.QueryRow(query, args...)
+}
+
+func (q legacyQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return q.db.Exec(query, args...)
+}
@@ -0,0 +1,71 @@
+package meddlerx
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+// oldHandle only offers the context-free methods.
+type oldHandle struct {
+	db *sql.DB
+}
+
+func (h oldHandle) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return h.db.Query(query, args...)
+}
+
+func (h oldHandle) QueryRow(query string, args ...interface{}) *sql.Row {
+	return h.db.QueryRow(query, args...)
+}
+
+func (h oldHandle) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return h.db.Exec(query, args...)
+}
+
+func TestAsQuerier(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	// the test database lives in a single connection, so the *sql.Conn
+	// must be released before the other handles are used
+	conn, err := db.Conn(testCtx)
+	if err != nil {
+		t.Fatalf("Conn error: %v", err)
+	}
+	q, err := AsQuerier(conn)
+	if err != nil {
+		t.Errorf("AsQuerier(*sql.Conn): %v", err)
+	} else if err := Load(testCtx, q, "person", new(Person), 2); err != nil {
+		t.Errorf("Load through *sql.Conn: %v", err)
+	}
+	conn.Close()
+
+	for _, handle := range []interface{}{db, oldHandle{db}} {
+		q, err := AsQuerier(handle)
+		if err != nil {
+			t.Errorf("AsQuerier(%T): %v", handle, err)
+			continue
+		}
+		elt := new(Person)
+		if err := Load(testCtx, q, "person", elt, 2); err != nil {
+			t.Errorf("Load through %T: %v", handle, err)
+		}
+	}
+
+	q, _ = AsQuerier(oldHandle{db})
+	ctx, cancel := context.WithCancel(testCtx)
+	cancel()
+	var people []*Person
+	if err := QueryAll(ctx, q, &people, "select * from person"); err == nil {
+		t.Errorf("QueryAll through adapter with cancelled context: expected err, got nil")
+	}
+
+	if _, err := AsQuerier("not a database"); err == nil {
+		t.Errorf("AsQuerier(string): expected err, got nil")
+	}
+	if _, err := AsQuerier(nil); err == nil {
+		t.Errorf("AsQuerier(nil): expected err, got nil")
+	}
+}
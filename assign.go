@@ -0,0 +1,101 @@
+package meddlerx
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// assignValue stores src, a value as returned by a database driver, in the
+// scan target dst, converting it the way rows.Scan would for the common
+// cases. It is used where values come from somewhere other than *sql.Rows.
+func assignValue(dst, src interface{}) error {
+	if scanner, ok := dst.(sql.Scanner); ok {
+		return scanner.Scan(src)
+	}
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() {
+		return fmt.Errorf("destination is not a pointer: %T", dst)
+	}
+	dv = dv.Elem()
+
+	if src == nil {
+		switch dv.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+			dv.Set(reflect.Zero(dv.Type()))
+			return nil
+		}
+		return fmt.Errorf("converting NULL to %s is unsupported", dv.Type())
+	}
+	if b, ok := src.([]byte); ok {
+		// never hand out the caller's buffer
+		src = append([]byte(nil), b...)
+	}
+	sv := reflect.ValueOf(src)
+	if sv.Type().AssignableTo(dv.Type()) {
+		dv.Set(sv)
+		return nil
+	}
+	if dv.Kind() == reflect.Ptr {
+		elt := reflect.New(dv.Type().Elem())
+		if err := assignValue(elt.Interface(), src); err != nil {
+			return err
+		}
+		dv.Set(elt)
+		return nil
+	}
+
+	// everything else goes through the text form, as rows.Scan does
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case time.Time:
+		s = v.Format(time.RFC3339Nano)
+	default:
+		s = fmt.Sprint(v)
+	}
+	fail := func(err error) error {
+		return fmt.Errorf("converting %T to %s: %w", src, dv.Type(), err)
+	}
+	switch dv.Kind() {
+	case reflect.String:
+		dv.SetString(s)
+	case reflect.Slice:
+		if dv.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("converting %T to %s is unsupported", src, dv.Type())
+		}
+		dv.SetBytes([]byte(s))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, dv.Type().Bits())
+		if err != nil {
+			return fail(err)
+		}
+		dv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, dv.Type().Bits())
+		if err != nil {
+			return fail(err)
+		}
+		dv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, dv.Type().Bits())
+		if err != nil {
+			return fail(err)
+		}
+		dv.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fail(err)
+		}
+		dv.SetBool(b)
+	default:
+		return fmt.Errorf("converting %T to %s is unsupported", src, dv.Type())
+	}
+	return nil
+}
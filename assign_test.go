@@ -0,0 +1,55 @@
+package meddlerx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAssignValue(t *testing.T) {
+	var i int
+	var u uint8
+	var f float64
+	var b bool
+	var s string
+	var raw []byte
+	var p *int
+	var any interface{}
+
+	tests := []struct {
+		dst, src, expected interface{}
+	}{
+		{&i, int64(42), 42},
+		{&i, []byte("-7"), -7},
+		{&u, "200", uint8(200)},
+		{&f, []byte("1.5"), 1.5},
+		{&b, int64(1), true},
+		{&s, []byte("text"), "text"},
+		{&s, int64(3), "3"},
+		{&raw, "bytes", []byte("bytes")},
+		{&p, int64(5), 5},
+		{&any, "x", "x"},
+	}
+	for _, test := range tests {
+		if err := assignValue(test.dst, test.src); err != nil {
+			t.Errorf("assignValue(%T, %#v): %v", test.dst, test.src, err)
+			continue
+		}
+		got := reflect.ValueOf(test.dst).Elem()
+		if got.Kind() == reflect.Ptr {
+			got = got.Elem()
+		}
+		if !reflect.DeepEqual(got.Interface(), test.expected) {
+			t.Errorf("assignValue(%T, %#v): expected %#v, got %#v", test.dst, test.src, test.expected, got.Interface())
+		}
+	}
+
+	if err := assignValue(&p, nil); err != nil || p != nil {
+		t.Errorf("assignValue(NULL): expected nil pointer, got %v, %v", p, err)
+	}
+	if err := assignValue(&i, nil); err == nil {
+		t.Errorf("assignValue(NULL) into int: expected an error")
+	}
+	if err := assignValue(&i, "x"); err == nil {
+		t.Errorf("assignValue(\"x\") into int: expected an error")
+	}
+}
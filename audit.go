@@ -0,0 +1,165 @@
+package meddlerx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// WriteOp identifies the kind of write reported to an AuditHook or
+// OnChange callback.
+type WriteOp string
+
+// Write operations
+const (
+	OpInsert WriteOp = "insert"
+	OpUpdate WriteOp = "update"
+	OpDelete WriteOp = "delete"
+)
+
+// AuditEvent describes a single write made by Insert, InsertWithPK, Update,
+// Save, Delete, InsertIgnore, Upsert, SaveOrUpdateOn, or Batch. Values are
+// keyed by column name and hold what was sent to (or read from) the
+// database, i.e. after meddlers have run.
+type AuditEvent struct {
+	Op    WriteOp
+	Table string
+	PK    int64
+
+	// Old holds the row as it was before an Update or Delete. It is only
+	// filled in if the Database has AuditOldValues set, and is nil for
+	// inserts or if the row did not exist.
+	Old map[string]interface{}
+
+	// New holds the columns written by an Insert or Update. It is nil for
+	// deletes.
+	New map[string]interface{}
+}
+
+// AuditHook is called after every successful write by a Database that has
+// one set. It runs with the same Querier as the write, so inside a
+// transaction it can record history rows that commit or roll back with the
+// change. An error from the hook is returned by the write.
+type AuditHook interface {
+	Audit(ctx context.Context, db Querier, event *AuditEvent) error
+}
+
+// AuditFunc adapts a function to an AuditHook.
+type AuditFunc func(ctx context.Context, db Querier, event *AuditEvent) error
+
+// Audit calls f.
+func (f AuditFunc) Audit(ctx context.Context, db Querier, event *AuditEvent) error {
+	return f(ctx, db, event)
+}
+
+// auditValues returns the values of the columns of src that a write with
+// o touches.
+func (d *Database) auditValues(src interface{}, o *writeOptions) (map[string]interface{}, error) {
+	names, err := d.writeColumns(src, true, o)
+	if err != nil {
+		return nil, err
+	}
+	values, err := d.SomeValues(src, names)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{}, len(names))
+	for i, name := range names {
+		m[name] = values[i]
+	}
+	return m, nil
+}
+
+// auditOld reads the current row for src's primary key into a map, for
+// AuditOldValues. It returns nil if there is nothing to read.
+func (d *Database) auditOld(ctx context.Context, db Querier, op, table string, src interface{}) (map[string]interface{}, error) {
+	if d.Audit == nil || !d.AuditOldValues {
+		return nil, nil
+	}
+	return d.currentRow(ctx, db, op, table, src)
+}
+
+// currentRow reads the row for src's primary key into a map keyed by
+// column. It returns nil if there is no such row.
+func (d *Database) currentRow(ctx context.Context, db Querier, op, table string, src interface{}) (map[string]interface{}, error) {
+	pkName, pkValue, err := d.PrimaryKey(src)
+	if err != nil || pkName == "" {
+		return nil, err
+	}
+	names, err := d.Columns(src, true)
+	if err != nil {
+		return nil, err
+	}
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = d.quoted(name)
+	}
+
+	q := fmt.Sprintf("SELECT %s FROM %s WHERE %s=%s", strings.Join(quoted, ","),
+		d.quotedTable(table), d.quoted(pkName), d.placeholder(1))
+	values := make([]interface{}, len(names))
+	targets := make([]interface{}, len(names))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+	err = db.QueryRowContext(ctx, q, pkValue).Scan(targets...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, d.queryError(op, table, q, []interface{}{pkValue}, err)
+	}
+
+	old := make(map[string]interface{}, len(names))
+	for i, name := range names {
+		if b, ok := values[i].([]byte); ok {
+			values[i] = append([]byte(nil), b...)
+		}
+		old[name] = values[i]
+	}
+	return old, nil
+}
+
+// written reports a completed write to the AuditHook, OnChange callback,
+// and Cache, if there are any. For updates and deletes that matched no rows,
+// nothing is reported. The row is always removed from the identity map in
+// ctx.
+func (d *Database) written(ctx context.Context, db Querier, op WriteOp, opName, table string, src interface{}, o *writeOptions, result sql.Result, old map[string]interface{}) error {
+	if _, ok := IdentityMapFrom(ctx); ok {
+		if _, pk, err := d.PrimaryKey(src); err == nil {
+			forget(ctx, table, pk)
+		}
+	}
+	if d.Audit == nil && d.OnChange == nil && d.Cache == nil {
+		return nil
+	}
+	if result != nil {
+		if n, err := result.RowsAffected(); err == nil && n == 0 {
+			return nil
+		}
+	}
+
+	_, pk, err := d.PrimaryKey(src)
+	if err != nil {
+		return err
+	}
+	if d.Cache != nil {
+		d.Cache.Invalidate(ctx, table, pk)
+	}
+	if d.Audit != nil {
+		event := &AuditEvent{Op: op, Table: table, PK: pk, Old: old}
+		if op != OpDelete {
+			if event.New, err = d.auditValues(src, o); err != nil {
+				return err
+			}
+		}
+		if err := d.Audit.Audit(ctx, db, event); err != nil {
+			return fmt.Errorf("meddler.%s: audit hook: %w", opName, err)
+		}
+	}
+	if d.OnChange != nil || d.Cache != nil {
+		d.publish(ctx, db, ChangeEvent{Op: op, Table: table, PK: pk, Record: src})
+	}
+	return nil
+}
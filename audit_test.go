@@ -0,0 +1,99 @@
+package meddlerx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	setupEvents(t)
+	defer db.Exec("drop table event")
+
+	var events []AuditEvent
+	d := SQLite.Clone()
+	d.AuditOldValues = true
+	d.Audit = AuditFunc(func(ctx context.Context, db Querier, event *AuditEvent) error {
+		events = append(events, *event)
+		return nil
+	})
+
+	e := &event{Key: "a", Payload: "first"}
+	if err := d.Insert(testCtx, db, "event", e); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	e.Payload = "second"
+	if err := d.Update(testCtx, db, "event", e, Fields("payload")); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	if err := d.Delete(testCtx, db, "event", e); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	// no rows match, so nothing is reported
+	if err := d.Delete(testCtx, db, "event", e); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+
+	expected := []AuditEvent{
+		{Op: OpInsert, Table: "event", PK: e.ID,
+			New: map[string]interface{}{"id": e.ID, "key": "a", "payload": "first"}},
+		{Op: OpUpdate, Table: "event", PK: e.ID,
+			Old: map[string]interface{}{"id": e.ID, "key": "a", "payload": "first"},
+			New: map[string]interface{}{"id": e.ID, "payload": "second"}},
+		{Op: OpDelete, Table: "event", PK: e.ID,
+			Old: map[string]interface{}{"id": e.ID, "key": "a", "payload": "second"}},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Audit: expected %+v, got %+v", expected, events)
+	}
+
+	// hook errors are returned by the write
+	failed := errors.New("audit failed")
+	d.Audit = AuditFunc(func(ctx context.Context, db Querier, event *AuditEvent) error {
+		return failed
+	})
+	if err := d.Insert(testCtx, db, "event", &event{Key: "b"}); !errors.Is(err, failed) {
+		t.Errorf("Insert with failing hook: expected audit error, got %v", err)
+	}
+}
+
+func TestAuditUpserts(t *testing.T) {
+	setupEvents(t)
+	defer db.Exec("drop table event")
+
+	type audited struct {
+		Op WriteOp
+		PK int64
+	}
+	var events []audited
+	d := SQLite.Clone()
+	d.Audit = AuditFunc(func(ctx context.Context, db Querier, event *AuditEvent) error {
+		events = append(events, audited{event.Op, event.PK})
+		return nil
+	})
+
+	a := &event{Key: "a", Payload: "first"}
+	if _, err := d.InsertIgnore(testCtx, db, "event", a); err != nil {
+		t.Fatalf("InsertIgnore error: %v", err)
+	}
+	// skipped, so nothing is reported
+	if _, err := d.InsertIgnore(testCtx, db, "event", &event{ID: a.ID, Key: "a"}); err != nil {
+		t.Fatalf("InsertIgnore error: %v", err)
+	}
+	if _, err := d.Upsert(testCtx, db, "event", &event{Key: "a", Payload: "second"}, "key"); err != nil {
+		t.Fatalf("Upsert error: %v", err)
+	}
+	b := &event{Key: "b"}
+	if _, err := d.Upsert(testCtx, db, "event", b, "key"); err != nil {
+		t.Fatalf("Upsert error: %v", err)
+	}
+	if _, err := d.SaveOrUpdateOn(testCtx, db, "event", &event{Key: "b", Payload: "third"}, "key"); err != nil {
+		t.Fatalf("SaveOrUpdateOn error: %v", err)
+	}
+
+	expected := []audited{{OpInsert, a.ID}, {OpUpdate, a.ID}, {OpInsert, b.ID}, {OpUpdate, b.ID}}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Audit: expected %v, got %v", expected, events)
+	}
+}
@@ -0,0 +1,342 @@
+package meddlerx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// BatchStatement is a single statement queued in a Batch.
+type BatchStatement struct {
+	Query string
+	Args  []interface{}
+
+	// Returning is set when the statement ends in a RETURNING clause that
+	// yields the new primary key as a single integer column.
+	Returning bool
+}
+
+// BatchResult is the outcome of a single BatchStatement. ID holds the value
+// yielded by a Returning statement, or the LastInsertId of any other
+// statement where the driver reports one. RowsAffected is -1 if it is not
+// known, e.g. for a statement sent together with others.
+type BatchResult struct {
+	ID           int64
+	RowsAffected int64
+}
+
+// batchResult passes a BatchResult on as a sql.Result.
+type batchResult struct {
+	res BatchResult
+}
+
+func (r batchResult) LastInsertId() (int64, error) { return r.res.ID, nil }
+func (r batchResult) RowsAffected() (int64, error) {
+	if r.res.RowsAffected < 0 {
+		return 0, fmt.Errorf("meddler.Batch: rows affected not known")
+	}
+	return r.res.RowsAffected, nil
+}
+
+// BatchExecer is implemented by queriers that can send several statements
+// to the database in fewer round trips, such as MultiStatementExecer or an
+// adapter, written by the caller, around pgx's SendBatch. When the Querier
+// passed to Batch.Flush implements it, the whole batch is handed over in a
+// single call, with the statement timeout and QueryTags comment already
+// applied; otherwise the statements are run one at a time.
+//
+// ExecBatch must return one result per statement, in order.
+type BatchExecer interface {
+	ExecBatch(ctx context.Context, stmts []BatchStatement) ([]BatchResult, error)
+}
+
+// batchOp is a queued operation and the record it applies to.
+type batchOp struct {
+	op    string
+	write WriteOp
+	table string
+	src   interface{}
+	stmt  BatchStatement
+}
+
+// Batch queues Insert, Update, and Delete operations so that they can be
+// sent to the database together. The queries are built when the operation
+// is queued, so later changes to a record are not seen; primary keys of
+// inserted records are set when the batch is flushed.
+//
+// Statements only share round trips when Flush is given a BatchExecer.
+// Without one, each operation is a round trip of its own. With
+// MultiStatementExecer, only updates and deletes are combined, and each
+// insert is still sent separately. meddler does not ship a BatchExecer for
+// pgx, so on PostgreSQL batching needs one written around its SendBatch.
+type Batch struct {
+	d   *Database
+	ops []batchOp
+	err error
+}
+
+// NewBatch returns an empty Batch that builds its queries for d.
+func (d *Database) NewBatch() *Batch {
+	return &Batch{d: d}
+}
+
+// NewBatch using the Default Database type
+func NewBatch() *Batch {
+	return Default.NewBatch()
+}
+
+// Insert queues an INSERT of src. As with Insert, a primary key field must
+// be zero.
+func (b *Batch) Insert(table string, src interface{}) *Batch {
+	if err := b.d.writable("Insert", table); err != nil {
+		return b.fail(err)
+	}
+	d := b.d.forTable(table)
+	pkName, pkValue, err := d.PrimaryKey(src)
+	if err != nil {
+		return b.fail(err)
+	}
+	if pkName != "" && pkValue != 0 {
+		return b.fail(fmt.Errorf("meddler.Insert: %w", ErrPrimaryKeyNotZero))
+	}
+	q, values, err := d.insertQuery(table, src, false, nil)
+	if err != nil {
+		return b.fail(err)
+	}
+	stmt := BatchStatement{Query: q, Args: values}
+	if d.UseReturningToGetID && pkName != "" {
+		stmt.Query += " RETURNING " + d.quoted(pkName)
+		stmt.Returning = true
+	}
+	b.ops = append(b.ops, batchOp{op: "Insert", write: OpInsert, table: table, src: src, stmt: stmt})
+	return b
+}
+
+// Update queues an UPDATE of src.
+func (b *Batch) Update(table string, src interface{}) *Batch {
+	if err := b.d.writable("Update", table); err != nil {
+		return b.fail(err)
+	}
+	q, values, err := b.d.forTable(table).updateQuery(table, src, nil)
+	if err != nil {
+		return b.fail(err)
+	}
+	b.ops = append(b.ops, batchOp{op: "Update", write: OpUpdate, table: table, src: src, stmt: BatchStatement{Query: q, Args: values}})
+	return b
+}
+
+// Delete queues a DELETE of src.
+func (b *Batch) Delete(table string, src interface{}) *Batch {
+	if err := b.d.writable("Delete", table); err != nil {
+		return b.fail(err)
+	}
+	q, values, err := b.d.forTable(table).deleteQuery(table, src)
+	if err != nil {
+		return b.fail(err)
+	}
+	b.ops = append(b.ops, batchOp{op: "Delete", write: OpDelete, table: table, src: src, stmt: BatchStatement{Query: q, Args: values}})
+	return b
+}
+
+// fail records the first error from queueing an operation; it is returned
+// by Flush.
+func (b *Batch) fail(err error) *Batch {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// Len returns the number of queued operations.
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// Flush runs the queued operations, in order, and empties the batch. If an
+// operation could not be queued, Flush returns that error without running
+// anything. Flush does not start a transaction; pass a *sql.Tx or EventTx
+// if the operations must succeed or fail together.
+//
+// Each write is then handled as the single-record functions handle it:
+// the Cache entry is invalidated and Audit and OnChange are told, with the
+// record as it is at Flush. For tables that keep History, and for
+// AuditOldValues, the current rows are read before each statement runs, or
+// before the whole batch is sent to a BatchExecer.
+func (b *Batch) Flush(ctx context.Context, db Querier) error {
+	if b.err != nil {
+		return b.err
+	}
+	ops := b.ops
+	b.ops = nil
+	if len(ops) == 0 {
+		return nil
+	}
+	for _, op := range ops {
+		if op.op != "Insert" {
+			forgetTable(ctx, op.table)
+		}
+	}
+
+	// before reads the old row of an update or delete for Audit and
+	// History
+	olds := make([]map[string]interface{}, len(ops))
+	before := func(ctx context.Context, db Querier, i int) error {
+		op := ops[i]
+		if op.op == "Insert" {
+			return nil
+		}
+		d := b.d.forTable(op.table)
+		old, err := d.auditOld(ctx, db, op.op, op.table, op.src)
+		if err != nil {
+			return err
+		}
+		olds[i] = old
+		return d.saveHistory(ctx, db, op.op, op.table, op.src, old)
+	}
+
+	execer, batched := db.(BatchExecer)
+	ctx, db, done := b.d.begin(ctx, db, "Batch", "")
+	defer done()
+	var results []BatchResult
+	if batched {
+		sq, _ := db.(*stmtQuerier)
+		stmts := make([]BatchStatement, len(ops))
+		for i, op := range ops {
+			if err := before(ctx, db, i); err != nil {
+				return err
+			}
+			stmts[i] = op.stmt
+			if sq != nil {
+				// the statement timeout and QueryTags apply inside the
+				// batch as well
+				var err error
+				if stmts[i].Query, err = sq.prepare(ctx, op.stmt.Query); err != nil {
+					return err
+				}
+			}
+		}
+		var err error
+		if results, err = execer.ExecBatch(ctx, stmts); err != nil {
+			return fmt.Errorf("meddler.Batch: %w", err)
+		}
+		if len(results) != len(ops) {
+			return fmt.Errorf("meddler.Batch: got %d results for %d statements", len(results), len(ops))
+		}
+	} else {
+		results = make([]BatchResult, len(ops))
+		for i, op := range ops {
+			if err := before(ctx, db, i); err != nil {
+				return err
+			}
+			res, err := b.d.execBatchStatement(ctx, db, op.stmt)
+			if err != nil {
+				return b.d.queryError(op.op, op.table, op.stmt.Query, op.stmt.Args, err)
+			}
+			results[i] = res
+		}
+	}
+
+	for i, op := range ops {
+		// save the new primary keys
+		if op.op == "Insert" {
+			pkName, _, err := b.d.PrimaryKey(op.src)
+			if err != nil {
+				return err
+			}
+			if pkName != "" {
+				if err := b.d.SetPrimaryKey(op.src, results[i].ID); err != nil {
+					return fmt.Errorf("meddler.Insert: Error saving updated pk: %w", err)
+				}
+			}
+		}
+		if err := b.d.written(ctx, db, op.write, op.op, op.table, op.src, nil, batchResult{results[i]}, olds[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execBatchStatement runs a single statement for a Querier that cannot
+// batch.
+func (d *Database) execBatchStatement(ctx context.Context, db Querier, stmt BatchStatement) (BatchResult, error) {
+	var res BatchResult
+	if stmt.Returning {
+		if err := db.QueryRowContext(ctx, stmt.Query, stmt.Args...).Scan(&res.ID); err != nil {
+			return res, err
+		}
+		res.RowsAffected = 1
+		return res, nil
+	}
+
+	result, err := db.ExecContext(ctx, stmt.Query, stmt.Args...)
+	if err != nil {
+		return res, err
+	}
+	if res.RowsAffected, err = result.RowsAffected(); err != nil {
+		return res, err
+	}
+	// not every driver reports an insert id, and only inserts need it
+	res.ID, _ = result.LastInsertId()
+	return res, nil
+}
+
+// MultiStatementExecer is a BatchExecer for drivers that run several
+// semicolon-separated statements, with all their arguments, in one Exec:
+// go-sqlite3, and go-sql-driver/mysql with multiStatements and
+// interpolateParams set. Each run of consecutive updates and deletes is
+// sent as one string, and their results have a RowsAffected of -1, since
+// the driver only reports one result for the string. Each insert is sent
+// on its own, as its new primary key must be read back.
+//
+// The statements must use "?" placeholders. PostgreSQL does not accept
+// several statements with arguments in one Exec; use a BatchExecer around
+// pgx's SendBatch instead.
+type MultiStatementExecer struct {
+	Querier
+	d *Database
+}
+
+// MultiStatement returns a MultiStatementExecer that sends batches to db.
+func (d *Database) MultiStatement(db Querier) *MultiStatementExecer {
+	return &MultiStatementExecer{Querier: db, d: d}
+}
+
+// MultiStatement using the Default Database type
+func MultiStatement(db Querier) *MultiStatementExecer {
+	return Default.MultiStatement(db)
+}
+
+// ExecBatch implements BatchExecer.
+func (m *MultiStatementExecer) ExecBatch(ctx context.Context, stmts []BatchStatement) ([]BatchResult, error) {
+	if m.d.PlaceholderFunc != nil || m.d.Placeholder != "?" {
+		return nil, fmt.Errorf("meddler.MultiStatement: statements must use ? placeholders, not %s", m.d.placeholder(1))
+	}
+	results := make([]BatchResult, len(stmts))
+	for i := 0; i < len(stmts); {
+		if strings.HasPrefix(stmts[i].Query, "INSERT") {
+			res, err := m.d.execBatchStatement(ctx, m.Querier, stmts[i])
+			if err != nil {
+				return nil, m.d.queryError("Batch", "", stmts[i].Query, stmts[i].Args, err)
+			}
+			results[i] = res
+			i++
+			continue
+		}
+
+		// join the run of statements up to the next insert
+		var queries []string
+		var args []interface{}
+		j := i
+		for ; j < len(stmts) && !strings.HasPrefix(stmts[j].Query, "INSERT"); j++ {
+			queries = append(queries, stmts[j].Query)
+			args = append(args, stmts[j].Args...)
+			results[j] = BatchResult{RowsAffected: -1}
+		}
+		q := strings.Join(queries, ";\n")
+		if _, err := m.ExecContext(ctx, q, args...); err != nil {
+			return nil, m.d.queryError("Batch", "", q, args, err)
+		}
+		i = j
+	}
+	return results, nil
+}
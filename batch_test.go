@@ -0,0 +1,215 @@
+package meddlerx
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// batchQuerier is a recordingQuerier that also implements BatchExecer.
+type batchQuerier struct {
+	recordingQuerier
+	batches [][]BatchStatement
+}
+
+func (b *batchQuerier) ExecBatch(ctx context.Context, stmts []BatchStatement) ([]BatchResult, error) {
+	b.batches = append(b.batches, stmts)
+	results := make([]BatchResult, len(stmts))
+	for i := range stmts {
+		results[i] = BatchResult{ID: int64(100 + i), RowsAffected: 1}
+	}
+	return results, nil
+}
+
+func TestBatch(t *testing.T) {
+	setupEvents(t)
+	defer db.Exec("drop table event")
+
+	a := &event{Key: "a", Payload: "one"}
+	b := &event{Key: "b", Payload: "two"}
+	batch := SQLite.NewBatch().Insert("event", a).Insert("event", b)
+	if batch.Len() != 2 {
+		t.Errorf("Batch.Len: expected 2, got %d", batch.Len())
+	}
+	if err := batch.Flush(testCtx, db); err != nil {
+		t.Fatalf("Batch.Flush error: %v", err)
+	}
+	if a.ID != 1 || b.ID != 2 {
+		t.Errorf("Batch insert: expected pks 1 and 2, got %d and %d", a.ID, b.ID)
+	}
+	if batch.Len() != 0 {
+		t.Errorf("Batch.Len after Flush: expected 0, got %d", batch.Len())
+	}
+
+	a.Payload = "changed"
+	if err := batch.Update("event", a).Delete("event", b).Flush(testCtx, db); err != nil {
+		t.Fatalf("Batch.Flush error: %v", err)
+	}
+	var events []*event
+	if err := SQLite.QueryAll(testCtx, db, &events, "select * from event"); err != nil {
+		t.Fatalf("QueryAll error: %v", err)
+	}
+	if len(events) != 1 || events[0].Payload != "changed" {
+		t.Errorf("Batch update and delete: unexpected rows %+v", events)
+	}
+
+	// errors while queueing are reported by Flush, and nothing is run
+	batch = SQLite.NewBatch().Insert("event", &event{Key: "c"}).Update("event", &event{Key: "d"})
+	if err := batch.Flush(testCtx, db); err == nil {
+		t.Errorf("Batch.Flush with zero pk update: expected err, got nil")
+	}
+	var count int
+	db.QueryRow("select count(*) from event").Scan(&count)
+	if count != 1 {
+		t.Errorf("Batch with queueing error: expected 1 row, found %d", count)
+	}
+}
+
+func TestBatchExecer(t *testing.T) {
+	once.Do(setup)
+
+	q := new(batchQuerier)
+	a := &event{Key: "a"}
+	batch := PostgreSQL.NewBatch().
+		Insert("event", a).
+		Update("event", &event{ID: 5, Key: "b"}).
+		Delete("event", &event{ID: 6})
+	if err := batch.Flush(testCtx, q); err != nil {
+		t.Fatalf("Batch.Flush error: %v", err)
+	}
+	if len(q.batches) != 1 || len(q.batches[0]) != 3 {
+		t.Fatalf("BatchExecer: expected one batch of 3 statements, got %v", q.batches)
+	}
+	if q.query != "" {
+		t.Errorf("BatchExecer: statement run outside the batch: %s", q.query)
+	}
+
+	stmts := q.batches[0]
+	expected := []string{
+		`INSERT INTO "event" ("key","payload") VALUES ($1,$2) RETURNING "id"`,
+		`UPDATE "event" SET "key"=$1,"payload"=$2 WHERE "id"=$3`,
+		`DELETE FROM "event" WHERE "id"=$1`,
+	}
+	for i, stmt := range stmts {
+		if stmt.Query != expected[i] {
+			t.Errorf("statement %d: expected %s, got %s", i, expected[i], stmt.Query)
+		}
+	}
+	if !stmts[0].Returning || stmts[1].Returning {
+		t.Errorf("BatchExecer: Returning should only be set on the insert")
+	}
+	if a.ID != 100 {
+		t.Errorf("BatchExecer: expected pk 100, got %d", a.ID)
+	}
+
+	// per-table quote characters apply as they do outside a batch
+	d := PostgreSQL.Clone()
+	d.Tables = map[string]TableConfig{"event": {Quote: "`"}}
+	q = new(batchQuerier)
+	batch = d.NewBatch().
+		Insert("event", &event{Key: "a"}).
+		Update("event", &event{ID: 5, Key: "b"}).
+		Delete("event", &event{ID: 6})
+	if err := batch.Flush(testCtx, q); err != nil {
+		t.Fatalf("Batch.Flush error: %v", err)
+	}
+	expected = []string{
+		"INSERT INTO `event` (`key`,`payload`) VALUES ($1,$2) RETURNING `id`",
+		"UPDATE `event` SET `key`=$1,`payload`=$2 WHERE `id`=$3",
+		"DELETE FROM `event` WHERE `id`=$1",
+	}
+	for i, stmt := range q.batches[0] {
+		if stmt.Query != expected[i] {
+			t.Errorf("statement %d with a Quote override: expected %s, got %s", i, expected[i], stmt.Query)
+		}
+	}
+
+	// QueryTags reach statements handed to a BatchExecer
+	d = PostgreSQL.Clone()
+	d.QueryTags = SQLCommenter("checkout")
+	q = new(batchQuerier)
+	if err := d.NewBatch().Delete("event", &event{ID: 6}).Flush(testCtx, q); err != nil {
+		t.Fatalf("Batch.Flush error: %v", err)
+	}
+	if expected := `DELETE FROM "event" WHERE "id"=$1 /*app='checkout',op='Batch'*/`; q.batches[0][0].Query != expected {
+		t.Errorf("BatchExecer with QueryTags: expected %s, got %s", expected, q.batches[0][0].Query)
+	}
+}
+
+func TestBatchWritten(t *testing.T) {
+	once.Do(setup)
+	if _, err := db.Exec(personHistorySchema); err != nil {
+		t.Fatalf("DB error: %v", err)
+	}
+	defer db.Exec("drop table person_history")
+	defer db.Exec("delete from person")
+
+	var ops []WriteOp
+	d := SQLite.Clone()
+	d.History = map[string]bool{"person": true}
+	d.OnChange = func(ctx context.Context, event ChangeEvent) {
+		ops = append(ops, event.Op)
+	}
+
+	alice := &Person{Name: "Alice", Email: "alice@alice.com", Opened: time.Now()}
+	bob := &Person{Name: "Bob", Email: "bob@bob.com", Opened: time.Now()}
+	if err := d.NewBatch().Insert("person", alice).Insert("person", bob).Flush(testCtx, db); err != nil {
+		t.Fatalf("Batch.Flush error: %v", err)
+	}
+	alice.Name = "Alicia"
+	if err := d.NewBatch().Update("person", alice).Delete("person", bob).Flush(testCtx, db); err != nil {
+		t.Fatalf("Batch.Flush error: %v", err)
+	}
+	if expected := []WriteOp{OpInsert, OpInsert, OpUpdate, OpDelete}; !reflect.DeepEqual(ops, expected) {
+		t.Errorf("OnChange: expected %v, got %v", expected, ops)
+	}
+	var count int
+	if err := db.QueryRow("select count(*) from person_history").Scan(&count); err != nil || count != 2 {
+		t.Errorf("History: expected 2 old versions, got %d, %v", count, err)
+	}
+
+	// a statement that matched no row is not reported
+	ops = nil
+	if err := d.NewBatch().Update("person", bob).Flush(testCtx, db); err != nil {
+		t.Fatalf("Batch.Flush error: %v", err)
+	}
+	if len(ops) != 0 {
+		t.Errorf("OnChange: expected no events for a missing row, got %v", ops)
+	}
+}
+
+func TestMultiStatement(t *testing.T) {
+	setupEvents(t)
+	defer db.Exec("drop table event")
+
+	a := &event{Key: "a", Payload: "one"}
+	b := &event{Key: "b", Payload: "two"}
+	q := SQLite.MultiStatement(db)
+	if err := SQLite.NewBatch().Insert("event", a).Insert("event", b).Flush(testCtx, q); err != nil {
+		t.Fatalf("Batch.Flush error: %v", err)
+	}
+	if a.ID != 1 || b.ID != 2 {
+		t.Errorf("MultiStatement insert: expected pks 1 and 2, got %d and %d", a.ID, b.ID)
+	}
+
+	a.Payload = "changed"
+	c := &event{Key: "c", Payload: "three"}
+	batch := SQLite.NewBatch().Update("event", a).Delete("event", b).Insert("event", c)
+	if err := batch.Flush(testCtx, q); err != nil {
+		t.Fatalf("Batch.Flush error: %v", err)
+	}
+	var events []*event
+	if err := SQLite.QueryAll(testCtx, db, &events, "select * from event order by id"); err != nil {
+		t.Fatalf("QueryAll error: %v", err)
+	}
+	if len(events) != 2 || events[0].Payload != "changed" || events[1].ID != c.ID || c.ID == 0 {
+		t.Errorf("MultiStatement update, delete, and insert: unexpected rows %+v", events)
+	}
+
+	// numbered placeholders cannot be joined
+	err := PostgreSQL.NewBatch().Update("event", a).Flush(testCtx, PostgreSQL.MultiStatement(new(recordingQuerier)))
+	if err == nil {
+		t.Errorf("MultiStatement with $1 placeholders: expected an error")
+	}
+}
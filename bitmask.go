@@ -0,0 +1,206 @@
+package meddlerx
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// SetMeddler converts bit flag fields to and from MySQL SET and BIT
+// columns, and integer columns in other databases. Its value lists the
+// names of the flags, separated by commas, starting with bit 0, as in the
+// definition of a SET column. Register one for each set of flags:
+//
+//	meddler.Register("perms", meddler.SetMeddler("read,write,admin"))
+//
+// Fields may be any integer type, holding the mask, or []string, holding
+// the names of the flags that are set. []string fields need flag names,
+// but the "bitmask" meddler, which has none, works for integers, storing
+// MySQL BIT columns.
+//
+// When reading, integers, decimal text, comma-separated flag names, and
+// the big-endian bytes of a MySQL BIT column are all accepted. A NULL is
+// read as no flags.
+type SetMeddler string
+
+func (names SetMeddler) members() []string {
+	if names == "" {
+		return nil
+	}
+	return strings.Split(string(names), ",")
+}
+
+// PreRead is called before a Scan operation for fields that have a SetMeddler
+func (names SetMeddler) PreRead(fieldAddr interface{}) (scanTarget interface{}, err error) {
+	return new(interface{}), nil
+}
+
+// PostRead is called after a Scan operation for fields that have a SetMeddler
+func (names SetMeddler) PostRead(fieldAddr, scanTarget interface{}) error {
+	ptr := scanTarget.(*interface{})
+	if ptr == nil {
+		return fmt.Errorf("SetMeddler.PostRead: nil pointer")
+	}
+
+	var mask uint64
+	switch value := (*ptr).(type) {
+	case nil:
+	case int64:
+		mask = uint64(value)
+	case []byte:
+		var err error
+		if mask, err = names.parse(value); err != nil {
+			return fmt.Errorf("SetMeddler.PostRead: %w", err)
+		}
+	case string:
+		var err error
+		if mask, err = names.parse([]byte(value)); err != nil {
+			return fmt.Errorf("SetMeddler.PostRead: %w", err)
+		}
+	default:
+		return fmt.Errorf("SetMeddler.PostRead: unexpected value type %T", value)
+	}
+
+	fieldVal := reflect.ValueOf(fieldAddr).Elem()
+	switch fieldVal.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fieldVal.SetInt(int64(mask))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		fieldVal.SetUint(mask)
+	case reflect.Slice:
+		if fieldVal.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("SetMeddler.PostRead: unsupported field type %v", fieldVal.Type())
+		}
+		set, err := names.names(mask)
+		if err != nil {
+			return fmt.Errorf("SetMeddler.PostRead: %w", err)
+		}
+		fieldVal.Set(reflect.ValueOf(set).Convert(fieldVal.Type()))
+	default:
+		return fmt.Errorf("SetMeddler.PostRead: unsupported field type %v", fieldVal.Type())
+	}
+	return nil
+}
+
+// PreWrite is called before an Insert or Update operation for fields that
+// have a SetMeddler. Without a Database to ask, it writes the mask.
+func (names SetMeddler) PreWrite(field interface{}) (saveValue interface{}, err error) {
+	return names.PreWriteDialect(SQLite, field)
+}
+
+// PreWriteDialect implements DialectWriter for fields with a SetMeddler
+func (names SetMeddler) PreWriteDialect(d *Database, field interface{}) (saveValue interface{}, err error) {
+	var mask uint64
+	v := reflect.ValueOf(field)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		mask = uint64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		mask = v.Uint()
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return nil, fmt.Errorf("SetMeddler.PreWrite: unsupported field type %T", field)
+		}
+		members := names.members()
+		for i := 0; i < v.Len(); i++ {
+			bit := -1
+			for j, member := range members {
+				if member == v.Index(i).String() {
+					bit = j
+					break
+				}
+			}
+			if bit < 0 {
+				return nil, fmt.Errorf("SetMeddler.PreWrite: unknown flag %q", v.Index(i).String())
+			}
+			mask |= 1 << uint(bit)
+		}
+	default:
+		return nil, fmt.Errorf("SetMeddler.PreWrite: unsupported field type %T", field)
+	}
+
+	if d.Dialect == DialectMySQL && names != "" {
+		set, err := names.names(mask)
+		if err != nil {
+			return nil, fmt.Errorf("SetMeddler.PreWrite: %w", err)
+		}
+		return strings.Join(set, ","), nil
+	}
+	return int64(mask), nil
+}
+
+// ColumnType implements ColumnTyper for fields with a SetMeddler
+func (names SetMeddler) ColumnType(d *Database, fieldType reflect.Type) (string, bool, error) {
+	if d.Dialect != DialectMySQL {
+		return d.integerType(reflect.Int64), false, nil
+	}
+	members := names.members()
+	if len(members) == 0 {
+		return "BIT(64)", false, nil
+	}
+	quoted := make([]string, len(members))
+	for i, member := range members {
+		quoted[i] = "'" + strings.ReplaceAll(member, "'", "''") + "'"
+	}
+	return "SET(" + strings.Join(quoted, ",") + ")", false, nil
+}
+
+// parse reads a mask from decimal text, flag names, or BIT column bytes.
+func (names SetMeddler) parse(raw []byte) (uint64, error) {
+	if len(raw) == 0 {
+		return 0, nil
+	}
+	if n, err := strconv.ParseUint(string(raw), 10, 64); err == nil {
+		return n, nil
+	}
+	if names != "" && isText(raw) {
+		var mask uint64
+		members := names.members()
+	flags:
+		for _, flag := range strings.Split(string(raw), ",") {
+			for bit, member := range members {
+				if member == flag {
+					mask |= 1 << uint(bit)
+					continue flags
+				}
+			}
+			return 0, fmt.Errorf("unknown flag %q", flag)
+		}
+		return mask, nil
+	}
+	if len(raw) > 8 {
+		return 0, fmt.Errorf("bit value of %d bytes is too long", len(raw))
+	}
+	var buf [8]byte
+	copy(buf[8-len(raw):], raw)
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+// names returns the names of the flags set in mask.
+func (names SetMeddler) names(mask uint64) ([]string, error) {
+	members := names.members()
+	set := []string{}
+	for bit := 0; mask != 0; bit++ {
+		if mask&1 != 0 {
+			if bit >= len(members) {
+				return nil, fmt.Errorf("bit %d has no flag name", bit)
+			}
+			set = append(set, members[bit])
+		}
+		mask >>= 1
+	}
+	return set, nil
+}
+
+// isText reports whether raw is printable ASCII, as opposed to the
+// bytes of a BIT column.
+func isText(raw []byte) bool {
+	for _, c := range raw {
+		if c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
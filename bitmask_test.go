@@ -0,0 +1,77 @@
+package meddlerx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type account struct {
+	ID    int64    `meddler:"id,pk"`
+	Perms uint8    `meddler:"perms,perms"`
+	Roles []string `meddler:"roles,perms"`
+	Flags int64    `meddler:"flags,bitmask"`
+}
+
+func TestSetMeddler(t *testing.T) {
+	once.Do(setup)
+	Register("perms", SetMeddler("read,write,admin"))
+	if err := SQLite.EnsureTable(testCtx, db, "account", new(account)); err != nil {
+		t.Fatalf("EnsureTable error: %v", err)
+	}
+	defer db.Exec("drop table account")
+
+	elt := &account{Perms: 5, Roles: []string{"write", "admin"}, Flags: 1 << 40}
+	if err := SQLite.Insert(testCtx, db, "account", elt); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	var perms, roles, flags int64
+	if err := db.QueryRow("select perms, roles, flags from account where id = ?", elt.ID).Scan(&perms, &roles, &flags); err != nil {
+		t.Fatalf("DB error: %v", err)
+	}
+	if perms != 5 || roles != 6 || flags != 1<<40 {
+		t.Errorf("SetMeddler: expected masks 5, 6, %d, got %d, %d, %d", int64(1<<40), perms, roles, flags)
+	}
+
+	loaded := new(account)
+	if err := SQLite.Load(testCtx, db, "account", loaded, elt.ID); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, elt) {
+		t.Errorf("SetMeddler round trip: expected %+v, got %+v", elt, loaded)
+	}
+
+	// SET and BIT values, as MySQL returns them
+	if _, err := db.Exec("update account set perms = 'read,admin', roles = '', flags = ? where id = ?", []byte{0x01, 0x02}, elt.ID); err != nil {
+		t.Fatalf("DB error: %v", err)
+	}
+	if err := SQLite.Load(testCtx, db, "account", loaded, elt.ID); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if loaded.Perms != 5 || len(loaded.Roles) != 0 || loaded.Flags != 0x0102 {
+		t.Errorf("SetMeddler parsing: got %+v", loaded)
+	}
+
+	values, err := MySQL.SomeValues(elt, []string{"perms", "roles", "flags"})
+	if err != nil {
+		t.Fatalf("SomeValues error: %v", err)
+	}
+	if expected := []interface{}{"read,admin", "write,admin", int64(1 << 40)}; !reflect.DeepEqual(values, expected) {
+		t.Errorf("SetMeddler: expected %v in MySQL, got %v", expected, values)
+	}
+
+	elt.Roles = []string{"owner"}
+	if err := SQLite.Update(testCtx, db, "account", elt); err == nil {
+		t.Errorf("SetMeddler: expected an error for an unknown flag")
+	}
+
+	q, err := MySQL.CreateTableSQL("account", new(account))
+	if err != nil {
+		t.Fatalf("CreateTableSQL error: %v", err)
+	}
+	for _, want := range []string{"`perms` SET('read','write','admin')", "`flags` BIT(64)"} {
+		if !strings.Contains(q, want) {
+			t.Errorf("CreateTableSQL: expected %s in\n%s", want, q)
+		}
+	}
+}
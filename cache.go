@@ -0,0 +1,159 @@
+package meddlerx
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/gob"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+func init() {
+	// drivers return these inside interface values
+	gob.Register(time.Time{})
+}
+
+// Cache is a read-through cache for Load, keyed by table and primary key.
+// Entries are opaque byte strings, so it can be backed by an external
+// store such as Redis or memcached. Implementations must be safe for
+// concurrent use, and should treat their own failures as misses.
+type Cache interface {
+	Get(ctx context.Context, table string, pk int64) (data []byte, ok bool)
+	Set(ctx context.Context, table string, pk int64, data []byte, ttl time.Duration)
+	Invalidate(ctx context.Context, table string, pk int64)
+}
+
+// cachedRow is what gets stored in a Cache: the row as the driver returned
+// it, so that meddlers run the same way on a hit as on a miss.
+type cachedRow struct {
+	Columns []string
+	Values  []interface{}
+}
+
+// loadCached is Load for a Database with a Cache. q is the query that
+// fetches the row on a miss, given arg, the key as passed to the database.
+func (d *Database) loadCached(ctx context.Context, db Querier, table string, dst interface{}, pk int64, arg interface{}, q string) error {
+	var row cachedRow
+	if data, ok := d.Cache.Get(ctx, table, pk); ok {
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&row); err == nil {
+			return d.assignRow(dst, row.Columns, row.Values)
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, q, arg)
+	if err != nil {
+		return d.queryError("Load", table, q, []interface{}{arg}, err)
+	}
+	defer rows.Close()
+	if row.Columns, err = rows.Columns(); err != nil {
+		return err
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	row.Values = make([]interface{}, len(row.Columns))
+	targets := make([]interface{}, len(row.Columns))
+	for i := range targets {
+		targets[i] = &row.Values[i]
+	}
+	if err := rows.Scan(targets...); err != nil {
+		return err
+	}
+	for i, value := range row.Values {
+		if b, ok := value.([]byte); ok {
+			row.Values[i] = append([]byte(nil), b...)
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&row); err != nil {
+		if Debug {
+			d.logf("meddler.Load: not caching %s row %d: %v", table, pk, err)
+		}
+	} else {
+		d.Cache.Set(ctx, table, pk, buf.Bytes(), d.CacheTTL)
+	}
+	return d.assignRow(dst, row.Columns, row.Values)
+}
+
+// assignRow copies driver values for the named columns into dst.
+func (d *Database) assignRow(dst interface{}, columns []string, values []interface{}) error {
+	data, err := getFields(reflect.TypeOf(dst))
+	if err != nil {
+		return err
+	}
+	targets, err := d.targets(data, nil, dst, columns)
+	if err != nil {
+		return err
+	}
+	for i, target := range targets {
+		if err := assignValue(target, values[i]); err != nil {
+			return fmt.Errorf("meddler.Load: column %s: %w", columns[i], err)
+		}
+	}
+	return d.writeTargets(data, dst, columns, targets)
+}
+
+// MemoryCache is a Cache that keeps entries in process memory. The zero
+// value is ready to use.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+}
+
+type memoryCacheEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+func memoryCacheKey(table string, pk int64) string {
+	return table + "\x00" + strconv.FormatInt(pk, 10)
+}
+
+// Get returns the entry for table and pk, if it exists and has not expired.
+func (c *MemoryCache) Get(ctx context.Context, table string, pk int64) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := memoryCacheKey(table, pk)
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.data, true
+}
+
+// Set stores data for table and pk. A ttl of zero keeps it until it is
+// invalidated.
+func (c *MemoryCache) Set(ctx context.Context, table string, pk int64, data []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]memoryCacheEntry)
+	}
+	entry := memoryCacheEntry{data: data}
+	if ttl > 0 {
+		entry.expires = time.Now().Add(ttl)
+	}
+	c.entries[memoryCacheKey(table, pk)] = entry
+}
+
+// Invalidate removes the entry for table and pk.
+func (c *MemoryCache) Invalidate(ctx context.Context, table string, pk int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, memoryCacheKey(table, pk))
+}
@@ -0,0 +1,130 @@
+package meddlerx
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+// countingCache counts hits on a MemoryCache.
+type countingCache struct {
+	MemoryCache
+	hits int
+}
+
+func (c *countingCache) Get(ctx context.Context, table string, pk int64) ([]byte, bool) {
+	data, ok := c.MemoryCache.Get(ctx, table, pk)
+	if ok {
+		c.hits++
+	}
+	return data, ok
+}
+
+func TestCache(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	cache := new(countingCache)
+	d := SQLite.Clone()
+	d.Cache = cache
+
+	for i := 0; i < 2; i++ {
+		elt := new(Person)
+		if err := d.Load(testCtx, db, "person", elt, 2); err != nil {
+			t.Fatalf("Load error on Bob: %v", err)
+		}
+		bob.ID = 2
+		personEqual(t, elt, bob)
+	}
+	if cache.hits != 1 {
+		t.Errorf("Cache: expected 1 hit, got %d", cache.hits)
+	}
+
+	// writes invalidate the entry
+	elt := new(Person)
+	if err := d.Load(testCtx, db, "person", elt, 2); err != nil {
+		t.Fatalf("Load error on Bob: %v", err)
+	}
+	elt.Name = "Robert"
+	if err := d.Update(testCtx, db, "person", elt); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	reloaded := new(Person)
+	if err := d.Load(testCtx, db, "person", reloaded, 2); err != nil {
+		t.Fatalf("Load error on Bob: %v", err)
+	}
+	if reloaded.Name != "Robert" {
+		t.Errorf("Cache: expected the updated name, got %q", reloaded.Name)
+	}
+	if err := d.Delete(testCtx, db, "person", reloaded); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	if err := d.Load(testCtx, db, "person", reloaded, 2); err != sql.ErrNoRows {
+		t.Errorf("Load after Delete: expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestMemoryCacheTTL(t *testing.T) {
+	var cache MemoryCache
+	cache.Set(testCtx, "person", 1, []byte("a"), time.Nanosecond)
+	cache.Set(testCtx, "person", 2, []byte("b"), 0)
+	time.Sleep(time.Millisecond)
+	if _, ok := cache.Get(testCtx, "person", 1); ok {
+		t.Errorf("MemoryCache: expected entry 1 to expire")
+	}
+	if data, ok := cache.Get(testCtx, "person", 2); !ok || string(data) != "b" {
+		t.Errorf("MemoryCache: expected entry 2, got %q, %v", data, ok)
+	}
+	cache.Invalidate(testCtx, "person", 2)
+	if _, ok := cache.Get(testCtx, "person", 2); ok {
+		t.Errorf("MemoryCache: expected entry 2 to be invalidated")
+	}
+}
+
+func TestCacheUpsert(t *testing.T) {
+	setupEvents(t)
+	defer db.Exec("drop table event")
+
+	d := SQLite.Clone()
+	d.Cache = new(MemoryCache)
+	a := &event{Key: "a", Payload: "first"}
+	if err := d.Insert(testCtx, db, "event", a); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	cached := func() string {
+		loaded := new(event)
+		if err := d.Load(testCtx, db, "event", loaded, a.ID); err != nil {
+			t.Fatalf("Load error: %v", err)
+		}
+		return loaded.Payload
+	}
+
+	// every write of a record invalidates its entry
+	writes := []struct {
+		payload string
+		write   func(src *event) error
+	}{
+		{"upserted", func(src *event) error {
+			_, err := d.Upsert(testCtx, db, "event", src, "key")
+			return err
+		}},
+		{"saved on key", func(src *event) error {
+			_, err := d.SaveOrUpdateOn(testCtx, db, "event", src, "key")
+			return err
+		}},
+		{"batched", func(src *event) error {
+			return d.NewBatch().Update("event", src).Flush(testCtx, db)
+		}},
+	}
+	for _, w := range writes {
+		cached()
+		if err := w.write(&event{ID: a.ID, Key: "a", Payload: w.payload}); err != nil {
+			t.Fatalf("%s: write error: %v", w.payload, err)
+		}
+		if payload := cached(); payload != w.payload {
+			t.Errorf("%s: expected the new row, got %q", w.payload, payload)
+		}
+	}
+}
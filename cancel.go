@@ -0,0 +1,71 @@
+package meddlerx
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// cancelTimeout limits how long the statement that cancels a query past
+// its deadline may take.
+const cancelTimeout = 5 * time.Second
+
+// cancelStatements returns the query that reads the server's id for the
+// current connection and the statement, with a %d for that id, that
+// cancels whatever the connection is running. Both are empty for dialects
+// whose drivers already stop the server when a context ends.
+func (d *Database) cancelStatements() (idQuery, cancelQuery string) {
+	switch d.Dialect {
+	case DialectPostgreSQL:
+		return "SELECT pg_backend_pid()", "SELECT pg_cancel_backend(%d)"
+	case DialectMySQL:
+		return "SELECT CONNECTION_ID()", "KILL QUERY %d"
+	}
+	return "", ""
+}
+
+// queryAllCancelable runs QueryAll on a connection of its own and cancels
+// the query on the server if ctx's deadline passes first.
+func (d *Database) queryAllCancelable(ctx context.Context, pool *sql.DB, dst interface{}, query string, args []interface{}, idQuery, cancelQuery string) error {
+	conn, err := pool.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("meddler.QueryAll: %w", err)
+	}
+	defer conn.Close()
+
+	var id int64
+	if err := conn.QueryRowContext(ctx, idQuery).Scan(&id); err != nil {
+		return fmt.Errorf("meddler.QueryAll: reading connection id: %w", err)
+	}
+
+	stop := make(chan struct{})
+	canceled := make(chan bool, 1)
+	go func() {
+		select {
+		case <-stop:
+			canceled <- false
+		case <-ctx.Done():
+			if ctx.Err() != context.DeadlineExceeded {
+				canceled <- false
+				return
+			}
+			cancelCtx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+			defer cancel()
+			if _, err := pool.ExecContext(cancelCtx, fmt.Sprintf(cancelQuery, id)); err != nil {
+				d.logf("meddler.QueryAll: cancelling query on connection %d: %v", id, err)
+			}
+			canceled <- true
+		}
+	}()
+
+	err = d.QueryAll(ctx, conn, dst, query, args...)
+	close(stop)
+	if <-canceled {
+		// the connection may still be winding down the cancelled query, so
+		// keep it out of the pool
+		conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	}
+	return err
+}
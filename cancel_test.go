@@ -0,0 +1,120 @@
+package meddlerx
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// cancelServer fakes the PostgreSQL functions used by CancelOnDeadline on
+// top of SQLite, with a wait() function that blocks until the connection
+// running it is cancelled.
+var cancelServer struct {
+	sync.Mutex
+	nextID    int64
+	lookups   int64
+	waiting   map[int64]chan struct{}
+	cancelled []int64
+}
+
+func init() {
+	cancelServer.waiting = make(map[int64]chan struct{})
+	sql.Register("sqlite3_cancel", &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			cancelServer.Lock()
+			cancelServer.nextID++
+			id := cancelServer.nextID
+			release := make(chan struct{})
+			cancelServer.waiting[id] = release
+			cancelServer.Unlock()
+
+			if err := conn.RegisterFunc("pg_backend_pid", func() int64 {
+				atomic.AddInt64(&cancelServer.lookups, 1)
+				return id
+			}, false); err != nil {
+				return err
+			}
+			if err := conn.RegisterFunc("pg_cancel_backend", func(target int64) bool {
+				cancelServer.Lock()
+				defer cancelServer.Unlock()
+				cancelServer.cancelled = append(cancelServer.cancelled, target)
+				if ch := cancelServer.waiting[target]; ch != nil {
+					close(ch)
+					delete(cancelServer.waiting, target)
+				}
+				return true
+			}, false); err != nil {
+				return err
+			}
+			return conn.RegisterFunc("wait", func() bool {
+				select {
+				case <-release:
+				case <-time.After(5 * time.Second):
+				}
+				return true
+			}, false)
+		},
+	})
+}
+
+func TestCancelOnDeadline(t *testing.T) {
+	pool, err := sql.Open("sqlite3_cancel", ":memory:")
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	defer pool.Close()
+	cancelServer.Lock()
+	cancelServer.cancelled = nil
+	cancelServer.Unlock()
+	atomic.StoreInt64(&cancelServer.lookups, 0)
+
+	d := SQLite.Clone(WithDialect(DialectPostgreSQL))
+	d.CancelOnDeadline = true
+	type row struct {
+		ID int64 `meddler:"id"`
+	}
+
+	// without a deadline the query runs as usual
+	var rows []*row
+	if err := d.QueryAll(context.Background(), pool, &rows, "SELECT 1 AS id"); err != nil || len(rows) != 1 {
+		t.Fatalf("QueryAll without deadline: got %d rows, %v", len(rows), err)
+	}
+	if n := atomic.LoadInt64(&cancelServer.lookups); n != 0 {
+		t.Errorf("expected no connection id lookups without a deadline, got %d", n)
+	}
+
+	// a query that finishes in time is not cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rows = nil
+	if err := d.QueryAll(ctx, pool, &rows, "SELECT 2 AS id"); err != nil || len(rows) != 1 || rows[0].ID != 2 {
+		t.Fatalf("QueryAll within deadline: got %v, %v", rows, err)
+	}
+
+	// a query still running at the deadline is cancelled on the server
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	rows = nil
+	if err := d.QueryAll(ctx, pool, &rows, "SELECT 3 AS id WHERE wait()"); err == nil {
+		t.Errorf("expected an error from a query past its deadline")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("query was not cancelled, took %v", elapsed)
+	}
+
+	cancelServer.Lock()
+	cancelled := cancelServer.cancelled
+	cancelServer.Unlock()
+	if len(cancelled) != 1 {
+		t.Fatalf("expected one cancelled connection, got %v", cancelled)
+	}
+	if n := atomic.LoadInt64(&cancelServer.lookups); n != 2 {
+		t.Errorf("expected 2 connection id lookups, got %d", n)
+	}
+}
@@ -0,0 +1,186 @@
+package meddlerx
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ChangeEvent describes a write, for publishing domain events. Record is
+// the struct pointer that was written; it is not copied, so callbacks
+// should not expect it to stay unchanged after the write returns.
+type ChangeEvent struct {
+	Op     WriteOp
+	Table  string
+	PK     int64
+	Record interface{}
+}
+
+// EventTx is a transaction whose writes are reported to the OnChange
+// callback of the Database only once it commits. Writes made with a plain
+// *sql.Tx are never reported, since meddler cannot tell whether they were
+// committed; writes made outside a transaction are reported right away.
+type EventTx struct {
+	*sql.Tx
+
+	d      *Database
+	ctx    context.Context
+	mu     sync.Mutex
+	events []ChangeEvent
+}
+
+// BeginEvents starts a transaction on db that reports its writes to
+// OnChange when it commits.
+func (d *Database) BeginEvents(ctx context.Context, db *sql.DB, opts *sql.TxOptions) (*EventTx, error) {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("meddler.BeginEvents: %w", err)
+	}
+	return &EventTx{Tx: tx, d: d, ctx: ctx}, nil
+}
+
+// BeginEvents using the Default Database type
+func BeginEvents(ctx context.Context, db *sql.DB, opts *sql.TxOptions) (*EventTx, error) {
+	return Default.BeginEvents(ctx, db, opts)
+}
+
+// Commit commits the transaction and then passes the queued events to
+// OnChange, in the order the writes were made. Cache entries for the
+// written rows are invalidated again, in case a concurrent Load cached the
+// old row before the commit.
+func (tx *EventTx) Commit() error {
+	if err := tx.Tx.Commit(); err != nil {
+		return err
+	}
+	tx.mu.Lock()
+	events := tx.events
+	tx.events = nil
+	tx.mu.Unlock()
+	for _, event := range events {
+		if tx.d.Cache != nil {
+			tx.d.Cache.Invalidate(tx.ctx, event.Table, event.PK)
+		}
+		if tx.d.OnChange != nil {
+			tx.d.OnChange(tx.ctx, event)
+		}
+	}
+	return nil
+}
+
+// Rollback aborts the transaction and discards the queued events.
+func (tx *EventTx) Rollback() error {
+	tx.mu.Lock()
+	tx.events = nil
+	tx.mu.Unlock()
+	return tx.Tx.Rollback()
+}
+
+// queued returns the number of events queued so far. It may be called on
+// a nil EventTx.
+func (tx *EventTx) queued() int {
+	if tx == nil {
+		return 0
+	}
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	return len(tx.events)
+}
+
+// discard drops the events queued after the first n, for writes that were
+// rolled back to a savepoint. It may be called on a nil EventTx.
+func (tx *EventTx) discard(n int) {
+	if tx == nil {
+		return
+	}
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if n < len(tx.events) {
+		tx.events = tx.events[:n]
+	}
+}
+
+// unwrap returns the Querier wrapped by meddler's own wrappers of db.
+func unwrap(db Querier) Querier {
+	for {
+		switch q := db.(type) {
+		case *stmtQuerier:
+			db = q.db
+		case *MultiStatementExecer:
+			db = q.Querier
+		default:
+			return db
+		}
+	}
+}
+
+// inTx reports whether db is a transaction, including an EventTx.
+func inTx(db Querier) bool {
+	switch unwrap(db).(type) {
+	case *sql.Tx, *EventTx:
+		return true
+	}
+	return false
+}
+
+// publish sends event to OnChange, or queues it if db is an EventTx.
+func (d *Database) publish(ctx context.Context, db Querier, event ChangeEvent) {
+	switch db := unwrap(db).(type) {
+	case *EventTx:
+		db.mu.Lock()
+		db.events = append(db.events, event)
+		db.mu.Unlock()
+	case *sql.Tx:
+		// the outcome is unknown, so there is nothing safe to report
+	default:
+		if d.OnChange != nil {
+			d.OnChange(ctx, event)
+		}
+	}
+}
+
+// OutboxMessage is a row of a transactional outbox table, as written by
+// InsertOutbox. A matching table for SQLite looks like:
+//
+//	CREATE TABLE outbox (
+//		id INTEGER PRIMARY KEY,
+//		op TEXT NOT NULL,
+//		table_name TEXT NOT NULL,
+//		row_pk INTEGER NOT NULL,
+//		payload TEXT NOT NULL,
+//		created DATETIME NOT NULL
+//	)
+type OutboxMessage struct {
+	ID        int64     `meddler:"id,pk"`
+	Op        string    `meddler:"op"`
+	TableName string    `meddler:"table_name"`
+	RowPK     int64     `meddler:"row_pk"`
+	Payload   string    `meddler:"payload"`
+	Created   time.Time `meddler:"created,utctime"`
+}
+
+// InsertOutbox records event in the outbox table, with the record encoded
+// as JSON. Called with the same transaction as the write, the message
+// commits or rolls back together with it, and a separate relay can publish
+// it later.
+func (d *Database) InsertOutbox(ctx context.Context, db Querier, table string, event ChangeEvent) error {
+	payload, err := json.Marshal(event.Record)
+	if err != nil {
+		return fmt.Errorf("meddler.InsertOutbox: %w", err)
+	}
+	msg := &OutboxMessage{
+		Op:        string(event.Op),
+		TableName: event.Table,
+		RowPK:     event.PK,
+		Payload:   string(payload),
+		Created:   time.Now(),
+	}
+	return d.Insert(ctx, db, table, msg)
+}
+
+// InsertOutbox using the Default Database type
+func InsertOutbox(ctx context.Context, db Querier, table string, event ChangeEvent) error {
+	return Default.InsertOutbox(ctx, db, table, event)
+}
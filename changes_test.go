@@ -0,0 +1,110 @@
+package meddlerx
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+const outboxSchema = `create table outbox (
+	id integer primary key,
+	op text not null,
+	table_name text not null,
+	row_pk integer not null,
+	payload text not null,
+	created datetime not null
+)`
+
+func TestOnChange(t *testing.T) {
+	setupEvents(t)
+	defer db.Exec("drop table event")
+
+	var events []ChangeEvent
+	d := SQLite.Clone()
+	d.OnChange = func(ctx context.Context, event ChangeEvent) {
+		events = append(events, event)
+	}
+
+	// outside a transaction, events are published right away
+	a := &event{Key: "a", Payload: "first"}
+	if err := d.Insert(testCtx, db, "event", a); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	expected := []ChangeEvent{{Op: OpInsert, Table: "event", PK: a.ID, Record: a}}
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("OnChange: expected %+v, got %+v", expected, events)
+	}
+
+	// inside an EventTx, only once it commits
+	tx, err := d.BeginEvents(testCtx, db, nil)
+	if err != nil {
+		t.Fatalf("BeginEvents error: %v", err)
+	}
+	a.Payload = "second"
+	if err := d.Update(testCtx, tx, "event", a); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	if len(events) != 1 {
+		t.Errorf("OnChange: expected no events before commit, got %+v", events[1:])
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit error: %v", err)
+	}
+	expected = append(expected, ChangeEvent{Op: OpUpdate, Table: "event", PK: a.ID, Record: a})
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("OnChange: expected %+v, got %+v", expected, events)
+	}
+
+	// and never if it rolls back
+	tx, err = d.BeginEvents(testCtx, db, nil)
+	if err != nil {
+		t.Fatalf("BeginEvents error: %v", err)
+	}
+	if err := d.Delete(testCtx, tx, "event", a); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback error: %v", err)
+	}
+	if len(events) != 2 {
+		t.Errorf("OnChange: expected no events after rollback, got %+v", events[2:])
+	}
+}
+
+func TestInsertOutbox(t *testing.T) {
+	setupEvents(t)
+	defer db.Exec("drop table event")
+	if _, err := db.Exec(outboxSchema); err != nil {
+		t.Fatalf("creating outbox table: %v", err)
+	}
+	defer db.Exec("drop table outbox")
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Begin error: %v", err)
+	}
+	e := &event{Key: "a", Payload: "first"}
+	if err := SQLite.Insert(testCtx, tx, "event", e); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	change := ChangeEvent{Op: OpInsert, Table: "event", PK: e.ID, Record: e}
+	if err := SQLite.InsertOutbox(testCtx, tx, "outbox", change); err != nil {
+		t.Fatalf("InsertOutbox error: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit error: %v", err)
+	}
+
+	var msgs []*OutboxMessage
+	if err := SQLite.QueryAll(testCtx, db, &msgs, "select * from outbox"); err != nil {
+		t.Fatalf("QueryAll error: %v", err)
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("outbox: expected 1 message, got %d", len(msgs))
+	}
+	msg := msgs[0]
+	if msg.Op != "insert" || msg.TableName != "event" || msg.RowPK != e.ID ||
+		msg.Payload != `{"ID":1,"Key":"a","Payload":"first"}` || msg.Created.IsZero() {
+		t.Errorf("outbox: got %+v", msg)
+	}
+}
@@ -0,0 +1,13 @@
+// Command meddlervet checks meddler struct tags. It can be run on
+// directories or as a go vet tool:
+//
+//	go vet -vettool=$(which meddlervet) ./...
+//
+// See package meddlervet for the checks it makes.
+package main
+
+import "github.com/scanfully/meddlerx/meddlervet"
+
+func main() {
+	meddlervet.Main()
+}
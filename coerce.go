@@ -0,0 +1,296 @@
+package meddlerx
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Coercion selects how a Database converts the values drivers return to
+// the types of struct fields. Drivers differ: pq returns NUMERIC as text,
+// MySQL returns most values as []byte unless asked otherwise, and SQLite
+// returns whatever type was stored, so with CoerceNone the same struct can
+// scan on one driver and fail on another. The other modes apply one policy
+// to fields of integer, float, string, []byte, bool, and time.Time types,
+// whatever the driver:
+//
+//   - Integers take integers, floats, and decimal text, if the value fits.
+//   - Floats take integers, floats, and decimal text.
+//   - Strings and []byte take text or bytes as is, and other values in
+//     their usual text form.
+//   - Bools take 0 and 1, and the text forms strconv.ParseBool accepts.
+//   - Times take times, and text in RFC 3339 or the layouts MySQL and
+//     SQLite use.
+//
+// CoerceStrict refuses conversions that lose information, such as 1.5 to
+// an int or 2 to a bool, and CoerceLenient truncates floats toward zero
+// and takes any non-zero number as true. Neither accepts NULL unless the
+// Database has NullAsZero set. Fields with meddlers other than the
+// identity meddler scan through their own targets, which are converted
+// the same way when they are of the types above.
+type Coercion int
+
+// Coercion modes
+const (
+	// CoerceNone leaves conversion to the sql package and the driver. This
+	// is the default.
+	CoerceNone Coercion = iota
+
+	// CoerceLenient converts wherever there is a sensible value.
+	CoerceLenient
+
+	// CoerceStrict converts only where no information is lost.
+	CoerceStrict
+)
+
+// coercible reports whether target is of a type Coercion applies to.
+func coercible(target interface{}) bool {
+	if _, ok := target.(sql.Scanner); ok {
+		return false
+	}
+	t := reflect.TypeOf(target)
+	if t.Kind() != reflect.Ptr {
+		return false
+	}
+	t = t.Elem()
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String, reflect.Bool:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	}
+	return t == timeType
+}
+
+// coercedTarget wraps a scan target for Coercion and NullAsZero.
+type coercedTarget struct {
+	target     interface{}
+	mode       Coercion
+	nullAsZero bool
+}
+
+// Scan implements sql.Scanner.
+func (c *coercedTarget) Scan(src interface{}) error {
+	dv := reflect.ValueOf(c.target).Elem()
+	if src == nil {
+		if c.nullAsZero || nullable(c.target) {
+			dv.Set(reflect.Zero(dv.Type()))
+			return nil
+		}
+		return fmt.Errorf("converting NULL to %s is unsupported", dv.Type())
+	}
+	if c.mode == CoerceNone || !coercible(c.target) {
+		return assignValue(c.target, src)
+	}
+	if err := coerce(dv, src, c.mode == CoerceStrict); err != nil {
+		return fmt.Errorf("converting %T to %s: %w", src, dv.Type(), err)
+	}
+	return nil
+}
+
+// textTimeLayouts are the text forms of times that drivers return when
+// they do not parse them.
+var textTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// coerce stores src, a driver value, in dv, which is of a type coercible
+// accepts.
+func coerce(dv reflect.Value, src interface{}, strict bool) error {
+	if b, ok := src.([]byte); ok {
+		src = string(b)
+	}
+
+	if dv.Type() == timeType {
+		switch v := src.(type) {
+		case time.Time:
+			dv.Set(reflect.ValueOf(v))
+			return nil
+		case string:
+			for _, layout := range textTimeLayouts {
+				if t, err := time.Parse(layout, v); err == nil {
+					dv.Set(reflect.ValueOf(t))
+					return nil
+				}
+			}
+			return fmt.Errorf("unrecognized time %q", v)
+		}
+		return fmt.Errorf("unsupported value")
+	}
+
+	switch dv.Kind() {
+	case reflect.String:
+		dv.SetString(textForm(src))
+		return nil
+	case reflect.Slice:
+		dv.SetBytes([]byte(textForm(src)))
+		return nil
+
+	case reflect.Bool:
+		switch v := src.(type) {
+		case bool:
+			dv.SetBool(v)
+			return nil
+		case int64:
+			if strict && v != 0 && v != 1 {
+				return fmt.Errorf("%d is not 0 or 1", v)
+			}
+			dv.SetBool(v != 0)
+			return nil
+		case float64:
+			if strict && v != 0 && v != 1 {
+				return fmt.Errorf("%g is not 0 or 1", v)
+			}
+			dv.SetBool(v != 0)
+			return nil
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				if f, ferr := strconv.ParseFloat(strings.TrimSpace(v), 64); ferr == nil {
+					return coerce(dv, f, strict)
+				}
+				return err
+			}
+			dv.SetBool(b)
+			return nil
+		}
+
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch v := src.(type) {
+		case int64:
+			f = float64(v)
+			if strict && int64(f) != v {
+				return fmt.Errorf("%d cannot be represented exactly", v)
+			}
+		case float64:
+			f = v
+		case bool:
+			if v {
+				f = 1
+			}
+		case string:
+			var err error
+			if f, err = strconv.ParseFloat(strings.TrimSpace(v), 64); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported value")
+		}
+		if dv.OverflowFloat(f) {
+			return fmt.Errorf("%g overflows %s", f, dv.Type())
+		}
+		dv.SetFloat(f)
+		return nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch v := src.(type) {
+		case int64:
+			return setInteger(dv, v, 0, false)
+		case float64:
+			return setFloatInteger(dv, v, strict)
+		case bool:
+			n := int64(0)
+			if v {
+				n = 1
+			}
+			return setInteger(dv, n, 0, false)
+		case string:
+			s := strings.TrimSpace(v)
+			if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return setInteger(dv, n, 0, false)
+			}
+			if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+				return setInteger(dv, 0, n, true)
+			}
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return err
+			}
+			return setFloatInteger(dv, f, strict)
+		}
+	}
+	return fmt.Errorf("unsupported value")
+}
+
+// setInteger stores n, or u if unsigned is set, in the integer value dv,
+// checking that it fits.
+func setInteger(dv reflect.Value, n int64, u uint64, unsigned bool) error {
+	switch dv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if unsigned {
+			if u > math.MaxInt64 {
+				return fmt.Errorf("%d overflows %s", u, dv.Type())
+			}
+			n = int64(u)
+		}
+		if dv.OverflowInt(n) {
+			return fmt.Errorf("%d overflows %s", n, dv.Type())
+		}
+		dv.SetInt(n)
+	default:
+		if !unsigned {
+			if n < 0 {
+				return fmt.Errorf("%d is negative", n)
+			}
+			u = uint64(n)
+		}
+		if dv.OverflowUint(u) {
+			return fmt.Errorf("%d overflows %s", u, dv.Type())
+		}
+		dv.SetUint(u)
+	}
+	return nil
+}
+
+// setFloatInteger stores f in the integer value dv. Fractions are an error
+// if strict is set, and are truncated otherwise.
+func setFloatInteger(dv reflect.Value, f float64, strict bool) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("%g is not a number", f)
+	}
+	if t := math.Trunc(f); t != f {
+		if strict {
+			return fmt.Errorf("%g is not an integer", f)
+		}
+		f = t
+	}
+	if f < 0 {
+		if f < math.MinInt64 {
+			return fmt.Errorf("%g overflows %s", f, dv.Type())
+		}
+		return setInteger(dv, int64(f), 0, false)
+	}
+	if f >= math.MaxUint64 {
+		return fmt.Errorf("%g overflows %s", f, dv.Type())
+	}
+	return setInteger(dv, 0, uint64(f), true)
+}
+
+// textForm returns the text form of a driver value.
+func textForm(src interface{}) string {
+	switch v := src.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(src)
+}
@@ -0,0 +1,98 @@
+package meddlerx
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCoerce(t *testing.T) {
+	tests := []struct {
+		dst     interface{}
+		src     interface{}
+		strict  interface{} // expected result in strict mode, or nil for an error
+		lenient interface{} // likewise for lenient mode
+	}{
+		{new(int32), int64(7), int32(7), int32(7)},
+		{new(int32), int64(math.MaxInt64), nil, nil},
+		{new(int8), []byte("-12"), int8(-12), int8(-12)},
+		{new(int), "1.00", 1, 1},
+		{new(int), "1.50", nil, 1},
+		{new(int), float64(-2.5), nil, -2},
+		{new(uint16), int64(-1), nil, nil},
+		{new(uint64), "18446744073709551615", uint64(math.MaxUint64), uint64(math.MaxUint64)},
+		{new(float64), "12.25", 12.25, 12.25},
+		{new(float64), int64(1<<53 + 1), nil, float64(1<<53 + 1)},
+		{new(float32), float64(math.MaxFloat64), nil, nil},
+		{new(string), []byte("abc"), "abc", "abc"},
+		{new(string), int64(5), "5", "5"},
+		{new(string), 2.5, "2.5", "2.5"},
+		{new([]byte), "xyz", []byte("xyz"), []byte("xyz")},
+		{new(bool), int64(1), true, true},
+		{new(bool), int64(2), nil, true},
+		{new(bool), []byte("f"), false, false},
+		{new(bool), "maybe", nil, nil},
+		{new(time.Time), "2024-01-02 03:04:05", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{new(time.Time), int64(5), nil, nil},
+	}
+	for _, test := range tests {
+		for _, strict := range []bool{true, false} {
+			expected := test.lenient
+			if strict {
+				expected = test.strict
+			}
+			dv := reflect.New(reflect.TypeOf(test.dst).Elem()).Elem()
+			err := coerce(dv, test.src, strict)
+			if expected == nil {
+				if err == nil {
+					t.Errorf("coerce %T %v into %T (strict %v): expected err, got %v", test.src, test.src, test.dst, strict, dv.Interface())
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("coerce %T %v into %T (strict %v): %v", test.src, test.src, test.dst, strict, err)
+			} else if !reflect.DeepEqual(dv.Interface(), expected) {
+				t.Errorf("coerce %T %v into %T (strict %v): expected %v, got %v", test.src, test.src, test.dst, strict, expected, dv.Interface())
+			}
+		}
+	}
+}
+
+func TestCoercion(t *testing.T) {
+	once.Do(setup)
+
+	// as pq returns NUMERIC and MySQL returns DATETIME without parseTime
+	type row struct {
+		N    int       `meddler:"n"`
+		When time.Time `meddler:"t"`
+		Flag bool      `meddler:"flag"`
+	}
+	q := "select '1.00' as n, '2024-01-02 03:04:05' as t, 1 as flag"
+
+	elt := new(row)
+	if err := SQLite.QueryRow(testCtx, db, elt, q); err == nil {
+		t.Errorf("QueryRow with CoerceNone: expected err, got %+v", elt)
+	}
+
+	d := SQLite.Clone()
+	d.Coercion = CoerceStrict
+	if err := d.QueryRow(testCtx, db, elt, q); err != nil {
+		t.Fatalf("QueryRow with CoerceStrict: %v", err)
+	}
+	if elt.N != 1 || elt.When.Year() != 2024 || !elt.Flag {
+		t.Errorf("QueryRow with CoerceStrict: unexpected row %+v", elt)
+	}
+
+	if err := d.QueryRow(testCtx, db, elt, "select '1.5' as n, null as t, 0 as flag"); err == nil {
+		t.Errorf("QueryRow with CoerceStrict: expected err for 1.5 into int, got %+v", elt)
+	}
+	d.Coercion = CoerceLenient
+	d.NullAsZero = true
+	if err := d.QueryRow(testCtx, db, elt, "select '1.5' as n, null as t, 0 as flag"); err != nil {
+		t.Fatalf("QueryRow with CoerceLenient: %v", err)
+	}
+	if elt.N != 1 || !elt.When.IsZero() || elt.Flag {
+		t.Errorf("QueryRow with CoerceLenient: unexpected row %+v", elt)
+	}
+}
@@ -0,0 +1,87 @@
+package meddlerx
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type queryTagsKey struct{}
+
+// WithQueryTags returns a context whose queries carry the given tags in
+// addition to those from SQLCommenter, e.g. a W3C traceparent:
+//
+//	ctx = meddlerx.WithQueryTags(ctx, map[string]string{"traceparent": tp})
+func WithQueryTags(ctx context.Context, tags map[string]string) context.Context {
+	merged := make(map[string]string)
+	for k, v := range queryTagsFrom(ctx) {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, queryTagsKey{}, merged)
+}
+
+func queryTagsFrom(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(queryTagsKey{}).(map[string]string)
+	return tags
+}
+
+// SQLCommenter returns a QueryTags function that tags each query with the
+// application name, the meddler operation, the table, and any tags added
+// to the context with WithQueryTags, producing comments like
+//
+//	/*app='checkout',op='Load',table='person',traceparent='00-...'*/
+func SQLCommenter(app string) func(ctx context.Context, op, table string) map[string]string {
+	return func(ctx context.Context, op, table string) map[string]string {
+		tags := map[string]string{"op": op}
+		if app != "" {
+			tags["app"] = app
+		}
+		if table != "" {
+			tags["table"] = table
+		}
+		for k, v := range queryTagsFrom(ctx) {
+			tags[k] = v
+		}
+		return tags
+	}
+}
+
+// sqlComment formats tags as a comment following the sqlcommenter spec:
+// keys sorted, keys and values URL-encoded, values in single quotes.
+func sqlComment(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = commentEscape(k) + "='" + commentEscape(tags[k]) + "'"
+	}
+	return "/*" + strings.Join(pairs, ",") + "*/"
+}
+
+// commentEscape URL-encodes s and escapes the characters that matter inside
+// a quoted comment value.
+func commentEscape(s string) string {
+	s = strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+	return strings.ReplaceAll(s, "'", "\\'")
+}
+
+// appendComment adds comment to the end of query, before any final
+// semicolon.
+func appendComment(query, comment string) string {
+	trimmed := strings.TrimRight(query, " \t\r\n")
+	if strings.HasSuffix(trimmed, ";") {
+		return strings.TrimSuffix(trimmed, ";") + " " + comment + ";"
+	}
+	return trimmed + " " + comment
+}
@@ -0,0 +1,68 @@
+package meddlerx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestQueryTags(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	d := SQLite.Clone()
+	d.QueryTags = SQLCommenter("checkout")
+	ctx := WithQueryTags(testCtx, map[string]string{"traceparent": "00-abc-01", "route": "/people/{id}"})
+
+	rec := new(recordingQuerier)
+	elt := new(Person)
+	if err := d.Load(ctx, rec, "person", elt, 2); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	expected := `SELECT "id","name","Email","Age","opened","closed","updated","height" FROM "person" WHERE "id" = ? ` +
+		`/*app='checkout',op='Load',route='%2Fpeople%2F%7Bid%7D',table='person',traceparent='00-abc-01'*/`
+	if rec.query != expected {
+		t.Errorf("Load: expected\n%s\ngot\n%s", expected, rec.query)
+	}
+
+	var people []*Person
+	if err := d.QueryAll(testCtx, rec, &people, "select * from person;"); err != nil || len(people) != 2 {
+		t.Fatalf("QueryAll: expected 2 people, got %d, %v", len(people), err)
+	}
+	if expected := `select * from person /*app='checkout',op='QueryAll'*/;`; rec.query != expected {
+		t.Errorf("QueryAll: expected %s, got %s", expected, rec.query)
+	}
+
+	// no tags, no comment
+	d.QueryTags = func(ctx context.Context, op, table string) map[string]string { return nil }
+	if err := d.Load(testCtx, rec, "person", elt, 2); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if expected := `SELECT "id","name","Email","Age","opened","closed","updated","height" FROM "person" WHERE "id" = ?`; rec.query != expected {
+		t.Errorf("Load without tags: expected %s, got %s", expected, rec.query)
+	}
+}
+
+func TestQueryTagsNested(t *testing.T) {
+	once.Do(setup)
+	defer db.Exec("delete from person")
+
+	// forTable hands the nested Insert a copy of d, which must still see
+	// that the outer operation has already tagged the Querier
+	d := SQLite.Clone()
+	d.QueryTags = SQLCommenter("checkout")
+	d.Tables = map[string]TableConfig{"person": {Quote: "`"}}
+
+	rec := new(recordingQuerier)
+	ctx, q, done := d.begin(testCtx, rec, "Save", "person")
+	defer done()
+	elt := &Person{Name: "Alice", Email: "alice@alice.com"}
+	if err := d.Insert(ctx, q, "person", elt); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	expected := "INSERT INTO `person` (`name`,`Email`,`Age`,`opened`,`closed`,`updated`,`height`) VALUES (?,?,?,?,?,?,?) " +
+		"/*app='checkout',op='Save',table='person'*/"
+	if rec.query != expected {
+		t.Errorf("Insert: expected\n%s\ngot\n%s", expected, rec.query)
+	}
+}
@@ -0,0 +1,266 @@
+package meddlerx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Cond is a condition for the WHERE clause of Count, Exists, and
+// DeleteWhere. SQL returns the condition, written with ? placeholders
+// whatever the dialect, and its arguments; d supplies the quoting for any
+// column names. Raw wraps a hand-written fragment, while Eq, In, And, and
+// the other builder functions put conditions together from column names
+// and values, so code can move from one to the other a piece at a time:
+//
+//	n, err := meddlerx.Count(ctx, db, "person", meddlerx.And(
+//		meddlerx.Raw("age > ?", 21),
+//		meddlerx.Eq("country", "NZ"),
+//	))
+type Cond interface {
+	SQL(d *Database) (string, []interface{})
+}
+
+// Raw returns a Cond for a hand-written fragment of SQL with ? placeholders.
+func Raw(cond string, args ...interface{}) Cond {
+	return rawCond{cond, args}
+}
+
+type rawCond struct {
+	cond string
+	args []interface{}
+}
+
+func (c rawCond) SQL(*Database) (string, []interface{}) {
+	return c.cond, c.args
+}
+
+// Eq returns a Cond that column equals value. A nil value tests for NULL.
+func Eq(column string, value interface{}) Cond {
+	return compareCond{column, "=", value}
+}
+
+// Ne returns a Cond that column does not equal value. A nil value tests
+// for NOT NULL.
+func Ne(column string, value interface{}) Cond {
+	return compareCond{column, "<>", value}
+}
+
+// Lt returns a Cond that column is less than value.
+func Lt(column string, value interface{}) Cond {
+	return compareCond{column, "<", value}
+}
+
+// Le returns a Cond that column is at most value.
+func Le(column string, value interface{}) Cond {
+	return compareCond{column, "<=", value}
+}
+
+// Gt returns a Cond that column is greater than value.
+func Gt(column string, value interface{}) Cond {
+	return compareCond{column, ">", value}
+}
+
+// Ge returns a Cond that column is at least value.
+func Ge(column string, value interface{}) Cond {
+	return compareCond{column, ">=", value}
+}
+
+type compareCond struct {
+	column string
+	op     string
+	value  interface{}
+}
+
+func (c compareCond) SQL(d *Database) (string, []interface{}) {
+	if c.value == nil {
+		switch c.op {
+		case "=":
+			return d.quoted(c.column) + " IS NULL", nil
+		case "<>":
+			return d.quoted(c.column) + " IS NOT NULL", nil
+		}
+	}
+	return d.quoted(c.column) + " " + c.op + " ?", []interface{}{c.value}
+}
+
+// In returns a Cond that column equals one of values. With no values, no
+// row matches.
+func In(column string, values ...interface{}) Cond {
+	return inCond{column, values}
+}
+
+type inCond struct {
+	column string
+	values []interface{}
+}
+
+func (c inCond) SQL(d *Database) (string, []interface{}) {
+	if len(c.values) == 0 {
+		return "1=0", nil
+	}
+	return d.quoted(c.column) + " IN (?" + strings.Repeat(",?", len(c.values)-1) + ")", c.values
+}
+
+// And returns a Cond that all of conds hold. With no conds, every row
+// matches.
+func And(conds ...Cond) Cond {
+	return joinCond{" AND ", "1=1", conds}
+}
+
+// Or returns a Cond that at least one of conds holds. With no conds, no
+// row matches.
+func Or(conds ...Cond) Cond {
+	return joinCond{" OR ", "1=0", conds}
+}
+
+type joinCond struct {
+	sep   string
+	empty string
+	conds []Cond
+}
+
+func (c joinCond) SQL(d *Database) (string, []interface{}) {
+	if len(c.conds) == 0 {
+		return c.empty, nil
+	}
+	parts := make([]string, len(c.conds))
+	var args []interface{}
+	for i, cond := range c.conds {
+		part, condArgs := cond.SQL(d)
+		parts[i] = "(" + part + ")"
+		args = append(args, condArgs...)
+	}
+	return strings.Join(parts, c.sep), args
+}
+
+// Not returns a Cond that cond does not hold.
+func Not(cond Cond) Cond {
+	return notCond{cond}
+}
+
+type notCond struct {
+	cond Cond
+}
+
+func (c notCond) SQL(d *Database) (string, []interface{}) {
+	s, args := c.cond.SQL(d)
+	return "NOT (" + s + ")", args
+}
+
+// whereClause renders cond as a WHERE clause for d, or "" if cond is nil.
+func (d *Database) whereClause(op string, cond Cond) (string, []interface{}, error) {
+	if cond == nil {
+		return "", nil, nil
+	}
+	s, args := cond.SQL(d)
+	s, n := d.rebind(s, 0)
+	if n != len(args) {
+		return "", nil, fmt.Errorf("meddler.%s: condition has %d placeholders but %d arguments", op, n, len(args))
+	}
+	return " WHERE " + s, args, nil
+}
+
+// Count returns the number of rows of table that meet cond, or of all rows
+// if cond is nil.
+func (d *Database) Count(ctx context.Context, db Querier, table string, cond Cond) (int64, error) {
+	d = d.forTable(table)
+	where, args, err := d.whereClause("Count", cond)
+	if err != nil {
+		return 0, err
+	}
+	ctx, db, done := d.begin(ctx, db, "Count", table)
+	defer done()
+
+	q := "SELECT COUNT(*) FROM " + d.quotedTable(table) + where
+	var n int64
+	if err := db.QueryRowContext(ctx, q, args...).Scan(&n); err != nil {
+		return 0, d.queryError("Count", table, q, args, err)
+	}
+	return n, nil
+}
+
+// Count using the Default Database type
+func Count(ctx context.Context, db Querier, table string, cond Cond) (int64, error) {
+	return Default.Count(ctx, db, table, cond)
+}
+
+// Exists reports whether any row of table meets cond, or whether the table
+// has any rows if cond is nil.
+func (d *Database) Exists(ctx context.Context, db Querier, table string, cond Cond) (bool, error) {
+	d = d.forTable(table)
+	where, args, err := d.whereClause("Exists", cond)
+	if err != nil {
+		return false, err
+	}
+	ctx, db, done := d.begin(ctx, db, "Exists", table)
+	defer done()
+
+	var q string
+	if d.Dialect == DialectSQLServer {
+		q = "SELECT TOP 1 1 FROM " + d.quotedTable(table) + where
+	} else {
+		q = "SELECT 1 FROM " + d.quotedTable(table) + where + " LIMIT 1"
+	}
+	var one int
+	switch err := db.QueryRowContext(ctx, q, args...).Scan(&one); err {
+	case nil:
+		return true, nil
+	case sql.ErrNoRows:
+		return false, nil
+	default:
+		return false, d.queryError("Exists", table, q, args, err)
+	}
+}
+
+// Exists using the Default Database type
+func Exists(ctx context.Context, db Querier, table string, cond Cond) (bool, error) {
+	return Default.Exists(ctx, db, table, cond)
+}
+
+// DeleteWhere deletes the rows of table that meet cond and returns how many
+// there were. cond may not be nil; use DeleteAll to empty a table.
+//
+// Without a record type, DeleteWhere cannot tell which primary keys it
+// deleted, so it fails for a table that keeps History and for a Database
+// with a Cache, Audit, or OnChange, which would otherwise miss the deleted
+// rows. Load the records and Delete each one instead.
+func (d *Database) DeleteWhere(ctx context.Context, db Querier, table string, cond Cond) (int64, error) {
+	if err := d.writable("DeleteWhere", table); err != nil {
+		return 0, err
+	}
+	if cond == nil {
+		return 0, fmt.Errorf("meddler.DeleteWhere: no condition given; use DeleteAll to delete every row")
+	}
+	switch {
+	case d.History[table]:
+		return 0, fmt.Errorf("meddler.DeleteWhere: table %s keeps history; delete its records one at a time", table)
+	case d.Cache != nil, d.Audit != nil, d.OnChange != nil:
+		return 0, fmt.Errorf("meddler.DeleteWhere: deleted rows would not reach the Cache, Audit, or OnChange hooks; delete the records one at a time")
+	}
+	d = d.forTable(table)
+	where, args, err := d.whereClause("DeleteWhere", cond)
+	if err != nil {
+		return 0, err
+	}
+	forgetTable(ctx, table)
+	ctx, db, done := d.begin(ctx, db, "DeleteWhere", table)
+	defer done()
+
+	q := "DELETE FROM " + d.quotedTable(table) + where
+	result, err := db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return 0, d.queryError("DeleteWhere", table, q, args, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("meddler.DeleteWhere: %w", err)
+	}
+	return n, nil
+}
+
+// DeleteWhere using the Default Database type
+func DeleteWhere(ctx context.Context, db Querier, table string, cond Cond) (int64, error) {
+	return Default.DeleteWhere(ctx, db, table, cond)
+}
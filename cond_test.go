@@ -0,0 +1,93 @@
+package meddlerx
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCondSQL(t *testing.T) {
+	cond := And(
+		Raw("age > ? AND name <> '?'", 21),
+		Or(Eq("country", "NZ"), In("city", "Oslo", "Bergen"), In("zone")),
+		Not(Eq("closed", nil)),
+		Ne("height", nil),
+		And(),
+	)
+	where, args, err := PostgreSQL.whereClause("Count", cond)
+	if err != nil {
+		t.Fatalf("whereClause error: %v", err)
+	}
+	expected := ` WHERE (age > $1 AND name <> '?') AND (("country" = $2) OR ("city" IN ($3,$4)) OR (1=0)) AND (NOT ("closed" IS NULL)) AND ("height" IS NOT NULL) AND (1=1)`
+	if where != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, where)
+	}
+	if expectedArgs := []interface{}{21, "NZ", "Oslo", "Bergen"}; !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected args %v, got %v", expectedArgs, args)
+	}
+
+	if where, args, err := MySQL.whereClause("Count", nil); where != "" || args != nil || err != nil {
+		t.Errorf("expected no WHERE clause for a nil Cond, got %q, %v, %v", where, args, err)
+	}
+	if _, _, err := MySQL.whereClause("Count", Raw("a = ? AND b = ?", 1)); err == nil || !strings.Contains(err.Error(), "2 placeholders but 1 arguments") {
+		t.Errorf("expected a placeholder count error, got %v", err)
+	}
+}
+
+func TestCountExistsDeleteWhere(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	counts := []struct {
+		cond     Cond
+		expected int64
+	}{
+		{nil, 2},
+		{Raw("name = ?", "Alice"), 1},
+		{Eq("height", nil), 1},
+		{And(Raw("Age > ?", 30), Eq("name", "Alice")), 1},
+		{Or(Eq("name", "Carol"), In("Email", "bob@bob.com", "x")), 1},
+		{In("name"), 0},
+	}
+	for _, test := range counts {
+		n, err := SQLite.Count(testCtx, db, "person", test.cond)
+		if err != nil {
+			t.Fatalf("Count error: %v", err)
+		}
+		if n != test.expected {
+			where, _, _ := SQLite.whereClause("Count", test.cond)
+			t.Errorf("Count%s: expected %d, got %d", where, test.expected, n)
+		}
+	}
+
+	if found, err := SQLite.Exists(testCtx, db, "person", Eq("name", "Bob")); err != nil || !found {
+		t.Errorf("Exists for Bob: got %v, %v", found, err)
+	}
+	if found, err := SQLite.Exists(testCtx, db, "person", Eq("name", "Carol")); err != nil || found {
+		t.Errorf("Exists for Carol: got %v, %v", found, err)
+	}
+
+	if _, err := SQLite.DeleteWhere(testCtx, db, "person", nil); err == nil {
+		t.Errorf("expected DeleteWhere with a nil Cond to fail")
+	}
+
+	// rows that history, caches, or hooks need to hear about are refused
+	tracked := []*Database{SQLite.Clone(), SQLite.Clone(), SQLite.Clone()}
+	tracked[0].History = map[string]bool{"person": true}
+	tracked[1].Cache = new(MemoryCache)
+	tracked[2].OnChange = func(context.Context, ChangeEvent) {}
+	for i, d := range tracked {
+		if _, err := d.DeleteWhere(testCtx, db, "person", Eq("name", "Bob")); err == nil {
+			t.Errorf("expected DeleteWhere with tracking %d to fail", i)
+		}
+	}
+	n, err := SQLite.DeleteWhere(testCtx, db, "person", Raw("name = ?", "Bob"))
+	if err != nil || n != 1 {
+		t.Errorf("DeleteWhere: expected 1 row, got %d, %v", n, err)
+	}
+	if n, err := SQLite.Count(testCtx, db, "person", nil); err != nil || n != 1 {
+		t.Errorf("expected 1 row left, got %d, %v", n, err)
+	}
+}
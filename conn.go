@@ -0,0 +1,26 @@
+package meddlerx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// WithConn takes a dedicated connection from db, calls fn with it, and
+// returns the connection to the pool when fn returns. Everything fn does
+// through the Querier, including meddler operations, runs on that one
+// connection, so session state such as advisory locks, temporary tables,
+// and session variables carries from one statement to the next. The
+// Querier is a *sql.Conn, which can also begin transactions, e.g. for
+// SaveAll; it must not be used after fn returns.
+func WithConn(ctx context.Context, db *sql.DB, fn func(conn Querier) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("meddler.WithConn: %w", err)
+	}
+	err = fn(conn)
+	if closeErr := conn.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("meddler.WithConn: %w", closeErr)
+	}
+	return err
+}
@@ -0,0 +1,42 @@
+package meddlerx
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestWithConn(t *testing.T) {
+	// a pool in which each connection is its own database
+	pool, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	defer pool.Close()
+
+	err = WithConn(testCtx, pool, func(conn Querier) error {
+		// hold another connection, so the pool would hand out a new one
+		other, err := pool.Conn(testCtx)
+		if err != nil {
+			return err
+		}
+		defer other.Close()
+
+		if _, err := conn.ExecContext(testCtx, schema1); err != nil {
+			return err
+		}
+		if err := SQLite.SaveAll(testCtx, conn, "person", []*Person{{Name: "Alice", Age: 1}, {Name: "Bob", Age: 2}}); err != nil {
+			return err
+		}
+		var people []*Person
+		if err := SQLite.QueryAll(testCtx, conn, &people, "select * from person"); err != nil {
+			return err
+		}
+		if len(people) != 2 {
+			t.Errorf("WithConn: expected 2 rows on the pinned connection, got %d", len(people))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithConn error: %v", err)
+	}
+}
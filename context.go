@@ -0,0 +1,128 @@
+package meddlerx
+
+import (
+	"context"
+	"fmt"
+)
+
+type querierKey struct{}
+
+// NewContext returns a context that carries q, typically a *sql.DB at the
+// edge of a request and a *sql.Tx inside a transaction. The ...Ctx methods
+// use it in place of an explicit Querier argument.
+func NewContext(ctx context.Context, q Querier) context.Context {
+	return context.WithValue(ctx, querierKey{}, q)
+}
+
+// FromContext returns the Querier stored in ctx by NewContext, if any.
+func FromContext(ctx context.Context) (Querier, bool) {
+	q, ok := ctx.Value(querierKey{}).(Querier)
+	return q, ok && q != nil
+}
+
+// querierFrom returns the Querier in ctx, or an error for op.
+func querierFrom(ctx context.Context, op string) (Querier, error) {
+	q, ok := FromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("meddler.%s: %w", op, ErrNoQuerier)
+	}
+	return q, nil
+}
+
+// LoadCtx is Load with the Querier taken from ctx.
+func (d *Database) LoadCtx(ctx context.Context, table string, dst interface{}, pk int64) error {
+	db, err := querierFrom(ctx, "LoadCtx")
+	if err != nil {
+		return err
+	}
+	return d.Load(ctx, db, table, dst, pk)
+}
+
+// LoadCtx using the Default Database type
+func LoadCtx(ctx context.Context, table string, dst interface{}, pk int64) error {
+	return Default.LoadCtx(ctx, table, dst, pk)
+}
+
+// InsertCtx is Insert with the Querier taken from ctx.
+func (d *Database) InsertCtx(ctx context.Context, table string, src interface{}, opts ...WriteOption) error {
+	db, err := querierFrom(ctx, "InsertCtx")
+	if err != nil {
+		return err
+	}
+	return d.Insert(ctx, db, table, src, opts...)
+}
+
+// InsertCtx using the Default Database type
+func InsertCtx(ctx context.Context, table string, src interface{}, opts ...WriteOption) error {
+	return Default.InsertCtx(ctx, table, src, opts...)
+}
+
+// UpdateCtx is Update with the Querier taken from ctx.
+func (d *Database) UpdateCtx(ctx context.Context, table string, src interface{}, opts ...WriteOption) error {
+	db, err := querierFrom(ctx, "UpdateCtx")
+	if err != nil {
+		return err
+	}
+	return d.Update(ctx, db, table, src, opts...)
+}
+
+// UpdateCtx using the Default Database type
+func UpdateCtx(ctx context.Context, table string, src interface{}, opts ...WriteOption) error {
+	return Default.UpdateCtx(ctx, table, src, opts...)
+}
+
+// SaveCtx is Save with the Querier taken from ctx.
+func (d *Database) SaveCtx(ctx context.Context, table string, src interface{}, opts ...WriteOption) error {
+	db, err := querierFrom(ctx, "SaveCtx")
+	if err != nil {
+		return err
+	}
+	return d.Save(ctx, db, table, src, opts...)
+}
+
+// SaveCtx using the Default Database type
+func SaveCtx(ctx context.Context, table string, src interface{}, opts ...WriteOption) error {
+	return Default.SaveCtx(ctx, table, src, opts...)
+}
+
+// DeleteCtx is Delete with the Querier taken from ctx.
+func (d *Database) DeleteCtx(ctx context.Context, table string, src interface{}, opts ...WriteOption) error {
+	db, err := querierFrom(ctx, "DeleteCtx")
+	if err != nil {
+		return err
+	}
+	return d.Delete(ctx, db, table, src, opts...)
+}
+
+// DeleteCtx using the Default Database type
+func DeleteCtx(ctx context.Context, table string, src interface{}, opts ...WriteOption) error {
+	return Default.DeleteCtx(ctx, table, src, opts...)
+}
+
+// QueryRowCtx is QueryRow with the Querier taken from ctx.
+func (d *Database) QueryRowCtx(ctx context.Context, dst interface{}, query string, args ...interface{}) error {
+	db, err := querierFrom(ctx, "QueryRowCtx")
+	if err != nil {
+		return err
+	}
+	return d.QueryRow(ctx, db, dst, query, args...)
+}
+
+// QueryRowCtx using the Default Database type
+func QueryRowCtx(ctx context.Context, dst interface{}, query string, args ...interface{}) error {
+	return Default.QueryRowCtx(ctx, dst, query, args...)
+}
+
+// QueryAllCtx is QueryAll with the Querier taken from ctx.
+func (d *Database) QueryAllCtx(ctx context.Context, dst interface{}, query string, args ...interface{}) error {
+	db, err := querierFrom(ctx, "QueryAllCtx")
+	if err != nil {
+		return err
+	}
+	return d.QueryAll(ctx, db, dst, query, args...)
+}
+
+// QueryAllCtx using the Default Database type
+func QueryAllCtx(ctx context.Context, dst interface{}, query string, args ...interface{}) error {
+	return Default.QueryAllCtx(ctx, dst, query, args...)
+}
@@ -0,0 +1,51 @@
+package meddlerx
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestContextQuerier(t *testing.T) {
+	once.Do(setup)
+	defer db.Exec("delete from person")
+
+	if err := LoadCtx(testCtx, "person", new(Person), 1); !errors.Is(err, ErrNoQuerier) {
+		t.Errorf("LoadCtx without a Querier: expected ErrNoQuerier, got %v", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Begin error: %v", err)
+	}
+	defer tx.Rollback()
+	ctx := NewContext(testCtx, tx)
+	if q, ok := FromContext(ctx); !ok || q != tx {
+		t.Fatalf("FromContext: expected the transaction, got %v, %v", q, ok)
+	}
+
+	p := &Person{Name: "Carol", Email: "carol@carol.com"}
+	if err := InsertCtx(ctx, "person", p); err != nil {
+		t.Fatalf("InsertCtx error: %v", err)
+	}
+	p.Age = 40
+	if err := SaveCtx(ctx, "person", p); err != nil {
+		t.Fatalf("SaveCtx error: %v", err)
+	}
+	elt := new(Person)
+	if err := LoadCtx(ctx, "person", elt, p.ID); err != nil {
+		t.Fatalf("LoadCtx error: %v", err)
+	}
+	if elt.Name != "Carol" || elt.Age != 40 {
+		t.Errorf("LoadCtx: got %+v", elt)
+	}
+	var people []*Person
+	if err := QueryAllCtx(ctx, &people, "select * from person"); err != nil || len(people) != 1 {
+		t.Errorf("QueryAllCtx: expected 1 person, got %d, %v", len(people), err)
+	}
+	if err := DeleteCtx(ctx, "person", p, MustAffect()); err != nil {
+		t.Errorf("DeleteCtx error: %v", err)
+	}
+	if err := QueryRowCtx(ctx, elt, "select * from person where id = ?", p.ID); err == nil {
+		t.Errorf("QueryRowCtx after delete: expected an error")
+	}
+}
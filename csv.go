@@ -0,0 +1,161 @@
+package meddlerx
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+)
+
+// ExportCSV performs the given query and writes the rows to w as CSV, with
+// a header row naming the columns. dst must be a pointer to a struct, and
+// is used to scan each row; the columns written are those of the struct,
+// in the order given by Columns, with their values as they would be saved
+// by Insert. NULL is written as an empty field, times in RFC 3339 format,
+// and binary values as they are.
+func (d *Database) ExportCSV(ctx context.Context, db Querier, w io.Writer, dst interface{}, query string, args ...interface{}) error {
+	columns, err := d.Columns(dst, true)
+	if err != nil {
+		return err
+	}
+	out := csv.NewWriter(w)
+	if err := out.Write(columns); err != nil {
+		return fmt.Errorf("meddler.ExportCSV: %w", err)
+	}
+
+	record := make([]string, len(columns))
+	err = d.QueryEach(ctx, db, dst, func() error {
+		values, err := d.SomeValues(dst, columns)
+		if err != nil {
+			return err
+		}
+		for i, value := range values {
+			if record[i], err = csvField(value); err != nil {
+				return fmt.Errorf("meddler.ExportCSV: column %s: %w", columns[i], err)
+			}
+		}
+		return out.Write(record)
+	}, query, args...)
+	if err != nil {
+		return err
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return fmt.Errorf("meddler.ExportCSV: %w", err)
+	}
+	return nil
+}
+
+// ExportCSV using the Default Database type
+func ExportCSV(ctx context.Context, db Querier, w io.Writer, dst interface{}, query string, args ...interface{}) error {
+	return Default.ExportCSV(ctx, db, w, dst, query, args...)
+}
+
+// ImportCSV reads CSV from r, as written by ExportCSV, and inserts each
+// row into table. dst must be a pointer to a struct of the type to load
+// each row into; it is left holding the last row. The first row names the
+// columns, and each field is converted for its struct field's meddler as
+// though it came from the database. An empty field is read as NULL if the
+// struct field allows it. Rows with a non-zero primary key keep it, as with
+// InsertWithPK; others have one allocated. ImportCSV returns the number of
+// rows inserted.
+func (d *Database) ImportCSV(ctx context.Context, db Querier, table string, r io.Reader, dst interface{}) (int, error) {
+	dstVal := reflect.ValueOf(dst)
+	if dstVal.Kind() != reflect.Ptr || dstVal.IsNil() || dstVal.Elem().Kind() != reflect.Struct {
+		return 0, fmt.Errorf("meddler.ImportCSV: destination must be a pointer to a struct, found %T", dst)
+	}
+	in := csv.NewReader(r)
+	columns, err := in.Read()
+	if err == io.EOF {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("meddler.ImportCSV: %w", err)
+	}
+
+	zero := reflect.Zero(dstVal.Elem().Type())
+	n := 0
+	for {
+		record, err := in.Read()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, fmt.Errorf("meddler.ImportCSV: %w", err)
+		}
+
+		dstVal.Elem().Set(zero)
+		targets, err := d.Targets(dst, columns)
+		if err != nil {
+			return n, err
+		}
+		for i, target := range targets {
+			if err := csvAssign(target, record[i]); err != nil {
+				return n, fmt.Errorf("meddler.ImportCSV: line %d, column %s: %w", n+2, columns[i], err)
+			}
+		}
+		if err := d.WriteTargets(dst, columns, targets); err != nil {
+			return n, err
+		}
+
+		pkName, pk, err := d.PrimaryKey(dst)
+		if err != nil {
+			return n, err
+		}
+		if pkName != "" && pk != 0 {
+			err = d.InsertWithPK(ctx, db, table, dst)
+		} else {
+			err = d.Insert(ctx, db, table, dst)
+		}
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+}
+
+// ImportCSV using the Default Database type
+func ImportCSV(ctx context.Context, db Querier, table string, r io.Reader, dst interface{}) (int, error) {
+	return Default.ImportCSV(ctx, db, table, r, dst)
+}
+
+// csvField formats a value from SomeValues as a CSV field.
+func csvField(value interface{}) (string, error) {
+	v, err := driver.DefaultParameterConverter.ConvertValue(value)
+	if err != nil {
+		return "", err
+	}
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case []byte:
+		return string(v), nil
+	case string:
+		return v, nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// csvAssign stores a CSV field in a scan target from Targets.
+func csvAssign(target interface{}, s string) error {
+	if s == "" {
+		if err := assignValue(target, nil); err == nil {
+			return nil
+		}
+	}
+	// leave text alone when it is going to text anyway
+	switch reflect.TypeOf(target).Elem().Kind() {
+	case reflect.String, reflect.Slice:
+		return assignValue(target, s)
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return assignValue(target, t)
+	}
+	return assignValue(target, s)
+}
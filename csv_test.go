@@ -0,0 +1,60 @@
+package meddlerx
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCSV(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	var buf bytes.Buffer
+	if err := ExportCSV(testCtx, db, &buf, new(Person), "select * from person order by id"); err != nil {
+		t.Fatalf("ExportCSV error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("ExportCSV: expected a header and 2 rows, got\n%s", buf.String())
+	}
+	if lines[0] != "id,name,Email,Age,opened,closed,updated,height" {
+		t.Errorf("ExportCSV: unexpected header %s", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "1,Alice,alice@alice.com,") {
+		t.Errorf("ExportCSV: unexpected row %s", lines[1])
+	}
+
+	// restore them, keeping their keys
+	if _, err := db.Exec("delete from person"); err != nil {
+		t.Fatalf("DB error: %v", err)
+	}
+	n, err := ImportCSV(testCtx, db, "person", &buf, new(Person))
+	if err != nil {
+		t.Fatalf("ImportCSV error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("ImportCSV: expected 2 rows, got %d", n)
+	}
+
+	elt := new(Person)
+	if err := Load(testCtx, db, "person", elt, 2); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	bob.ID = 2
+	personEqual(t, elt, bob)
+
+	// new rows get new keys
+	in := "name,Email,Age,closed,height\nCarol,,7,,\n"
+	if n, err := ImportCSV(testCtx, db, "person", strings.NewReader(in), elt); err != nil || n != 1 {
+		t.Fatalf("ImportCSV: expected 1 row, got %d, %v", n, err)
+	}
+	if elt.ID != 3 || elt.Name != "Carol" || elt.Age != 7 || elt.Height != nil {
+		t.Errorf("ImportCSV: unexpected row %+v", elt)
+	}
+
+	if _, err := ImportCSV(testCtx, db, "person", strings.NewReader("id,Age\n9,seven\n"), elt); err == nil {
+		t.Errorf("ImportCSV with a bad number: expected err, got nil")
+	}
+}
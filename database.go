@@ -0,0 +1,140 @@
+package meddlerx
+
+// Option configures a Database created by New or Clone.
+type Option func(*options)
+
+// options collects the settings given to New or Clone.
+type options struct {
+	dialect         *Dialect
+	quote           *string
+	placeholder     *string
+	placeholderFunc func(n int) string
+	returning       *bool
+	readOnly        *bool
+	logger          Logger
+}
+
+// WithDialect sets the SQL dialect. For New, it also selects the preset
+// whose quoting, placeholders, and RETURNING support are used unless given
+// explicitly.
+func WithDialect(dialect Dialect) Option {
+	return func(o *options) { o.dialect = &dialect }
+}
+
+// WithQuote sets the quote character for table and column names.
+func WithQuote(quote string) Option {
+	return func(o *options) { o.quote = &quote }
+}
+
+// WithPlaceholder sets the placeholder style, e.g. "?", "$1", "@p1", or ":1".
+func WithPlaceholder(placeholder string) Option {
+	return func(o *options) { o.placeholder = &placeholder }
+}
+
+// WithPlaceholderFunc sets a function that writes the placeholder for the
+// nth argument of a query, counting from 1, for styles that Placeholder
+// cannot describe.
+func WithPlaceholderFunc(placeholder func(n int) string) Option {
+	return func(o *options) { o.placeholderFunc = placeholder }
+}
+
+// WithReturning sets whether inserts use RETURNING to get new keys.
+func WithReturning(returning bool) Option {
+	return func(o *options) { o.returning = &returning }
+}
+
+// WithReadOnly sets whether writes fail with ErrReadOnly, e.g. for a
+// Database used with a read replica:
+//
+//	replica := meddlerx.PostgreSQL.Clone(meddlerx.WithReadOnly(true))
+func WithReadOnly(readOnly bool) Option {
+	return func(o *options) { o.readOnly = &readOnly }
+}
+
+// WithLogger sets the destination for debug messages.
+func WithLogger(logger Logger) Option {
+	return func(o *options) { o.logger = logger }
+}
+
+// New returns a new Database. It starts from the preset for the dialect
+// given with WithDialect (MySQL if none is given) and applies the other
+// options on top, in any order. The result is not shared with anything
+// else, so it can be configured further before use; once in use it must
+// not be modified.
+func New(opts ...Option) *Database {
+	o := new(options)
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	base := MySQL
+	if o.dialect != nil {
+		switch *o.dialect {
+		case DialectPostgreSQL:
+			base = PostgreSQL
+		case DialectSQLite:
+			base = SQLite
+		case DialectSQLServer:
+			base = SQLServer
+		case DialectANSI:
+			base = ANSI
+		}
+	}
+	d := &Database{
+		Quote:               base.Quote,
+		Placeholder:         base.Placeholder,
+		UseReturningToGetID: base.UseReturningToGetID,
+		Dialect:             base.Dialect,
+	}
+	o.apply(d)
+	return d
+}
+
+// Clone returns a copy of d with opts applied. Unlike New, WithDialect only
+// changes the Dialect field. The History and Tables maps are copied too, so
+// the clone can change them without affecting d.
+func (d *Database) Clone(opts ...Option) *Database {
+	clone := *d
+	if d.History != nil {
+		clone.History = make(map[string]bool, len(d.History))
+		for table, keep := range d.History {
+			clone.History[table] = keep
+		}
+	}
+	if d.Tables != nil {
+		clone.Tables = make(map[string]TableConfig, len(d.Tables))
+		for table, config := range d.Tables {
+			clone.Tables[table] = config
+		}
+	}
+	o := new(options)
+	for _, opt := range opts {
+		opt(o)
+	}
+	o.apply(&clone)
+	return &clone
+}
+
+func (o *options) apply(d *Database) {
+	if o.dialect != nil {
+		d.Dialect = *o.dialect
+	}
+	if o.quote != nil {
+		d.Quote = *o.quote
+	}
+	if o.placeholder != nil {
+		d.Placeholder = *o.placeholder
+	}
+	if o.placeholderFunc != nil {
+		d.PlaceholderFunc = o.placeholderFunc
+	}
+	if o.returning != nil {
+		d.UseReturningToGetID = *o.returning
+	}
+	if o.readOnly != nil {
+		d.ReadOnly = *o.readOnly
+	}
+	if o.logger != nil {
+		d.Logger = o.logger
+	}
+}
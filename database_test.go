@@ -0,0 +1,176 @@
+package meddlerx
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	d := New()
+	if !reflect.DeepEqual(d, MySQL) || d == MySQL {
+		t.Errorf("New(): expected a copy of the MySQL preset, got %+v", d)
+	}
+
+	d = New(WithQuote("'"), WithDialect(DialectPostgreSQL))
+	if d.Dialect != DialectPostgreSQL || d.Placeholder != "$1" || !d.UseReturningToGetID || d.Quote != "'" {
+		t.Errorf("New with PostgreSQL dialect: got %+v", d)
+	}
+
+	d = New(WithDialect(DialectSQLite), WithReturning(true), WithPlaceholder("$1"))
+	if d.Dialect != DialectSQLite || !d.UseReturningToGetID || d.Placeholder != "$1" {
+		t.Errorf("New with SQLite dialect: got %+v", d)
+	}
+}
+
+func TestClone(t *testing.T) {
+	once.Do(setup)
+
+	var buf bytes.Buffer
+	d := SQLite.Clone(WithLogger(log.New(&buf, "", 0)))
+	if d == SQLite || d.Dialect != DialectSQLite || SQLite.Logger != nil {
+		t.Errorf("Clone: expected an independent copy, got %+v", d)
+	}
+
+	// maps are copied, not shared
+	base := SQLite.Clone()
+	base.History = map[string]bool{"person": true}
+	base.Tables = map[string]TableConfig{"person": {ReadOnly: true}}
+	derived := base.Clone()
+	derived.History["item"] = true
+	derived.Tables["person"] = TableConfig{}
+	if len(base.History) != 1 || !base.Tables["person"].ReadOnly {
+		t.Errorf("Clone: expected changes to the clone's maps to leave the original alone, got %v %v", base.History, base.Tables)
+	}
+
+	// debug messages go to the Database's logger
+	var people []*Person
+	if err := d.QueryAll(testCtx, db, &people, "select 1 as unmapped"); err != nil {
+		t.Fatalf("QueryAll error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "column [unmapped] not found") {
+		t.Errorf("Logger: expected a debug message, got %q", buf.String())
+	}
+}
+
+func TestPlaceholderStyles(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	type item struct {
+		ID   int64  `meddler:"id,pk"`
+		Name string `meddler:"name"`
+		Size int    `meddler:"size"`
+	}
+	tests := []struct {
+		d                 *Database
+		update, selection string
+	}{
+		{MySQL, "UPDATE `item` SET `name`=?,`size`=? WHERE `id`=?", "SELECT `id`,`name`,`size` FROM `item` WHERE (name = ? AND size > ?)"},
+		{PostgreSQL, `UPDATE "item" SET "name"=$1,"size"=$2 WHERE "id"=$3`, `SELECT "id","name","size" FROM "item" WHERE (name = $1 AND size > $2)`},
+		{SQLServer, `UPDATE "item" SET "name"=@p1,"size"=@p2 WHERE "id"=@p3`, `SELECT "id","name","size" FROM "item" WHERE (name = @p1 AND size > @p2)`},
+		{PostgreSQL.Clone(WithPlaceholder(":1")), `UPDATE "item" SET "name"=:1,"size"=:2 WHERE "id"=:3`, `SELECT "id","name","size" FROM "item" WHERE (name = :1 AND size > :2)`},
+		{
+			PostgreSQL.Clone(WithPlaceholderFunc(func(n int) string { return fmt.Sprintf("@arg%d", n-1) })),
+			`UPDATE "item" SET "name"=@arg0,"size"=@arg1 WHERE "id"=@arg2`,
+			`SELECT "id","name","size" FROM "item" WHERE (name = @arg0 AND size > @arg1)`,
+		},
+	}
+	for _, test := range tests {
+		q, _, err := test.d.updateQuery("item", &item{ID: 1}, nil)
+		if err != nil {
+			t.Fatalf("updateQuery error: %v", err)
+		}
+		if q != test.update {
+			t.Errorf("expected %s, got %s", test.update, q)
+		}
+		q, err = test.d.selectQuery("LoadAllWhere", "item", reflect.TypeOf(new(item)), newQueryOptions([]QueryOption{Where("name = ? AND size > ?", "x", 1)}))
+		if err != nil {
+			t.Fatalf("selectQuery error: %v", err)
+		}
+		if q != test.selection {
+			t.Errorf("expected %s, got %s", test.selection, q)
+		}
+	}
+
+	// SQLite takes both ?NNN and :AAA parameters, so the styles can be run
+	for _, d := range []*Database{
+		SQLite.Clone(WithPlaceholder(":1")),
+		SQLite.Clone(WithPlaceholderFunc(func(n int) string { return fmt.Sprintf("?%d", n) })),
+	} {
+		var p Person
+		if err := d.LoadWhere(testCtx, db, "person", &p, Where("name = ? AND Email = ?", "Bob", bob.Email)); err != nil {
+			t.Fatalf("LoadWhere with placeholder %s error: %v", d.placeholder(1), err)
+		}
+		p.Email = "bob@" + d.placeholder(1)
+		if err := d.Update(testCtx, db, "person", &p); err != nil {
+			t.Fatalf("Update error: %v", err)
+		}
+		var loaded Person
+		if err := d.Load(testCtx, db, "person", &loaded, p.ID); err != nil {
+			t.Fatalf("Load error: %v", err)
+		}
+		if loaded.Email != p.Email {
+			t.Errorf("expected email %s, got %s", p.Email, loaded.Email)
+		}
+		p.Email = bob.Email
+		if err := d.Update(testCtx, db, "person", &p); err != nil {
+			t.Fatalf("Update error: %v", err)
+		}
+	}
+}
+
+func TestQuoting(t *testing.T) {
+	tests := []struct {
+		d        *Database
+		name     string
+		expected string
+	}{
+		{ANSI, `id`, `"id"`},
+		{ANSI, `say "hi"`, `"say ""hi"""`},
+		{MySQL, "back`tick", "`back``tick`"},
+		{MySQL, `say "hi"`, "`say \"hi\"`"},
+	}
+	for _, test := range tests {
+		if got := test.d.QuoteIdentifier(test.name); got != test.expected {
+			t.Errorf("%s.QuoteIdentifier(%q): expected %s, got %s", test.d.Dialect, test.name, test.expected, got)
+		}
+	}
+
+	d := ANSI.Clone()
+	d.Tables = map[string]TableConfig{`odd"name`: {Schema: "app"}}
+	if got, expected := d.quotedTable(`odd"name`), `"app"."odd""name"`; got != expected {
+		t.Errorf("quotedTable: expected %s, got %s", expected, got)
+	}
+}
+
+func TestANSI(t *testing.T) {
+	if d := New(WithDialect(DialectANSI)); !reflect.DeepEqual(d, ANSI) {
+		t.Errorf("New(WithDialect(DialectANSI)): expected a copy of the ANSI preset, got %+v", d)
+	}
+
+	q, err := ANSI.CreateTableSQL("person", new(Person))
+	if err != nil {
+		t.Fatalf("CreateTableSQL error: %v", err)
+	}
+	for _, want := range []string{
+		`CREATE TABLE "person" (`,
+		`"id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY`,
+		`"opened" TIMESTAMP WITH TIME ZONE NOT NULL`,
+	} {
+		if !strings.Contains(q, want) {
+			t.Errorf("CreateTableSQL: expected %s in\n%s", want, q)
+		}
+	}
+
+	// placeholders are not rewritten inside standard string literals or
+	// quoted identifiers, even with doubled quotes
+	cond, n := ANSI.Clone(WithPlaceholder("$1")).rebind(`"a""?" = 'it''s ?' AND b = ?`, 0)
+	if expected := `"a""?" = 'it''s ?' AND b = $1`; cond != expected || n != 1 {
+		t.Errorf("rebind: expected %s, got %s with %d placeholders", expected, cond, n)
+	}
+}
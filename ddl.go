@@ -0,0 +1,368 @@
+package meddlerx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ColumnTyper is an optional interface for a Meddler that knows what kind
+// of column its values should be stored in. CreateTableSQL consults it
+// for every field annotated with the meddler. fieldType is the type of the
+// struct field, and the result is the column type to use in d's dialect,
+// along with whether the column should allow nulls.
+type ColumnTyper interface {
+	ColumnType(d *Database, fieldType reflect.Type) (sqlType string, nullable bool, err error)
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	byteSliceType = reflect.TypeOf([]byte(nil))
+)
+
+// CreateTableSQL returns a CREATE TABLE statement for the given table,
+// with one column per field of src in the order that Columns reports them.
+// Column types are chosen from the Go field types and meddlers, using the
+// Dialect of d. The primary key field, if any, becomes an auto-incrementing
+// primary key column.
+//
+// Fields tagged comment= get a column comment: a COMMENT clause on MySQL,
+// and an SQL comment after the column elsewhere. Indexes from index= tags
+// are part of the statement on MySQL only; use CreateTableStatements to get
+// the separate statements other dialects need.
+func (d *Database) CreateTableSQL(table string, src interface{}) (string, error) {
+	return d.createTableSQL(table, src, false)
+}
+
+// CreateTableSQL using the Default Database type
+func CreateTableSQL(table string, src interface{}) (string, error) {
+	return Default.CreateTableSQL(table, src)
+}
+
+// CreateTableStatements returns the CREATE TABLE statement from
+// CreateTableSQL followed by the statements that complete the schema
+// declared in src's tags: CREATE INDEX for each index= index, except on
+// MySQL, and COMMENT ON COLUMN for each comment= on PostgreSQL.
+func (d *Database) CreateTableStatements(table string, src interface{}) ([]string, error) {
+	return d.tableStatements(table, src, false)
+}
+
+// CreateTableStatements using the Default Database type
+func CreateTableStatements(table string, src interface{}) ([]string, error) {
+	return Default.CreateTableStatements(table, src)
+}
+
+// EnsureTable creates the table described by src unless it already exists,
+// using CREATE TABLE IF NOT EXISTS and the same column mapping as CreateTableSQL.
+// The statements of CreateTableStatements are run as well, with CREATE
+// INDEX IF NOT EXISTS on PostgreSQL and SQLite.
+func (d *Database) EnsureTable(ctx context.Context, db Querier, table string, src interface{}) error {
+	if err := d.writable("EnsureTable", table); err != nil {
+		return err
+	}
+	stmts, err := d.tableStatements(table, src, true)
+	if err != nil {
+		return err
+	}
+	for _, q := range stmts {
+		if _, err := db.ExecContext(ctx, q); err != nil {
+			return d.queryError("EnsureTable", table, q, nil, err)
+		}
+	}
+	return nil
+}
+
+// EnsureTable using the Default Database type
+func EnsureTable(ctx context.Context, db Querier, table string, src interface{}) error {
+	return Default.EnsureTable(ctx, db, table, src)
+}
+
+func (d *Database) createTableSQL(table string, src interface{}, ifNotExists bool) (string, error) {
+	data, err := getFields(reflect.TypeOf(src))
+	if err != nil {
+		return "", err
+	}
+	structType := reflect.TypeOf(src).Elem()
+
+	var defs []string
+	for _, name := range data.columns {
+		def, err := d.columnDefinition(data.fields[name], structType.FieldByIndex(data.fields[name].index).Type)
+		if err != nil {
+			return "", err
+		}
+		if meta := data.meta[name]; meta != nil && meta.comment != "" {
+			switch d.Dialect {
+			case DialectMySQL:
+				def += " COMMENT " + sqlString(meta.comment)
+			case DialectPostgreSQL:
+				// added with COMMENT ON COLUMN
+			default:
+				def += " /* " + strings.ReplaceAll(meta.comment, "*/", "* /") + " */"
+			}
+		}
+		defs = append(defs, def)
+	}
+	if d.Dialect == DialectMySQL {
+		indexes, err := data.indexes(table)
+		if err != nil {
+			return "", err
+		}
+		for _, index := range indexes {
+			def := "KEY "
+			if index.unique {
+				def = "UNIQUE KEY "
+			}
+			defs = append(defs, def+d.quoted(index.name)+" ("+d.quotedList(index.columns)+")")
+		}
+	}
+
+	create := "CREATE TABLE "
+	if ifNotExists {
+		create += "IF NOT EXISTS "
+	}
+	return fmt.Sprintf("%s%s (\n\t%s\n)", create, d.quotedTable(table), strings.Join(defs, ",\n\t")), nil
+}
+
+// tableStatements returns the CREATE TABLE statement for src followed by
+// the CREATE INDEX and COMMENT ON statements that are not part of it.
+func (d *Database) tableStatements(table string, src interface{}, ifNotExists bool) ([]string, error) {
+	create, err := d.createTableSQL(table, src, ifNotExists)
+	if err != nil {
+		return nil, err
+	}
+	stmts := []string{create}
+	if d.Dialect == DialectMySQL {
+		return stmts, nil
+	}
+
+	data, err := getFields(reflect.TypeOf(src))
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := data.indexes(table)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range indexes {
+		q := "CREATE INDEX "
+		if index.unique {
+			q = "CREATE UNIQUE INDEX "
+		}
+		if ifNotExists && (d.Dialect == DialectPostgreSQL || d.Dialect == DialectSQLite) {
+			q += "IF NOT EXISTS "
+		}
+		stmts = append(stmts, q+d.quoted(index.name)+" ON "+d.quotedTable(table)+" ("+d.quotedList(index.columns)+")")
+	}
+	if d.Dialect == DialectPostgreSQL {
+		for _, name := range data.columns {
+			if meta := data.meta[name]; meta != nil && meta.comment != "" {
+				stmts = append(stmts, "COMMENT ON COLUMN "+d.quotedTable(table)+"."+d.quoted(name)+" IS "+sqlString(meta.comment))
+			}
+		}
+	}
+	return stmts, nil
+}
+
+// quotedList quotes each name and joins them with commas.
+func (d *Database) quotedList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = d.quoted(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// sqlString renders s as an SQL string literal.
+func sqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// columnDefinition renders a single column for a CREATE TABLE statement.
+func (d *Database) columnDefinition(field *structField, fieldType reflect.Type) (string, error) {
+	if field.primaryKey {
+		return d.quoted(field.column) + " " + d.primaryKeyType(fieldType), nil
+	}
+
+	typer, ok := field.meddler.(ColumnTyper)
+	if !ok {
+		return "", fmt.Errorf("meddler.CreateTableSQL: column %s uses a meddler that does not implement ColumnTyper", field.column)
+	}
+	sqlType, nullable, err := typer.ColumnType(d, fieldType)
+	if err != nil {
+		return "", fmt.Errorf("meddler.CreateTableSQL: column %s: %w", field.column, err)
+	}
+
+	def := d.quoted(field.column) + " " + sqlType
+	if !nullable {
+		def += " NOT NULL"
+	}
+	return def, nil
+}
+
+// primaryKeyType returns the column type for an auto-incrementing primary key.
+func (d *Database) primaryKeyType(fieldType reflect.Type) string {
+	switch d.Dialect {
+	case DialectPostgreSQL:
+		return "BIGSERIAL PRIMARY KEY"
+	case DialectMySQL:
+		switch fieldType.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return "BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY"
+		}
+		return "BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY"
+	case DialectANSI:
+		return "BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY"
+	default:
+		return "INTEGER PRIMARY KEY"
+	}
+}
+
+// goColumnType maps a plain Go type to a column type. Pointers and the
+// sql.Null* types map to nullable columns.
+func (d *Database) goColumnType(t reflect.Type) (string, bool, error) {
+	nullable := false
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	switch t {
+	case timeType:
+		return d.timeType(), nullable, nil
+	case byteSliceType, reflect.TypeOf(sql.RawBytes(nil)):
+		return d.blobType(), nullable, nil
+	case reflect.TypeOf(sql.NullString{}):
+		return d.textType(), true, nil
+	case reflect.TypeOf(sql.NullInt64{}):
+		return d.integerType(reflect.Int64), true, nil
+	case reflect.TypeOf(sql.NullInt32{}):
+		return d.integerType(reflect.Int32), true, nil
+	case reflect.TypeOf(sql.NullInt16{}):
+		return d.integerType(reflect.Int16), true, nil
+	case reflect.TypeOf(sql.NullByte{}):
+		return d.integerType(reflect.Int16), true, nil
+	case reflect.TypeOf(sql.NullFloat64{}):
+		return d.floatType(reflect.Float64), true, nil
+	case reflect.TypeOf(sql.NullBool{}):
+		return d.boolType(), true, nil
+	case reflect.TypeOf(sql.NullTime{}):
+		return d.timeType(), true, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return d.boolType(), nullable, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return d.integerType(t.Kind()), nullable, nil
+	case reflect.Float32, reflect.Float64:
+		return d.floatType(t.Kind()), nullable, nil
+	case reflect.String:
+		return d.textType(), nullable, nil
+	}
+
+	return "", false, fmt.Errorf("no column type known for Go type %v", t)
+}
+
+func (d *Database) integerType(kind reflect.Kind) string {
+	if d.Dialect == DialectSQLite {
+		return "INTEGER"
+	}
+	switch kind {
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return "SMALLINT"
+	case reflect.Int32, reflect.Uint16:
+		return "INTEGER"
+	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+		if d.Dialect == DialectMySQL {
+			return "BIGINT UNSIGNED"
+		}
+		return "BIGINT"
+	default:
+		return "BIGINT"
+	}
+}
+
+func (d *Database) floatType(kind reflect.Kind) string {
+	switch {
+	case d.Dialect == DialectSQLite || kind == reflect.Float32:
+		return "REAL"
+	case d.Dialect == DialectMySQL:
+		return "DOUBLE"
+	default:
+		return "DOUBLE PRECISION"
+	}
+}
+
+func (d *Database) boolType() string {
+	return "BOOLEAN"
+}
+
+func (d *Database) textType() string {
+	if d.Dialect == DialectMySQL {
+		return "VARCHAR(255)"
+	}
+	return "TEXT"
+}
+
+func (d *Database) blobType() string {
+	switch d.Dialect {
+	case DialectPostgreSQL:
+		return "BYTEA"
+	case DialectMySQL:
+		return "LONGBLOB"
+	default:
+		return "BLOB"
+	}
+}
+
+func (d *Database) timeType() string {
+	switch d.Dialect {
+	case DialectPostgreSQL, DialectANSI:
+		return "TIMESTAMP WITH TIME ZONE"
+	case DialectMySQL:
+		return "DATETIME(6)"
+	default:
+		return "DATETIME"
+	}
+}
+
+// ColumnType implements ColumnTyper for fields with the IdentityMeddler
+func (elt IdentityMeddler) ColumnType(d *Database, fieldType reflect.Type) (string, bool, error) {
+	return d.goColumnType(fieldType)
+}
+
+// ColumnType implements ColumnTyper for fields with a TimeMeddler
+func (elt TimeMeddler) ColumnType(d *Database, fieldType reflect.Type) (string, bool, error) {
+	return d.timeType(), elt.ZeroIsNull || fieldType.Kind() == reflect.Ptr, nil
+}
+
+// ColumnType implements ColumnTyper for fields with the ZeroIsNullMeddler
+func (elt ZeroIsNullMeddler) ColumnType(d *Database, fieldType reflect.Type) (string, bool, error) {
+	sqlType, _, err := d.goColumnType(fieldType)
+	return sqlType, true, err
+}
+
+// ColumnType implements ColumnTyper for fields with the JSONMeddler
+func (zip JSONMeddler) ColumnType(d *Database, fieldType reflect.Type) (string, bool, error) {
+	return d.blobType(), false, nil
+}
+
+// ColumnType implements ColumnTyper for fields with the GobMeddler
+func (zip GobMeddler) ColumnType(d *Database, fieldType reflect.Type) (string, bool, error) {
+	return d.blobType(), false, nil
+}
+
+// ColumnType implements ColumnTyper for fields with the XMLMeddler
+func (zip XMLMeddler) ColumnType(d *Database, fieldType reflect.Type) (string, bool, error) {
+	if zip {
+		return d.blobType(), false, nil
+	}
+	if d.Dialect == DialectMySQL {
+		return "LONGTEXT", false, nil
+	}
+	return "TEXT", false, nil
+}
@@ -0,0 +1,64 @@
+package meddlerx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCreateTableSQL(t *testing.T) {
+	q, err := PostgreSQL.CreateTableSQL("person", new(Person))
+	if err != nil {
+		t.Fatalf("CreateTableSQL error: %v", err)
+	}
+	expected := `CREATE TABLE "person" (
+	"id" BIGSERIAL PRIMARY KEY,
+	"name" TEXT NOT NULL,
+	"Email" TEXT NOT NULL,
+	"Age" BIGINT,
+	"opened" TIMESTAMP WITH TIME ZONE NOT NULL,
+	"closed" TIMESTAMP WITH TIME ZONE,
+	"updated" TIMESTAMP WITH TIME ZONE,
+	"height" BIGINT
+)`
+	if q != expected {
+		t.Errorf("CreateTableSQL: expected\n%s\ngot\n%s", expected, q)
+	}
+
+	q, err = MySQL.CreateTableSQL("item", new(ItemJson))
+	if err != nil {
+		t.Fatalf("CreateTableSQL error: %v", err)
+	}
+	if !strings.Contains(q, "`id` BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY") || !strings.Contains(q, "`stuff` LONGBLOB NOT NULL") {
+		t.Errorf("CreateTableSQL: unexpected MySQL DDL:\n%s", q)
+	}
+
+	type unsupported struct {
+		ID int64 `meddler:"id,pk"`
+		C  complex128
+	}
+	if _, err := SQLite.CreateTableSQL("bad", new(unsupported)); err == nil {
+		t.Errorf("CreateTableSQL with complex field: expected err, got nil")
+	}
+}
+
+func TestEnsureTable(t *testing.T) {
+	once.Do(setup)
+
+	// create twice to check IF NOT EXISTS
+	for i := 0; i < 2; i++ {
+		if err := SQLite.EnsureTable(testCtx, db, "person_ddl", new(Person)); err != nil {
+			t.Fatalf("EnsureTable error: %v", err)
+		}
+	}
+	defer db.Exec("drop table person_ddl")
+
+	alice.ID = 0
+	if err := SQLite.Insert(testCtx, db, "person_ddl", alice); err != nil {
+		t.Fatalf("Insert into generated table: %v", err)
+	}
+	elt := new(Person)
+	if err := SQLite.Load(testCtx, db, "person_ddl", elt, alice.ID); err != nil {
+		t.Fatalf("Load from generated table: %v", err)
+	}
+	personEqual(t, elt, &Person{alice.ID, "Alice", 0, "alice@alice.com", 0, 32, when, when, &when, &aliceHeight})
+}
@@ -0,0 +1,119 @@
+package meddlerx
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Decoder converts rows of raw values into structs, applying the same
+// meddlers, coercion, and NULL handling as ScanRow. It lets rows that do not
+// come from *sql.Rows, such as CSV records, pgx CopyTo output, or row
+// payloads read from a message queue, go through the meddler conversion
+// pipeline. A Decoder is safe for concurrent use.
+type Decoder struct {
+	d    *Database
+	typ  reflect.Type
+	data *structData
+	plan *scanPlan
+}
+
+// NewDecoder returns a Decoder for rows with the given columns. dst is a
+// pointer to a struct of the type the rows will be decoded into; it is only
+// used for its type. Columns with no matching field are skipped when
+// decoding, as they are by ScanRow.
+func (d *Database) NewDecoder(dst interface{}, columns []string) (*Decoder, error) {
+	typ := reflect.TypeOf(dst)
+	data, err := getFields(typ)
+	if err != nil {
+		return nil, err
+	}
+	columns = append([]string(nil), columns...)
+	return &Decoder{d: d, typ: typ, data: data, plan: data.plan("", columns)}, nil
+}
+
+// NewDecoder using the Default Database type
+func NewDecoder(dst interface{}, columns []string) (*Decoder, error) {
+	return Default.NewDecoder(dst, columns)
+}
+
+// Columns returns the columns of the rows the Decoder accepts.
+func (dec *Decoder) Columns() []string {
+	return append([]string(nil), dec.plan.columns...)
+}
+
+// Decode stores a row of values, one for each column in order, in dst,
+// which must be a pointer to the struct type the Decoder was created for.
+// Values are expected in the forms a database driver returns them (int64,
+// float64, bool, []byte, string, time.Time, or nil for NULL); other values
+// are stored if they convert to the field's type. Errors converting a value
+// are reported as a *ScanError.
+func (dec *Decoder) Decode(values []interface{}, dst interface{}) error {
+	if t := reflect.TypeOf(dst); t != dec.typ {
+		return fmt.Errorf("meddler.Decode: expected %v, found %v", dec.typ, t)
+	}
+	columns := dec.plan.columns
+	if len(values) != len(columns) {
+		return fmt.Errorf("meddler.Decode: mismatch in number of columns (%d) and values (%d)",
+			len(columns), len(values))
+	}
+
+	if u, ok := dst.(RowUnmarshaler); ok {
+		return u.UnmarshalRow(columns, func(targets ...interface{}) error {
+			return dec.assign(values, targets)
+		})
+	}
+
+	targets, err := dec.d.planTargets(dec.plan, nil, dst)
+	if err != nil {
+		return err
+	}
+	if err := dec.assign(values, targets); err != nil {
+		return err
+	}
+	return dec.d.writePlanTargets(dec.plan, dst, targets)
+}
+
+// assign stores each value in its scan target, as rows.Scan would.
+func (dec *Decoder) assign(values, targets []interface{}) error {
+	if len(targets) != len(values) {
+		return fmt.Errorf("meddler.Decode: expected %d destination arguments, not %d", len(values), len(targets))
+	}
+	for i, target := range targets {
+		if err := assignValue(target, values[i]); err != nil {
+			scanErr := &ScanError{Column: dec.plan.columns[i], Err: err}
+			if field := dec.plan.fields[i]; field != nil {
+				scanErr.Field = dec.typ.Elem().FieldByIndex(field.index).Name
+			}
+			return scanErr
+		}
+	}
+	return nil
+}
+
+// DecodeAll decodes each row and appends the results to dst, a pointer to a
+// slice of structs or of pointers to structs, as for ScanAll. If a row
+// cannot be decoded, the rows before it are kept and the *ScanError records
+// the position of the failing row, counting from 1.
+func (dec *Decoder) DecodeAll(rows [][]interface{}, dst interface{}) error {
+	ptr := reflect.ValueOf(dst)
+	if ptr.Kind() != reflect.Ptr || ptr.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("meddler.DecodeAll: expected pointer to a slice, found %T", dst)
+	}
+	sliceVal := ptr.Elem()
+	byValue := sliceVal.Type().Elem().Kind() == reflect.Struct
+	if byValue && reflect.PtrTo(sliceVal.Type().Elem()) != dec.typ || !byValue && sliceVal.Type().Elem() != dec.typ {
+		return fmt.Errorf("meddler.DecodeAll: expected slice of %v, found %T", dec.typ, dst)
+	}
+
+	for n, values := range rows {
+		eltVal := reflect.New(dec.typ.Elem())
+		if err := dec.Decode(values, eltVal.Interface()); err != nil {
+			return atRow(err, n+1)
+		}
+		if byValue {
+			eltVal = eltVal.Elem()
+		}
+		sliceVal.Set(reflect.Append(sliceVal, eltVal))
+	}
+	return nil
+}
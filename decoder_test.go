@@ -0,0 +1,63 @@
+package meddlerx
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDecoder(t *testing.T) {
+	columns := []string{"id", "name", "Age", "opened", "closed", "height", "extra"}
+	dec, err := SQLite.NewDecoder(new(Person), columns)
+	if err != nil {
+		t.Fatalf("NewDecoder error: %v", err)
+	}
+
+	opened := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	p := new(Person)
+	if err := dec.Decode([]interface{}{int64(7), []byte("Alice"), nil, opened, nil, int64(180), "ignored"}, p); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if p.ID != 7 || p.Name != "Alice" || p.Age != 0 || !p.Opened.Equal(opened) || !p.Closed.IsZero() {
+		t.Errorf("unexpected result: %+v", p)
+	}
+	if p.Height == nil || *p.Height != 180 {
+		t.Errorf("expected height 180, got %v", p.Height)
+	}
+
+	// conversion failures name the column and field
+	err = dec.Decode([]interface{}{int64(8), "Bob", "old", opened, nil, nil, nil}, new(Person))
+	var scanErr *ScanError
+	if !errors.As(err, &scanErr) || scanErr.Column != "Age" || scanErr.Field != "Age" {
+		t.Errorf("expected a ScanError for Age, got %v", err)
+	}
+
+	if err := dec.Decode([]interface{}{int64(1)}, new(Person)); err == nil {
+		t.Errorf("expected an error for a short row")
+	}
+	if err := dec.Decode(make([]interface{}, len(columns)), new(HalfPerson)); err == nil {
+		t.Errorf("expected an error for the wrong struct type")
+	}
+
+	// DecodeAll keeps the rows before a failure and reports its position
+	rows := [][]interface{}{
+		{int64(1), "Alice", int64(30), opened, nil, nil, nil},
+		{int64(2), "Bob", nil, opened, nil, nil, nil},
+		{int64(3), "Carol", "x", opened, nil, nil, nil},
+	}
+	var people []Person
+	if err := dec.DecodeAll(rows[:2], &people); err != nil {
+		t.Fatalf("DecodeAll error: %v", err)
+	}
+	if len(people) != 2 || people[0].Age != 30 || people[1].Name != "Bob" {
+		t.Errorf("unexpected results: %+v", people)
+	}
+	var ptrs []*Person
+	err = dec.DecodeAll(rows, &ptrs)
+	if !errors.As(err, &scanErr) || scanErr.Row != 3 {
+		t.Errorf("expected a ScanError at row 3, got %v", err)
+	}
+	if len(ptrs) != 2 {
+		t.Errorf("expected 2 rows before the failure, got %d", len(ptrs))
+	}
+}
@@ -0,0 +1,76 @@
+package meddlerx
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// fieldDefault is the parsed default= option of a field: a value to insert
+// in place of a zero field, or the current time if now is set.
+type fieldDefault struct {
+	value reflect.Value
+	now   bool
+}
+
+// parseDefault parses the value of a default= option for field f. The
+// value "now()" is allowed on time.Time and *time.Time fields, and means
+// the time of the insert; anything else is converted to the field's type.
+func parseDefault(f reflect.StructField, value string) (*fieldDefault, error) {
+	if value == "now()" {
+		if f.Type != timeType && f.Type != reflect.PtrTo(timeType) {
+			return nil, fmt.Errorf("meddler found field %s with default=now(), but it is not a time.Time", f.Name)
+		}
+		return &fieldDefault{now: true}, nil
+	}
+	v := reflect.New(f.Type)
+	if err := assignValue(v.Interface(), value); err != nil {
+		return nil, fmt.Errorf("meddler found field %s with invalid default %q: %w", f.Name, value, err)
+	}
+	return &fieldDefault{value: v.Elem()}, nil
+}
+
+// applyDefaults sets the fields of src that are zero and have a default=
+// option to their default value.
+func applyDefaults(src interface{}) error {
+	data, err := getFields(reflect.TypeOf(src))
+	if err != nil {
+		return err
+	}
+	if len(data.defaults) == 0 {
+		return nil
+	}
+	structVal := reflect.ValueOf(src).Elem()
+	var now time.Time
+	for _, name := range data.columns {
+		def, present := data.defaults[name]
+		if !present {
+			continue
+		}
+		fieldVal := structVal.FieldByIndex(data.fields[name].index)
+		if !fieldVal.IsZero() {
+			continue
+		}
+		if !def.now {
+			if def.value.Kind() == reflect.Ptr {
+				// do not share the pointer between records
+				copied := reflect.New(def.value.Type().Elem())
+				copied.Elem().Set(def.value.Elem())
+				fieldVal.Set(copied)
+			} else {
+				fieldVal.Set(def.value)
+			}
+			continue
+		}
+		if now.IsZero() {
+			now = time.Now()
+		}
+		if fieldVal.Kind() == reflect.Ptr {
+			t := now
+			fieldVal.Set(reflect.ValueOf(&t))
+		} else {
+			fieldVal.Set(reflect.ValueOf(now))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package meddlerx
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type PersonDefaults struct {
+	ID     int64     `meddler:"id,pk"`
+	Name   string    `meddler:"name,default=anonymous"`
+	Email  string    `meddler:"Email"`
+	Age    int       `meddler:"Age,default=18"`
+	Opened time.Time `meddler:"opened,utctime,default=now()"`
+	Closed time.Time `meddler:"closed,utctimez"`
+	Height *int      `meddler:"height,default=170"`
+}
+
+func TestDefaults(t *testing.T) {
+	once.Do(setup)
+	defer db.Exec("delete from person")
+
+	before := time.Now()
+	p := &PersonDefaults{Email: "anon@example.com"}
+	if err := Insert(testCtx, db, "person", p); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	if p.Name != "anonymous" || p.Age != 18 || p.Height == nil || *p.Height != 170 || p.Opened.Before(before) {
+		t.Errorf("Insert with defaults: got %+v", p)
+	}
+
+	loaded := new(PersonDefaults)
+	if err := Load(testCtx, db, "person", loaded, p.ID); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if loaded.Name != "anonymous" || loaded.Age != 18 || *loaded.Height != 170 {
+		t.Errorf("Load after insert with defaults: got %+v", loaded)
+	}
+
+	// set fields are left alone, and pointers are not shared
+	q := &PersonDefaults{Name: "Carol", Age: 3}
+	if err := Insert(testCtx, db, "person", q); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	if q.Name != "Carol" || q.Age != 3 || q.Height == p.Height {
+		t.Errorf("Insert with values: got %+v", q)
+	}
+
+	// updates do not apply defaults
+	q.Age = 0
+	if err := Update(testCtx, db, "person", q); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	if q.Age != 0 {
+		t.Errorf("Update applied a default: got %+v", q)
+	}
+}
+
+func TestDefaultErrors(t *testing.T) {
+	type badNow struct {
+		Name string `meddler:"name,default=now()"`
+	}
+	type badInt struct {
+		Age int `meddler:"Age,default=old"`
+	}
+	for _, src := range []interface{}{new(badNow), new(badInt)} {
+		if _, err := getFields(reflect.TypeOf(src)); err == nil {
+			t.Errorf("getFields(%T): expected an error", src)
+		}
+	}
+}
+
+type generatedName struct {
+	ID    int64  `meddler:"id,pk"`
+	First string `meddler:"first"`
+	Last  string `meddler:"last"`
+	Full  string `meddler:"full,generated"`
+}
+
+func TestGenerated(t *testing.T) {
+	once.Do(setup)
+	if _, err := db.Exec(`create table gen_name (
+		id integer primary key,
+		first text not null,
+		last text not null,
+		full text generated always as (first || ' ' || last) virtual
+	)`); err != nil {
+		t.Fatalf("creating gen_name table: %v", err)
+	}
+	defer db.Exec("drop table gen_name")
+
+	n := &generatedName{First: "Ada", Last: "Lovelace", Full: "ignored"}
+	if err := Insert(testCtx, db, "gen_name", n); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	n.Last = "Byron"
+	if err := Save(testCtx, db, "gen_name", n); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+	if _, err := SQLite.Upsert(testCtx, db, "gen_name", n); err != nil {
+		t.Fatalf("Upsert error: %v", err)
+	}
+
+	loaded := new(generatedName)
+	if err := Load(testCtx, db, "gen_name", loaded, n.ID); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if loaded.Full != "Ada Byron" {
+		t.Errorf("generated column: expected Ada Byron, got %q", loaded.Full)
+	}
+}
@@ -0,0 +1,128 @@
+package meddlerx
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// columnMeta holds the schema metadata given in a field's tag with the
+// comment= and index= options.
+type columnMeta struct {
+	comment string
+	index   string // the name of the index, empty for an unnamed unique index
+	unique  bool
+}
+
+// set records a comment= or index= option for field f. The index option is
+// either an index name, "unique", or "unique:" followed by a name.
+func (meta *columnMeta) set(f reflect.StructField, key, value string) error {
+	if key == "comment" {
+		meta.comment = value
+		return nil
+	}
+	switch {
+	case value == "unique":
+		meta.unique = true
+	case strings.HasPrefix(value, "unique:"):
+		meta.unique, meta.index = true, strings.TrimPrefix(value, "unique:")
+	default:
+		meta.index = value
+	}
+	if meta.index == "" && !meta.unique {
+		return fmt.Errorf("meddler found field %s with an empty index name", f.Name)
+	}
+	return nil
+}
+
+// ColumnInfo describes a column mapped by a struct, as reported by
+// Describe.
+type ColumnInfo struct {
+	Name       string // the column name
+	Field      string // the Go struct field it maps to
+	PrimaryKey bool
+	Generated  bool // computed by the database, so never written
+	SQLType    string
+	Nullable   bool
+	Comment    string // from the comment= tag option
+	Index      string // from the index= tag option; empty for an unnamed unique index
+	Unique     bool   // the index is unique
+}
+
+// Describe returns the columns mapped by src, a pointer to a struct, in
+// the order of Columns. SQLType and Nullable give the column type that
+// CreateTableSQL would use in d's dialect, and are empty if the field's
+// meddler does not implement ColumnTyper.
+func (d *Database) Describe(src interface{}) ([]ColumnInfo, error) {
+	data, err := getFields(reflect.TypeOf(src))
+	if err != nil {
+		return nil, err
+	}
+	structType := reflect.TypeOf(src).Elem()
+
+	var infos []ColumnInfo
+	for _, name := range data.columns {
+		field := data.fields[name]
+		structField := structType.FieldByIndex(field.index)
+		info := ColumnInfo{
+			Name:       name,
+			Field:      structField.Name,
+			PrimaryKey: field.primaryKey,
+			Generated:  data.generated[name],
+		}
+		if field.primaryKey {
+			info.SQLType = d.primaryKeyType(structField.Type)
+		} else if typer, ok := field.meddler.(ColumnTyper); ok {
+			if sqlType, nullable, err := typer.ColumnType(d, structField.Type); err == nil {
+				info.SQLType, info.Nullable = sqlType, nullable
+			}
+		}
+		if meta := data.meta[name]; meta != nil {
+			info.Comment, info.Index, info.Unique = meta.comment, meta.index, meta.unique
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// Describe using the Default Database type
+func Describe(src interface{}) ([]ColumnInfo, error) {
+	return Default.Describe(src)
+}
+
+// tableIndex is an index declared with index= tag options.
+type tableIndex struct {
+	name    string
+	unique  bool
+	columns []string
+}
+
+// indexes returns the indexes declared by the fields of data for table.
+// Fields naming the same index form one index over their columns, in
+// column order. An unnamed unique index is named after the table and
+// column.
+func (data *structData) indexes(table string) ([]*tableIndex, error) {
+	var list []*tableIndex
+	byName := make(map[string]*tableIndex)
+	for _, name := range data.columns {
+		meta := data.meta[name]
+		if meta == nil || (meta.index == "" && !meta.unique) {
+			continue
+		}
+		indexName := meta.index
+		if indexName == "" {
+			indexName = table + "_" + name + "_key"
+		}
+		if index, present := byName[indexName]; present {
+			if index.unique != meta.unique {
+				return nil, fmt.Errorf("meddler: index %s is declared both unique and not unique", indexName)
+			}
+			index.columns = append(index.columns, name)
+			continue
+		}
+		index := &tableIndex{name: indexName, unique: meta.unique, columns: []string{name}}
+		byName[indexName] = index
+		list = append(list, index)
+	}
+	return list, nil
+}
@@ -0,0 +1,98 @@
+package meddlerx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type member struct {
+	ID     int64  `meddler:"id,pk"`
+	Email  string `meddler:"email,comment=user's login,index=unique"`
+	Org    int64  `meddler:"org,index=unique:member_org_name"`
+	Name   string `meddler:"name,index=unique:member_org_name"`
+	Region string `meddler:"region,index=member_region,comment=two letters"`
+	Notes  string `meddler:"notes"`
+}
+
+func TestDescribe(t *testing.T) {
+	infos, err := PostgreSQL.Describe(new(member))
+	if err != nil {
+		t.Fatalf("Describe error: %v", err)
+	}
+	expected := []ColumnInfo{
+		{Name: "id", Field: "ID", PrimaryKey: true, SQLType: "BIGSERIAL PRIMARY KEY"},
+		{Name: "email", Field: "Email", SQLType: "TEXT", Comment: "user's login", Unique: true},
+		{Name: "org", Field: "Org", SQLType: "BIGINT", Index: "member_org_name", Unique: true},
+		{Name: "name", Field: "Name", SQLType: "TEXT", Index: "member_org_name", Unique: true},
+		{Name: "region", Field: "Region", SQLType: "TEXT", Index: "member_region", Comment: "two letters"},
+		{Name: "notes", Field: "Notes", SQLType: "TEXT"},
+	}
+	if !reflect.DeepEqual(infos, expected) {
+		t.Errorf("Describe: expected\n%+v\ngot\n%+v", expected, infos)
+	}
+
+	type badIndex struct {
+		ID   int64  `meddler:"id,pk"`
+		Name string `meddler:"name,index="`
+	}
+	if _, err := SQLite.Describe(new(badIndex)); err == nil {
+		t.Errorf("expected an error for an empty index name")
+	}
+}
+
+func TestCreateTableStatements(t *testing.T) {
+	stmts, err := PostgreSQL.CreateTableStatements("member", new(member))
+	if err != nil {
+		t.Fatalf("CreateTableStatements error: %v", err)
+	}
+	expected := []string{
+		`CREATE UNIQUE INDEX "member_email_key" ON "member" ("email")`,
+		`CREATE UNIQUE INDEX "member_org_name" ON "member" ("org", "name")`,
+		`CREATE INDEX "member_region" ON "member" ("region")`,
+		`COMMENT ON COLUMN "member"."email" IS 'user''s login'`,
+		`COMMENT ON COLUMN "member"."region" IS 'two letters'`,
+	}
+	if len(stmts) != 1+len(expected) || !reflect.DeepEqual(stmts[1:], expected) {
+		t.Errorf("CreateTableStatements: expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(stmts[1:], "\n"))
+	}
+
+	// MySQL keeps everything in the CREATE TABLE statement
+	stmts, err = MySQL.CreateTableStatements("member", new(member))
+	if err != nil {
+		t.Fatalf("CreateTableStatements error: %v", err)
+	}
+	for _, want := range []string{
+		"`email` VARCHAR(255) NOT NULL COMMENT 'user''s login'",
+		"UNIQUE KEY `member_email_key` (`email`)",
+		"UNIQUE KEY `member_org_name` (`org`, `name`)",
+		"KEY `member_region` (`region`)",
+	} {
+		if len(stmts) != 1 || !strings.Contains(stmts[0], want) {
+			t.Errorf("CreateTableStatements: expected %s in\n%s", want, strings.Join(stmts, "\n"))
+		}
+	}
+
+	// EnsureTable runs them all and can be repeated
+	once.Do(setup)
+	defer db.Exec("drop table member_ddl")
+	for i := 0; i < 2; i++ {
+		if err := SQLite.EnsureTable(testCtx, db, "member_ddl", new(member)); err != nil {
+			t.Fatalf("EnsureTable error: %v", err)
+		}
+	}
+	var sqlText string
+	if err := db.QueryRow("select sql from sqlite_master where name = 'member_ddl'").Scan(&sqlText); err != nil {
+		t.Fatalf("reading table: %v", err)
+	}
+	if !strings.Contains(sqlText, `"region" TEXT NOT NULL /* two letters */`) {
+		t.Errorf("expected the comment in the table definition, got\n%s", sqlText)
+	}
+	var n int
+	if err := db.QueryRow("select count(*) from sqlite_master where type = 'index' and tbl_name = 'member_ddl' and name like 'member_%'").Scan(&n); err != nil || n != 3 {
+		t.Errorf("expected 3 indexes, got %d, %v", n, err)
+	}
+	if _, err := db.Exec("insert into member_ddl (email, org, name, region, notes) values ('a', 1, 'x', 'us', ''), ('a', 2, 'y', 'us', '')"); err == nil {
+		t.Errorf("expected the unique index on email to reject a duplicate")
+	}
+}
@@ -0,0 +1,302 @@
+package meddlerx
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// defaultDualWriteQueue is the queue capacity of an async DualWriter with
+// no QueueSize.
+const defaultDualWriteQueue = 1024
+
+// DualWriter mirrors the writes made to a primary database onto a secondary
+// one, such as a new cluster or schema that is being migrated to, so that
+// both stay current until reads are switched over. The primary is written
+// first, and its result is what the caller gets; the secondary is then
+// written with the same primary key.
+//
+// Inserts, updates, and saves are mirrored as a Save that inserts the row
+// if the secondary does not have it yet, so mirroring can start before a
+// backfill of the secondary has finished. Deletes are mirrored as deletes.
+// Mirrored writes are not part of any transaction on the primary; a write
+// that is later rolled back there has still been mirrored.
+//
+// By default the secondary is written before each method returns, and a
+// failure there is returned as a *DualWriteError after the primary write
+// has succeeded. With Async set, mirrored writes are queued and applied in
+// order by a background goroutine, and failures go to OnError. The record
+// is deep-copied when queued, so the caller may go on changing it. Call
+// Close to wait for queued writes before exiting.
+type DualWriter struct {
+	// Primary is the Database for the primary. If nil, Default is used.
+	Primary *Database
+
+	// Secondary is the Database for the secondary, and SecondaryDB the
+	// Querier it is written through. If Secondary is nil, Primary is used.
+	Secondary   *Database
+	SecondaryDB Querier
+
+	// Async queues mirrored writes instead of making them before returning.
+	// QueueSize is the capacity of the queue; if it is zero, 1024 is used.
+	// When the queue is full, writes wait for room or for their context to
+	// be done, in which case the mirrored write is dropped and reported.
+	Async     bool
+	QueueSize int
+
+	// OnError receives the mirrored writes that failed in async mode. If
+	// nil, they are logged with the secondary's Logger.
+	OnError func(err *DualWriteError)
+
+	start  sync.Once
+	mu     sync.RWMutex
+	closed bool
+	queue  chan *dualWrite
+	done   chan struct{}
+}
+
+// dualWrite is a write waiting to be mirrored.
+type dualWrite struct {
+	ctx   context.Context
+	op    string
+	table string
+	src   interface{}
+	pk    int64
+	opts  []WriteOption
+}
+
+func (w *DualWriter) primary() *Database {
+	if w.Primary == nil {
+		return Default
+	}
+	return w.Primary
+}
+
+func (w *DualWriter) secondary() *Database {
+	d := w.Secondary
+	if d == nil {
+		d = w.primary()
+	}
+	d = d.Clone()
+	d.SaveMode = SaveInsertMissing
+	return d
+}
+
+// Insert inserts a record into the primary and mirrors it.
+func (w *DualWriter) Insert(ctx context.Context, db Querier, table string, src interface{}, opts ...WriteOption) error {
+	if err := w.primary().Insert(ctx, db, table, src, opts...); err != nil {
+		return err
+	}
+	return w.mirror(ctx, "Insert", table, src, opts)
+}
+
+// Update updates a record in the primary and mirrors it.
+func (w *DualWriter) Update(ctx context.Context, db Querier, table string, src interface{}, opts ...WriteOption) error {
+	if err := w.primary().Update(ctx, db, table, src, opts...); err != nil {
+		return err
+	}
+	return w.mirror(ctx, "Update", table, src, opts)
+}
+
+// Save saves a record in the primary and mirrors it.
+func (w *DualWriter) Save(ctx context.Context, db Querier, table string, src interface{}, opts ...WriteOption) error {
+	if err := w.primary().Save(ctx, db, table, src, opts...); err != nil {
+		return err
+	}
+	return w.mirror(ctx, "Save", table, src, opts)
+}
+
+// Delete deletes a record from the primary and mirrors it.
+func (w *DualWriter) Delete(ctx context.Context, db Querier, table string, src interface{}, opts ...WriteOption) error {
+	if err := w.primary().Delete(ctx, db, table, src, opts...); err != nil {
+		return err
+	}
+	return w.mirror(ctx, "Delete", table, src, opts)
+}
+
+// mirror writes src to the secondary, or queues it to be written.
+func (w *DualWriter) mirror(ctx context.Context, op, table string, src interface{}, opts []WriteOption) error {
+	job := &dualWrite{ctx: ctx, op: op, table: table, src: src}
+	_, job.pk, _ = w.primary().PrimaryKey(src)
+
+	// options such as RowsAffected only make sense for the primary
+	for _, opt := range opts {
+		if mask, ok := opt.(FieldMask); ok {
+			job.opts = append(job.opts, mask)
+		}
+	}
+
+	if !w.Async {
+		return w.apply(job)
+	}
+
+	// the caller may change the record or cancel the context once we return
+	job.src = deepCopy(reflect.ValueOf(src), make(map[copiedPointer]reflect.Value)).Interface()
+	job.ctx = detachedContext{ctx}
+
+	w.start.Do(w.run)
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		w.report(job, ErrDualWriterClosed)
+		return nil
+	}
+	select {
+	case w.queue <- job:
+	case <-ctx.Done():
+		w.report(job, ctx.Err())
+	}
+	return nil
+}
+
+// apply makes a mirrored write.
+func (w *DualWriter) apply(job *dualWrite) error {
+	d := w.secondary()
+	var err error
+	if job.op == "Delete" {
+		err = d.Delete(job.ctx, w.SecondaryDB, job.table, job.src, job.opts...)
+	} else {
+		err = d.Save(job.ctx, w.SecondaryDB, job.table, job.src, job.opts...)
+	}
+	if err != nil {
+		return &DualWriteError{Op: job.op, Table: job.table, PK: job.pk, Err: err}
+	}
+	return nil
+}
+
+// run starts the goroutine that applies queued writes.
+func (w *DualWriter) run() {
+	size := w.QueueSize
+	if size <= 0 {
+		size = defaultDualWriteQueue
+	}
+	w.queue = make(chan *dualWrite, size)
+	w.done = make(chan struct{})
+	go func() {
+		defer close(w.done)
+		for job := range w.queue {
+			if err := w.apply(job); err != nil {
+				w.reportError(err.(*DualWriteError))
+			}
+		}
+	}()
+}
+
+// report passes a write that could not be mirrored to OnError.
+func (w *DualWriter) report(job *dualWrite, err error) {
+	w.reportError(&DualWriteError{Op: job.op, Table: job.table, PK: job.pk, Err: err})
+}
+
+func (w *DualWriter) reportError(err *DualWriteError) {
+	if w.OnError != nil {
+		w.OnError(err)
+		return
+	}
+	w.secondary().logf("%v", err)
+}
+
+// Close stops an async DualWriter from accepting more writes and waits
+// until the queued ones have been mirrored, or until ctx is done. Writes
+// made to the primary after Close are not mirrored, and are reported with
+// ErrDualWriterClosed. In sync mode, Close does nothing.
+func (w *DualWriter) Close(ctx context.Context) error {
+	if !w.Async {
+		return nil
+	}
+	w.start.Do(w.run)
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// deepCopy returns a copy of v that shares no pointers, slices, maps, or
+// arrays with it through exported fields, so that later changes to a
+// record, including to the contents of a json or gob field, do not reach
+// the copy. copies maps the pointers already copied, so cycles, e.g.
+// through relation fields, are kept as cycles.
+func deepCopy(v reflect.Value, copies map[copiedPointer]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		key := copiedPointer{v.Type(), v.Pointer()}
+		if c, present := copies[key]; present {
+			return c
+		}
+		c := reflect.New(v.Type().Elem())
+		copies[key] = c
+		c.Elem().Set(deepCopy(v.Elem(), copies))
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				c.Field(i).Set(deepCopy(v.Field(i), copies))
+			}
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i), copies))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i), copies))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value(), copies))
+		}
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopy(v.Elem(), copies))
+		return c
+	default:
+		return v
+	}
+}
+
+// copiedPointer identifies a pointer copied by deepCopy.
+type copiedPointer struct {
+	typ reflect.Type
+	ptr uintptr
+}
+
+// detachedContext keeps the values of a context but not its deadline or
+// cancellation, for work that outlives the call that started it.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
@@ -0,0 +1,227 @@
+package meddlerx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type mirrored struct {
+	ID   int64  `meddler:"id,pk"`
+	Name string `meddler:"name"`
+}
+
+// newMirrorDBs returns a primary and a secondary database, each with an
+// empty mirrored table.
+func newMirrorDBs(t *testing.T) (*sql.DB, *sql.DB) {
+	var dbs []*sql.DB
+	for i := 0; i < 2; i++ {
+		mdb, err := sql.Open("sqlite3", ":memory:")
+		if err != nil {
+			t.Fatalf("opening database: %v", err)
+		}
+		mdb.SetMaxOpenConns(1)
+		if err := SQLite.EnsureTable(testCtx, mdb, "mirrored", new(mirrored)); err != nil {
+			t.Fatalf("EnsureTable error: %v", err)
+		}
+		dbs = append(dbs, mdb)
+	}
+	return dbs[0], dbs[1]
+}
+
+func mirroredName(t *testing.T, mdb *sql.DB, id int64) string {
+	rec := new(mirrored)
+	err := SQLite.Load(testCtx, mdb, "mirrored", rec, id)
+	if err == sql.ErrNoRows {
+		return ""
+	}
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	return rec.Name
+}
+
+func TestDualWriter(t *testing.T) {
+	primary, secondary := newMirrorDBs(t)
+	defer primary.Close()
+	defer secondary.Close()
+	w := &DualWriter{Primary: SQLite, SecondaryDB: secondary}
+
+	rec := &mirrored{Name: "first"}
+	if err := w.Insert(testCtx, primary, "mirrored", rec); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	if name := mirroredName(t, secondary, rec.ID); name != "first" {
+		t.Errorf("expected the insert to be mirrored with key %d, got %q", rec.ID, name)
+	}
+
+	// a row the secondary already has, e.g. from a backfill, is updated
+	if _, err := secondary.Exec("insert into mirrored (id, name) values (2, 'stale')"); err != nil {
+		t.Fatalf("backfilling: %v", err)
+	}
+	second := &mirrored{Name: "second"}
+	if err := w.Save(testCtx, primary, "mirrored", second); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+	if name := mirroredName(t, secondary, second.ID); second.ID != 2 || name != "second" {
+		t.Errorf("expected row 2 to be second, got %d %q", second.ID, name)
+	}
+
+	// and a row it does not have yet is inserted by Update
+	if _, err := secondary.Exec("delete from mirrored where id = ?", rec.ID); err != nil {
+		t.Fatalf("deleting: %v", err)
+	}
+	rec.Name = "renamed"
+	var n int64
+	if err := w.Update(testCtx, primary, "mirrored", rec, RowsAffected(&n)); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	if name := mirroredName(t, secondary, rec.ID); name != "renamed" || n != 1 {
+		t.Errorf("expected renamed with 1 row affected on the primary, got %q, %d", name, n)
+	}
+
+	if err := w.Delete(testCtx, primary, "mirrored", rec); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	if name := mirroredName(t, secondary, rec.ID); name != "" {
+		t.Errorf("expected the delete to be mirrored, got %q", name)
+	}
+
+	// a failure on the secondary is returned once the primary is written
+	if _, err := secondary.Exec("drop table mirrored"); err != nil {
+		t.Fatalf("dropping: %v", err)
+	}
+	second.Name = "primary only"
+	err := w.Update(testCtx, primary, "mirrored", second)
+	var dualErr *DualWriteError
+	if !errors.As(err, &dualErr) || dualErr.Op != "Update" || dualErr.PK != second.ID {
+		t.Errorf("expected a DualWriteError, got %v", err)
+	}
+	if name := mirroredName(t, primary, second.ID); name != "primary only" {
+		t.Errorf("expected the primary to be updated, got %q", name)
+	}
+}
+
+func TestDualWriterAsync(t *testing.T) {
+	primary, secondary := newMirrorDBs(t)
+	defer primary.Close()
+	defer secondary.Close()
+
+	var (
+		mu     sync.Mutex
+		failed []*DualWriteError
+	)
+	w := &DualWriter{Primary: SQLite, SecondaryDB: secondary, Async: true, QueueSize: 2,
+		OnError: func(err *DualWriteError) {
+			mu.Lock()
+			failed = append(failed, err)
+			mu.Unlock()
+		},
+	}
+
+	// the record is copied when queued, and the context may end
+	ctx, cancel := context.WithCancel(testCtx)
+	var recs []*mirrored
+	for _, name := range []string{"a", "b", "c", "d"} {
+		rec := &mirrored{Name: name}
+		if err := w.Insert(ctx, primary, "mirrored", rec); err != nil {
+			t.Fatalf("Insert error: %v", err)
+		}
+		rec.Name = "changed"
+		recs = append(recs, rec)
+	}
+	cancel()
+	if err := w.Close(testCtx); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	for i, name := range []string{"a", "b", "c", "d"} {
+		if got := mirroredName(t, secondary, recs[i].ID); got != name {
+			t.Errorf("expected %s mirrored as row %d, got %q", name, recs[i].ID, got)
+		}
+	}
+
+	// writes after Close still reach the primary and are reported
+	late := &mirrored{Name: "late"}
+	if err := w.Insert(testCtx, primary, "mirrored", late); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	if name := mirroredName(t, primary, late.ID); name != "late" {
+		t.Errorf("expected the late insert on the primary, got %q", name)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(failed) != 1 || !errors.Is(failed[0], ErrDualWriterClosed) || failed[0].PK != late.ID {
+		t.Errorf("expected the late insert to be reported, got %v", failed)
+	}
+}
+
+func TestDualWriterAsyncError(t *testing.T) {
+	primary, secondary := newMirrorDBs(t)
+	defer primary.Close()
+	defer secondary.Close()
+	if _, err := secondary.Exec("drop table mirrored"); err != nil {
+		t.Fatalf("dropping: %v", err)
+	}
+
+	errs := make(chan *DualWriteError, 1)
+	w := &DualWriter{Primary: SQLite, SecondaryDB: secondary, Async: true,
+		OnError: func(err *DualWriteError) { errs <- err }}
+	rec := &mirrored{Name: "x"}
+	if err := w.Insert(testCtx, primary, "mirrored", rec); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	if err := w.Close(testCtx); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	select {
+	case err := <-errs:
+		if err.Op != "Insert" || err.Table != "mirrored" || err.PK != rec.ID {
+			t.Errorf("unexpected error %v", err)
+		}
+	default:
+		t.Errorf("expected the failed insert to be reported")
+	}
+}
+
+type mirroredTags struct {
+	ID     int64           `meddler:"id,pk"`
+	Nick   *string         `meddler:"nick"`
+	Tags   map[string]bool `meddler:"tags,json"`
+	Parent *mirroredTags   `meddler:"-"`
+}
+
+func TestDualWriterAsyncDeepCopy(t *testing.T) {
+	primary, secondary := newMirrorDBs(t)
+	defer primary.Close()
+	defer secondary.Close()
+	for _, mdb := range []*sql.DB{primary, secondary} {
+		if err := SQLite.EnsureTable(testCtx, mdb, "mirrored_tags", new(mirroredTags)); err != nil {
+			t.Fatalf("EnsureTable error: %v", err)
+		}
+	}
+	w := &DualWriter{Primary: SQLite, SecondaryDB: secondary, Async: true}
+
+	// nested values are copied too, and cycles survive the copy
+	nick := "al"
+	rec := &mirroredTags{Nick: &nick, Tags: map[string]bool{"a": true}}
+	rec.Parent = rec
+	if err := w.Insert(testCtx, primary, "mirrored_tags", rec); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	nick = "changed"
+	rec.Tags["b"] = true
+	if err := w.Close(testCtx); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+
+	mirrored := new(mirroredTags)
+	if err := SQLite.Load(testCtx, secondary, "mirrored_tags", mirrored, rec.ID); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if mirrored.Nick == nil || *mirrored.Nick != "al" || !reflect.DeepEqual(mirrored.Tags, map[string]bool{"a": true}) {
+		t.Errorf("expected the record as it was when queued, got %v %v", mirrored.Nick, mirrored.Tags)
+	}
+}
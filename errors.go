@@ -0,0 +1,213 @@
+package meddlerx
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoPrimaryKey is returned when an operation needs a primary key
+	// field but the struct does not have one marked.
+	ErrNoPrimaryKey = errors.New("meddler: no primary key field found")
+
+	// ErrPrimaryKeyNotZero is returned by Insert when the primary key
+	// field already holds a value.
+	ErrPrimaryKeyNotZero = errors.New("meddler: primary key must be zero")
+
+	// ErrStaleRow is returned when a write that targets an existing row
+	// by primary key finds no such row in the database.
+	ErrStaleRow = errors.New("meddler: row not found for primary key")
+
+	// ErrStop can be returned by the callback given to QueryEach to stop
+	// early without an error.
+	ErrStop = errors.New("meddler: stop iteration")
+
+	// ErrNoQuerier is returned by the ...Ctx functions when the context
+	// does not carry a Querier.
+	ErrNoQuerier = errors.New("meddler: no Querier in context")
+
+	// ErrReadOnly is returned by writes through a Database that has
+	// ReadOnly set, or to a table that is configured as read-only.
+	ErrReadOnly = errors.New("meddler: read-only")
+
+	// ErrDualWriterClosed is reported for writes that a DualWriter could
+	// not mirror because it had been closed.
+	ErrDualWriterClosed = errors.New("meddler: dual writer closed")
+)
+
+// QueryError is returned when the database driver reports an error while
+// running a query generated by meddler. Err holds the original driver error.
+//
+// Args is only populated, and the query text only included in the error
+// message, when the Database has VerboseErrors set, since bound arguments
+// frequently contain data that should not end up in logs.
+type QueryError struct {
+	Op    string        // the meddler operation, e.g. "Insert"
+	Table string        // the table involved, if any
+	Query string        // the SQL that was sent to the driver
+	Args  []interface{} // the bound arguments, if VerboseErrors is set
+	Err   error         // the error returned by the driver
+
+	verbose bool
+}
+
+func (err *QueryError) Error() string {
+	msg := fmt.Sprintf("meddler.%s: DB error: %v", err.Op, err.Err)
+	if err.Table != "" {
+		msg = fmt.Sprintf("meddler.%s: DB error on table %s: %v", err.Op, err.Table, err.Err)
+	}
+	if err.verbose {
+		msg += fmt.Sprintf(" [query: %s] [args: %v]", err.Query, err.Args)
+	}
+	return msg
+}
+
+// Unwrap returns the driver error.
+func (err *QueryError) Unwrap() error {
+	return err.Err
+}
+
+// DriverErr returns the original error as returned by the database driver
+// if the error comes from the driver, with the second value set to true.
+// Otherwise, it returns err itself with false as second value.
+func DriverErr(err error) (error, bool) {
+	var qe *QueryError
+	if errors.As(err, &qe) {
+		return qe.Err, true
+	}
+	return err, false
+}
+
+// queryError wraps a driver error in a QueryError, attaching the query
+// arguments only when the Database is configured to do so.
+func (d *Database) queryError(op, table, query string, args []interface{}, err error) *QueryError {
+	qe := &QueryError{Op: op, Table: table, Query: query, Err: err, verbose: d.VerboseErrors}
+	if d.VerboseErrors {
+		qe.Args = args
+	}
+	return qe
+}
+
+// ElementError is the error for one element of a slice passed to SaveAll or
+// InsertAll.
+type ElementError struct {
+	Index int
+	Err   error
+}
+
+func (err ElementError) Error() string {
+	return fmt.Sprintf("element %d: %v", err.Index, err.Err)
+}
+
+// Unwrap returns the element's error.
+func (err ElementError) Unwrap() error {
+	return err.Err
+}
+
+// BatchError is returned by SaveAll and InsertAll when elements fail. It
+// holds one ElementError per failed element, in index order, so callers
+// can retry just those records.
+type BatchError struct {
+	Op     string // the meddler operation, e.g. "SaveAll"
+	Errors []ElementError
+
+	// Saved is set if the elements that did not fail were kept, which
+	// happens only with ContinueOnError. Otherwise SaveAll and InsertAll
+	// roll back their own transaction; in a transaction of the caller's,
+	// the elements before the failure have been written, and the caller
+	// should roll back.
+	Saved bool
+}
+
+func (err *BatchError) Error() string {
+	if len(err.Errors) == 1 {
+		return fmt.Sprintf("meddler.%s: %v", err.Op, err.Errors[0])
+	}
+	return fmt.Sprintf("meddler.%s: %d elements failed, first %v", err.Op, len(err.Errors), err.Errors[0])
+}
+
+// Unwrap returns the error of the first failed element.
+func (err *BatchError) Unwrap() error {
+	return err.Errors[0].Err
+}
+
+// Failed returns the indexes of the failed elements.
+func (err *BatchError) Failed() []int {
+	indexes := make([]int, len(err.Errors))
+	for i, elt := range err.Errors {
+		indexes[i] = elt.Index
+	}
+	return indexes
+}
+
+// MissingKeysError is returned by LoadAll when some of the primary keys
+// it was given have no row. The rows that were found are loaded anyway.
+type MissingKeysError struct {
+	Table string
+	Keys  []int64 // the missing keys, in the order they were given
+}
+
+func (err *MissingKeysError) Error() string {
+	return fmt.Sprintf("meddler.LoadAll: %d keys not found in %s: %v", len(err.Keys), err.Table, err.Keys)
+}
+
+// Unwrap returns sql.ErrNoRows.
+func (err *MissingKeysError) Unwrap() error {
+	return sql.ErrNoRows
+}
+
+// DualWriteError reports a write that succeeded on the primary database
+// of a DualWriter but could not be mirrored on the secondary.
+type DualWriteError struct {
+	Op    string // the mirrored operation, e.g. "Update"
+	Table string
+	PK    int64 // the primary key of the record, or 0 if it has none
+	Err   error
+}
+
+func (err *DualWriteError) Error() string {
+	return fmt.Sprintf("meddler.DualWriter: mirroring %s of %s row %d: %v", err.Op, err.Table, err.PK, err.Err)
+}
+
+// Unwrap returns the error from the secondary database.
+func (err *DualWriteError) Unwrap() error {
+	return err.Err
+}
+
+// ScanError is returned when the database driver cannot store a column of
+// a result row in its struct field, most often a NULL in a field that
+// cannot hold one. Err holds the driver's error.
+type ScanError struct {
+	Row    int    // the position of the row in the result, from 1, or 0 if unknown
+	Column string // the column being scanned
+	Field  string // the Go struct field it was going to, if any
+	Err    error
+}
+
+func (err *ScanError) Error() string {
+	msg := "meddler: scanning"
+	if err.Row > 0 {
+		msg += fmt.Sprintf(" row %d,", err.Row)
+	}
+	msg += fmt.Sprintf(" column %s", err.Column)
+	if err.Field != "" {
+		msg += fmt.Sprintf(" into field %s", err.Field)
+	}
+	return msg + ": " + err.Err.Error()
+}
+
+// Unwrap returns the driver error.
+func (err *ScanError) Unwrap() error {
+	return err.Err
+}
+
+// atRow records the position of the row being scanned in err, if it is a
+// ScanError.
+func atRow(err error, row int) error {
+	var scanErr *ScanError
+	if errors.As(err, &scanErr) {
+		scanErr.Row = row
+	}
+	return err
+}
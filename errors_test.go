@@ -0,0 +1,147 @@
+package meddlerx
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+func TestErrorSentinels(t *testing.T) {
+	once.Do(setup)
+
+	alice.ID = 1
+	err := Insert(testCtx, db, "person", alice)
+	if !errors.Is(err, ErrPrimaryKeyNotZero) {
+		t.Errorf("Insert with pk set: want ErrPrimaryKeyNotZero, got %v", err)
+	}
+	alice.ID = 0
+
+	type noPK struct {
+		Name string
+	}
+	if err := Load(testCtx, db, "person", new(noPK), 1); !errors.Is(err, ErrNoPrimaryKey) {
+		t.Errorf("Load without pk: want ErrNoPrimaryKey, got %v", err)
+	}
+	if err := Update(testCtx, db, "person", new(noPK)); !errors.Is(err, ErrNoPrimaryKey) {
+		t.Errorf("Update without pk: want ErrNoPrimaryKey, got %v", err)
+	}
+	if err := SetPrimaryKey(new(noPK), 1); !errors.Is(err, ErrNoPrimaryKey) {
+		t.Errorf("SetPrimaryKey without pk: want ErrNoPrimaryKey, got %v", err)
+	}
+}
+
+func TestQueryError(t *testing.T) {
+	once.Do(setup)
+
+	alice.ID = 0
+	err := Insert(testCtx, db, "invalid", alice)
+	var qe *QueryError
+	if !errors.As(err, &qe) {
+		t.Fatalf("insert into invalid table: want *QueryError, got %T", err)
+	}
+	if qe.Op != "Insert" {
+		t.Errorf("QueryError.Op: want Insert, got %s", qe.Op)
+	}
+	if qe.Table != "invalid" {
+		t.Errorf("QueryError.Table: want invalid, got %s", qe.Table)
+	}
+	if qe.Query == "" {
+		t.Errorf("QueryError.Query: want generated SQL, got empty string")
+	}
+	var se sqlite3.Error
+	if !errors.As(err, &se) {
+		t.Errorf("errors.As: want sqlite3.Error in chain of %v", err)
+	}
+}
+
+func TestVerboseQueryError(t *testing.T) {
+	once.Do(setup)
+
+	verbose := &Database{Quote: `"`, Placeholder: "?", VerboseErrors: true}
+	bob.ID = 0
+	err := verbose.Insert(testCtx, db, "invalid", bob)
+	var qe *QueryError
+	if !errors.As(err, &qe) {
+		t.Fatalf("insert into invalid table: want *QueryError, got %T", err)
+	}
+	if len(qe.Args) != 7 {
+		t.Errorf("QueryError.Args: want 7 args, got %d", len(qe.Args))
+	}
+	if !strings.Contains(err.Error(), qe.Query) || !strings.Contains(err.Error(), "bob@bob.com") {
+		t.Errorf("verbose error message should contain query and args, got %s", err.Error())
+	}
+
+	// the default database redacts both
+	err = SQLite.Insert(testCtx, db, "invalid", bob)
+	if !errors.As(err, &qe) {
+		t.Fatalf("insert into invalid table: want *QueryError, got %T", err)
+	}
+	if qe.Args != nil {
+		t.Errorf("QueryError.Args: want nil without VerboseErrors, got %v", qe.Args)
+	}
+	if strings.Contains(err.Error(), "bob@bob.com") {
+		t.Errorf("error message leaked bound arguments: %s", err.Error())
+	}
+}
+
+func TestScanError(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	// height is NULL for Bob
+	type strict struct {
+		ID     int64  `meddler:"id,pk"`
+		Name   string `meddler:"name"`
+		Height int    `meddler:"height"`
+	}
+	var people []*strict
+	err := SQLite.QueryAll(testCtx, db, &people, "select id, name, height from person order by id")
+	var scanErr *ScanError
+	if !errors.As(err, &scanErr) {
+		t.Fatalf("QueryAll with a NULL int: expected a ScanError, got %v", err)
+	}
+	if scanErr.Row != 2 || scanErr.Column != "height" || scanErr.Field != "Height" {
+		t.Errorf("ScanError: expected row 2, column height, field Height, got %+v", scanErr)
+	}
+	if !strings.Contains(err.Error(), "row 2, column height into field Height") {
+		t.Errorf("ScanError: unexpected message %q", err.Error())
+	}
+
+	elt := new(strict)
+	err = SQLite.QueryRow(testCtx, db, elt, "select id, name, height from person where id = 2")
+	if !errors.As(err, &scanErr) || scanErr.Row != 1 {
+		t.Errorf("QueryRow with a NULL int: expected a ScanError for row 1, got %v", err)
+	}
+
+	// the second id, name, and height go to the second struct
+	rows, err := db.Query("select id, name, Email, id, name, height from person where id = 2")
+	if err != nil {
+		t.Fatalf("DB error on query: %v", err)
+	}
+	err = SQLite.ScanRowMulti(rows, new(Person), elt)
+	if !errors.As(err, &scanErr) || scanErr.Column != "height" || scanErr.Field != "Height" {
+		t.Errorf("ScanRowMulti with a NULL int: expected a ScanError for column height, got %v", err)
+	}
+
+	d := SQLite.Clone()
+	d.NullAsZero = true
+	people = nil
+	if err := d.QueryAll(testCtx, db, &people, "select id, name, height from person order by id"); err != nil {
+		t.Fatalf("QueryAll with NullAsZero: %v", err)
+	}
+	if len(people) != 2 || people[1].Height != 0 || people[1].Name != "Bob" || people[0].Height == 0 {
+		t.Errorf("QueryAll with NullAsZero: unexpected rows %+v %+v", people[0], people[1])
+	}
+
+	// a meddled field still gets its own target
+	loaded := new(Person)
+	if err := d.Load(testCtx, db, "person", loaded, 1); err != nil {
+		t.Fatalf("Load with NullAsZero: %v", err)
+	}
+	if loaded.Name != "Alice" || loaded.Height == nil || loaded.Opened.IsZero() {
+		t.Errorf("Load with NullAsZero: unexpected row %+v", loaded)
+	}
+}
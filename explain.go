@@ -0,0 +1,106 @@
+package meddlerx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Explain returns the query plan for query, as reported by the database.
+// PostgreSQL uses EXPLAIN ANALYZE, which runs the query, so it should not
+// be given statements with side effects outside a transaction that is
+// rolled back. MySQL uses EXPLAIN and SQLite EXPLAIN QUERY PLAN. SQL Server
+// is not supported. Each result row becomes one line, with columns
+// separated by " | ".
+func (d *Database) Explain(ctx context.Context, db Querier, query string, args ...interface{}) (string, error) {
+	return d.explain(ctx, db, true, query, args)
+}
+
+// Explain using the Default Database type
+func Explain(ctx context.Context, db Querier, query string, args ...interface{}) (string, error) {
+	return Default.Explain(ctx, db, query, args...)
+}
+
+// explain is Explain, with EXPLAIN ANALYZE used on PostgreSQL only if
+// analyze is set.
+func (d *Database) explain(ctx context.Context, db Querier, analyze bool, query string, args []interface{}) (string, error) {
+	ctx, db, done := d.begin(ctx, db, "Explain", "")
+	defer done()
+
+	var prefix string
+	switch d.Dialect {
+	case DialectPostgreSQL:
+		prefix = "EXPLAIN "
+		if analyze {
+			prefix = "EXPLAIN ANALYZE "
+		}
+	case DialectMySQL:
+		prefix = "EXPLAIN "
+	case DialectSQLite:
+		prefix = "EXPLAIN QUERY PLAN "
+	default:
+		return "", fmt.Errorf("meddler.Explain: not supported for dialect %q", d.Dialect)
+	}
+	q := prefix + query
+
+	rows, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return "", d.queryError("Explain", "", q, args, err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+
+	var lines []string
+	values := make([]sql.NullString, len(columns))
+	targets := make([]interface{}, len(columns))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(targets...); err != nil {
+			return "", err
+		}
+		fields := make([]string, len(values))
+		for i, value := range values {
+			fields[i] = value.String
+		}
+		lines = append(lines, strings.Join(fields, " | "))
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// explainTimeout limits the EXPLAIN sent for a slow query.
+const explainTimeout = 5 * time.Second
+
+// explainSlow logs the plan for a Load or QueryAll query that started at
+// start, if it took longer than ExplainThreshold. The plan is only
+// estimated, without running the query again, and is skipped for
+// statements other than SELECT, such as INSERT ... RETURNING. It gets its
+// own deadline, as the query's context has often expired by now.
+func (d *Database) explainSlow(ctx context.Context, db Querier, op, query string, args []interface{}, start time.Time) {
+	elapsed := time.Since(start)
+	if elapsed < d.ExplainThreshold {
+		return
+	}
+	trimmed := strings.TrimLeft(query, " \t\r\n")
+	if len(trimmed) < 6 || !strings.EqualFold(trimmed[:6], "SELECT") {
+		d.logf("meddler.%s: slow query (%v): %s", op, elapsed, query)
+		return
+	}
+	ctx, cancel := context.WithTimeout(detachedContext{ctx}, explainTimeout)
+	defer cancel()
+	plan, err := d.explain(ctx, db, false, query, args)
+	if err != nil {
+		d.logf("meddler.%s: slow query (%v), EXPLAIN failed: %v", op, elapsed, err)
+		return
+	}
+	d.logf("meddler.%s: slow query (%v): %s\n%s", op, elapsed, query, plan)
+}
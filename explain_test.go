@@ -0,0 +1,81 @@
+package meddlerx
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExplain(t *testing.T) {
+	once.Do(setup)
+
+	plan, err := SQLite.Explain(testCtx, db, "select * from person where id = ?", 1)
+	if err != nil {
+		t.Fatalf("Explain error: %v", err)
+	}
+	if !strings.Contains(plan, "person") {
+		t.Errorf("Explain: expected a plan mentioning person, got %q", plan)
+	}
+
+	if _, err := SQLServer.Explain(testCtx, db, "select 1"); err == nil {
+		t.Errorf("Explain on SQL Server: expected an error")
+	}
+}
+
+func TestExplainThreshold(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	var buf bytes.Buffer
+	d := SQLite.Clone(WithLogger(log.New(&buf, "", 0)))
+	d.ExplainThreshold = time.Nanosecond
+
+	var people []*Person
+	if err := d.QueryAll(testCtx, db, &people, "select * from person where name = ?", "Bob"); err != nil {
+		t.Fatalf("QueryAll error: %v", err)
+	}
+	if err := d.Load(testCtx, db, "person", new(Person), 1); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "meddler.QueryAll: slow query") || !strings.Contains(out, "meddler.Load: slow query") ||
+		!strings.Contains(out, "SCAN") {
+		t.Errorf("ExplainThreshold: expected plans to be logged, got %q", out)
+	}
+}
+
+func TestExplainSlowSelectOnly(t *testing.T) {
+	once.Do(setup)
+	defer db.Exec("delete from person")
+
+	var buf bytes.Buffer
+	d := SQLite.Clone(WithLogger(log.New(&buf, "", 0)))
+	d.ExplainThreshold = time.Nanosecond
+
+	// explaining this would insert a second row on some databases
+	rec := new(recordingQuerier)
+	insert := "insert into person (name, Email, Age, opened) values ('Carol', 'carol@carol.com', 0, '') returning id"
+	var people []*Person
+	if err := d.QueryAll(testCtx, rec, &people, insert); err != nil {
+		t.Fatalf("QueryAll error: %v", err)
+	}
+	if rec.query != insert {
+		t.Errorf("QueryAll: expected no EXPLAIN to follow, got %s", rec.query)
+	}
+	if out := buf.String(); !strings.Contains(out, "meddler.QueryAll: slow query") || strings.Contains(out, "EXPLAIN") {
+		t.Errorf("ExplainThreshold: expected the query to be logged without a plan, got %q", out)
+	}
+
+	// the plan is still logged once the caller's context has expired
+	buf.Reset()
+	ctx, cancel := context.WithCancel(testCtx)
+	cancel()
+	d.explainSlow(ctx, db, "QueryAll", "select * from person", nil, time.Time{})
+	if out := buf.String(); !strings.Contains(out, "SCAN") {
+		t.Errorf("explainSlow with an expired context: expected a plan, got %q", out)
+	}
+}
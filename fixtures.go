@@ -0,0 +1,157 @@
+package meddlerx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+)
+
+// fixtureTable is the list of rows for one table, as read from a fixture file.
+type fixtureTable struct {
+	table string
+	rows  []map[string]json.RawMessage
+}
+
+// fixtureRecord is a decoded row, ready to insert.
+type fixtureRecord struct {
+	table string
+	src   interface{}
+}
+
+// LoadFixtures reads every *.json file at the top level of fsys (in
+// lexical order) and inserts the rows it describes. Each file holds an
+// object that maps table names to lists of rows, and each row is an
+// object that maps column names to values:
+//
+//	{
+//		"person": [
+//			{"id": 1, "name": "Alice", "opened": "2013-06-23T15:30:12Z"}
+//		]
+//	}
+//
+// models maps each table name to a pointer to the struct type used for its
+// rows, e.g. {"person": (*Person)(nil)}. Values are decoded into the struct
+// fields with encoding/json and written with Insert, so meddlers are applied
+// exactly as they would be for application code. Rows with a non-zero
+// primary key are inserted with that key.
+//
+// Before inserting, the rows of every table named in the fixtures are
+// deleted, in the reverse of the order in which the tables first appear, so
+// that child tables listed after their parents are cleared first. Every row
+// is decoded first, so a missing model or a bad value leaves the tables as
+// they were.
+//
+// Only JSON is read. YAML would need a dependency this package does not
+// otherwise have; convert YAML fixtures to JSON, e.g. with yq, instead.
+func (d *Database) LoadFixtures(ctx context.Context, db Querier, fsys fs.FS, models map[string]interface{}) error {
+	names, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return err
+	}
+
+	var tables []fixtureTable
+	for _, name := range names {
+		raw, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		found, err := parseFixtureFile(raw)
+		if err != nil {
+			return fmt.Errorf("meddler.LoadFixtures: %s: %w", path.Base(name), err)
+		}
+		tables = append(tables, found...)
+	}
+
+	// decode every row before anything is deleted
+	var records []fixtureRecord
+	for _, t := range tables {
+		model, present := models[t.table]
+		if !present {
+			return fmt.Errorf("meddler.LoadFixtures: no model registered for table %s", t.table)
+		}
+		modelType := reflect.TypeOf(model)
+		data, err := getFields(modelType)
+		if err != nil {
+			return err
+		}
+
+		for i, row := range t.rows {
+			elt := reflect.New(modelType.Elem())
+			for column, value := range row {
+				field, present := data.fields[column]
+				if !present {
+					return fmt.Errorf("meddler.LoadFixtures: table %s row %d: column %s not found in struct", t.table, i, column)
+				}
+				if err := json.Unmarshal(value, elt.Elem().FieldByIndex(field.index).Addr().Interface()); err != nil {
+					return fmt.Errorf("meddler.LoadFixtures: table %s row %d column %s: %w", t.table, i, column, err)
+				}
+			}
+			records = append(records, fixtureRecord{table: t.table, src: elt.Interface()})
+		}
+	}
+
+	// clear the tables, children first
+	cleared := make(map[string]bool)
+	var order []string
+	for _, t := range tables {
+		if !cleared[t.table] {
+			cleared[t.table] = true
+			order = append(order, t.table)
+		}
+	}
+	for i := len(order) - 1; i >= 0; i-- {
+		if err := d.DeleteAll(ctx, db, order[i]); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range records {
+		pkName, pkValue, err := d.PrimaryKey(r.src)
+		if err != nil {
+			return err
+		}
+		if err := d.insert(ctx, db, r.table, r.src, pkName, pkName != "" && pkValue != 0, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LoadFixtures using the Default Database type
+func LoadFixtures(ctx context.Context, db Querier, fsys fs.FS, models map[string]interface{}) error {
+	return Default.LoadFixtures(ctx, db, fsys, models)
+}
+
+// parseFixtureFile decodes a fixture file, keeping tables in file order.
+func parseFixtureFile(raw []byte) ([]fixtureTable, error) {
+	var tables []fixtureTable
+	dec := json.NewDecoder(bytes.NewReader(raw))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expected an object of tables")
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		table := tok.(string)
+
+		var rows []map[string]json.RawMessage
+		if err := dec.Decode(&rows); err != nil {
+			return nil, fmt.Errorf("table %s: %w", table, err)
+		}
+		tables = append(tables, fixtureTable{table: table, rows: rows})
+	}
+
+	return tables, nil
+}
@@ -0,0 +1,54 @@
+package meddlerx
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLoadFixtures(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+	defer db.Exec("delete from item")
+
+	models := map[string]interface{}{
+		"person": (*Person)(nil),
+		"item":   (*ItemJson)(nil),
+	}
+	if err := LoadFixtures(testCtx, db, os.DirFS("testdata/fixtures"), models); err != nil {
+		t.Fatalf("LoadFixtures error: %v", err)
+	}
+
+	// the existing rows were cleared
+	var people []*Person
+	if err := QueryAll(testCtx, db, &people, "select * from person order by id"); err != nil {
+		t.Fatalf("QueryAll error: %v", err)
+	}
+	if len(people) != 2 {
+		t.Fatalf("expected 2 people after loading fixtures, got %d", len(people))
+	}
+	personEqual(t, people[0], &Person{10, "Dora", 0, "dora@dora.com", 0, 41, when, time.Time{}, nil, nil})
+	height := 70
+	personEqual(t, people[1], &Person{11, "Eve", 0, "eve@eve.com", 0, 0, when, time.Time{}, nil, &height})
+
+	// json meddler applied on the way in
+	var items []*ItemJson
+	if err := QueryAll(testCtx, db, &items, "select * from item"); err != nil {
+		t.Fatalf("QueryAll error: %v", err)
+	}
+	if len(items) != 1 || !items[0].Stuff["hello"] || !items[0].StuffZ["world"] {
+		t.Errorf("unexpected items loaded from fixtures: %+v", items)
+	}
+
+	delete(models, "item")
+	if err := LoadFixtures(testCtx, db, os.DirFS("testdata/fixtures"), models); err == nil {
+		t.Errorf("LoadFixtures with missing model: expected err, got nil")
+	}
+
+	// and nothing was cleared
+	var count int
+	if err := db.QueryRow("select count(*) from person").Scan(&count); err != nil || count != 2 {
+		t.Errorf("LoadFixtures with missing model: expected 2 people left, got %d, %v", count, err)
+	}
+}
@@ -0,0 +1,185 @@
+package meddlerx
+
+import (
+	"encoding"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// Point is a two-dimensional point geometry. SRID is the spatial reference
+// system identifier, or zero if unknown.
+type Point struct {
+	X, Y float64
+	SRID int
+}
+
+// WKB geometry type codes and EWKB flags
+const (
+	wkbPoint    = 1
+	ewkbZFlag   = 0x80000000
+	ewkbMFlag   = 0x40000000
+	ewkbSRIDFlg = 0x20000000
+)
+
+// MarshalBinary encodes the point as little-endian WKB, or as EWKB if SRID
+// is set.
+func (p Point) MarshalBinary() ([]byte, error) {
+	typ := uint32(wkbPoint)
+	buf := make([]byte, 21, 25)
+	buf[0] = 1
+	coords := buf[5:]
+	if p.SRID != 0 {
+		typ |= ewkbSRIDFlg
+		buf = buf[:25]
+		binary.LittleEndian.PutUint32(buf[5:], uint32(p.SRID))
+		coords = buf[9:]
+	}
+	binary.LittleEndian.PutUint32(buf[1:], typ)
+	binary.LittleEndian.PutUint64(coords, math.Float64bits(p.X))
+	binary.LittleEndian.PutUint64(coords[8:], math.Float64bits(p.Y))
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a two-dimensional point from WKB or EWKB in
+// either byte order.
+func (p *Point) UnmarshalBinary(data []byte) error {
+	if len(data) < 5 {
+		return fmt.Errorf("Point.UnmarshalBinary: WKB too short")
+	}
+	var order binary.ByteOrder
+	switch data[0] {
+	case 0:
+		order = binary.BigEndian
+	case 1:
+		order = binary.LittleEndian
+	default:
+		return fmt.Errorf("Point.UnmarshalBinary: invalid byte order %d", data[0])
+	}
+	typ := order.Uint32(data[1:])
+	data = data[5:]
+
+	srid := 0
+	if typ&ewkbSRIDFlg != 0 {
+		if len(data) < 4 {
+			return fmt.Errorf("Point.UnmarshalBinary: WKB too short")
+		}
+		srid = int(order.Uint32(data))
+		data = data[4:]
+	}
+	if typ&(ewkbZFlag|ewkbMFlag) != 0 || typ&^ewkbSRIDFlg != wkbPoint {
+		return fmt.Errorf("Point.UnmarshalBinary: geometry type %#x is not a 2D point", typ)
+	}
+	if len(data) != 16 {
+		return fmt.Errorf("Point.UnmarshalBinary: expected 16 bytes of coordinates, found %d", len(data))
+	}
+
+	p.X = math.Float64frombits(order.Uint64(data))
+	p.Y = math.Float64frombits(order.Uint64(data[8:]))
+	p.SRID = srid
+	return nil
+}
+
+// GeometryMeddler converts geometry and geography columns to and from Go
+// values. Fields may be a Point, a *Point (nil for NULL), or any type (or
+// pointer to a type) that implements encoding.BinaryMarshaler and
+// encoding.BinaryUnmarshaler on WKB, so that a full geometry library can be
+// plugged in.
+//
+// Values are read from either raw WKB or the hex-encoded EWKB that PostGIS
+// returns, and written as hex-encoded (E)WKB, which PostGIS accepts as
+// geometry input. If the meddler is true, Point values are written as EWKB
+// with their SRID; otherwise the SRID is dropped.
+type GeometryMeddler bool
+
+// PreRead is called before a Scan operation for fields that have the GeometryMeddler
+func (ewkb GeometryMeddler) PreRead(fieldAddr interface{}) (scanTarget interface{}, err error) {
+	// give a pointer to a byte buffer to grab the raw data
+	return new([]byte), nil
+}
+
+// PostRead is called after a Scan operation for fields that have the GeometryMeddler
+func (ewkb GeometryMeddler) PostRead(fieldAddr, scanTarget interface{}) error {
+	ptr := scanTarget.(*[]byte)
+	if ptr == nil {
+		return fmt.Errorf("GeometryMeddler.PostRead: nil pointer")
+	}
+	raw := *ptr
+
+	fieldVal := reflect.ValueOf(fieldAddr).Elem()
+	if raw == nil {
+		// null becomes the zero value (a nil pointer for pointer fields)
+		fieldVal.Set(reflect.Zero(fieldVal.Type()))
+		return nil
+	}
+
+	wkb, err := decodeWKB(raw)
+	if err != nil {
+		return fmt.Errorf("GeometryMeddler.PostRead: %w", err)
+	}
+
+	target := fieldVal.Addr()
+	if fieldVal.Kind() == reflect.Ptr {
+		target = reflect.New(fieldVal.Type().Elem())
+	}
+	unmarshaler, ok := target.Interface().(encoding.BinaryUnmarshaler)
+	if !ok {
+		return fmt.Errorf("GeometryMeddler.PostRead: %v does not implement encoding.BinaryUnmarshaler", fieldVal.Type())
+	}
+	if err := unmarshaler.UnmarshalBinary(wkb); err != nil {
+		return fmt.Errorf("GeometryMeddler.PostRead: %w", err)
+	}
+	if fieldVal.Kind() == reflect.Ptr {
+		fieldVal.Set(target)
+	}
+	return nil
+}
+
+// PreWrite is called before an Insert or Update operation for fields that have the GeometryMeddler
+func (ewkb GeometryMeddler) PreWrite(field interface{}) (saveValue interface{}, err error) {
+	if v := reflect.ValueOf(field); !v.IsValid() || v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, nil
+	}
+	if p, ok := field.(*Point); ok {
+		field = *p
+	}
+	if p, ok := field.(Point); ok && !bool(ewkb) {
+		p.SRID = 0
+		field = p
+	}
+
+	marshaler, ok := field.(encoding.BinaryMarshaler)
+	if !ok {
+		return nil, fmt.Errorf("GeometryMeddler.PreWrite: %T does not implement encoding.BinaryMarshaler", field)
+	}
+	wkb, err := marshaler.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("GeometryMeddler.PreWrite: %w", err)
+	}
+	return hex.EncodeToString(wkb), nil
+}
+
+// ColumnType implements ColumnTyper for fields with the GeometryMeddler
+func (ewkb GeometryMeddler) ColumnType(d *Database, fieldType reflect.Type) (string, bool, error) {
+	nullable := fieldType.Kind() == reflect.Ptr
+	if d.Dialect == DialectPostgreSQL || d.Dialect == DialectMySQL {
+		return "GEOMETRY", nullable, nil
+	}
+	return d.blobType(), nullable, nil
+}
+
+// decodeWKB returns raw WKB, decoding it first if it is hex-encoded.
+func decodeWKB(raw []byte) ([]byte, error) {
+	// binary WKB starts with a byte order byte of 0 or 1, while hex
+	// starts with the digit '0'
+	if len(raw) == 0 || raw[0] != '0' {
+		return raw, nil
+	}
+	wkb := make([]byte, hex.DecodedLen(len(raw)))
+	if _, err := hex.Decode(wkb, raw); err != nil {
+		return nil, err
+	}
+	return wkb, nil
+}
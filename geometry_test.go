@@ -0,0 +1,83 @@
+package meddlerx
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+type place struct {
+	ID       int64  `meddler:"id,pk"`
+	Location Point  `meddler:"location,ewkb"`
+	Area     *Point `meddler:"area,geometry"`
+}
+
+func TestPointWKB(t *testing.T) {
+	wkb, _ := Point{X: 1, Y: 2}.MarshalBinary()
+	if got, expected := hex.EncodeToString(wkb), "0101000000000000000000f03f0000000000000040"; got != expected {
+		t.Errorf("Point WKB: expected %s, got %s", expected, got)
+	}
+	wkb, _ = Point{X: 1, Y: 2, SRID: 4326}.MarshalBinary()
+	if got, expected := hex.EncodeToString(wkb), "0101000020e6100000000000000000f03f0000000000000040"; got != expected {
+		t.Errorf("Point EWKB: expected %s, got %s", expected, got)
+	}
+
+	// big-endian input
+	raw, _ := hex.DecodeString("00000000013ff00000000000004000000000000000")
+	var p Point
+	if err := p.UnmarshalBinary(raw); err != nil {
+		t.Fatalf("UnmarshalBinary error: %v", err)
+	}
+	if p != (Point{X: 1, Y: 2}) {
+		t.Errorf("UnmarshalBinary big-endian: got %+v", p)
+	}
+
+	// a linestring is not a point
+	raw, _ = hex.DecodeString("010200000000000000")
+	if err := p.UnmarshalBinary(raw); err == nil {
+		t.Errorf("UnmarshalBinary of a linestring: expected err, got nil")
+	}
+}
+
+func TestGeometryMeddler(t *testing.T) {
+	once.Do(setup)
+	if _, err := db.Exec("create table place (id integer primary key, location blob not null, area blob)"); err != nil {
+		t.Fatalf("creating place table: %v", err)
+	}
+	defer db.Exec("drop table place")
+
+	elt := &place{Location: Point{X: -122.4, Y: 37.8, SRID: 4326}}
+	if err := SQLite.Insert(testCtx, db, "place", elt); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	loaded := new(place)
+	if err := SQLite.Load(testCtx, db, "place", loaded, elt.ID); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if loaded.Location != elt.Location || loaded.Area != nil {
+		t.Errorf("GeometryMeddler round trip: got %+v, %v", loaded.Location, loaded.Area)
+	}
+
+	// the plain geometry meddler drops the SRID
+	loaded.Area = &Point{X: 3, Y: 4, SRID: 4326}
+	if err := SQLite.Update(testCtx, db, "place", loaded); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	if err := SQLite.Load(testCtx, db, "place", loaded, elt.ID); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if loaded.Area == nil || *loaded.Area != (Point{X: 3, Y: 4}) {
+		t.Errorf("GeometryMeddler nullable round trip: got %v", loaded.Area)
+	}
+
+	// raw binary WKB is accepted as well as hex
+	raw, _ := Point{X: 5, Y: 6}.MarshalBinary()
+	if _, err := db.Exec("update place set area = ? where id = ?", raw, elt.ID); err != nil {
+		t.Fatalf("DB error: %v", err)
+	}
+	if err := SQLite.Load(testCtx, db, "place", loaded, elt.ID); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if loaded.Area == nil || *loaded.Area != (Point{X: 5, Y: 6}) {
+		t.Errorf("GeometryMeddler binary WKB: got %v", loaded.Area)
+	}
+}
@@ -2,4 +2,7 @@ module github.com/scanfully/meddlerx
 
 go 1.18
 
-require github.com/mattn/go-sqlite3 v1.14.28
+require (
+	github.com/mattn/go-sqlite3 v1.14.28
+	golang.org/x/tools v0.18.0
+)
@@ -0,0 +1,144 @@
+package meddlerx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// HistorySuffix is appended to a table name to get the name of its history
+// table.
+const HistorySuffix = "_history"
+
+// saveHistory copies the current row for src into the history table of
+// table before an Update or Delete, if table is listed in History. old is
+// the row, if it has already been read. The history table has the columns
+// of the table, without its primary key constraint, and two more:
+// valid_from, when the version was written (NULL for the first version
+// seen), and valid_to, when it was replaced.
+func (d *Database) saveHistory(ctx context.Context, db Querier, op, table string, src interface{}, old map[string]interface{}) error {
+	if !d.History[table] {
+		return nil
+	}
+	if old == nil {
+		var err error
+		if old, err = d.currentRow(ctx, db, op, table, src); err != nil || old == nil {
+			return err
+		}
+	}
+	pkName, pkValue, err := d.PrimaryKey(src)
+	if err != nil {
+		return err
+	}
+	names, err := d.Columns(src, true)
+	if err != nil {
+		return err
+	}
+	history := table + HistorySuffix
+
+	// the version began when the one before it ended
+	q := fmt.Sprintf("SELECT MAX(%s) FROM %s WHERE %s=%s", d.quoted("valid_to"),
+		d.quotedTable(history), d.quoted(pkName), d.placeholder(1))
+	var from interface{}
+	if err := db.QueryRowContext(ctx, q, pkValue).Scan(&from); err != nil {
+		return d.queryError(op, history, q, []interface{}{pkValue}, err)
+	}
+
+	quoted := make([]string, 0, len(names)+2)
+	placeholders := make([]string, 0, len(names)+2)
+	values := make([]interface{}, 0, len(names)+2)
+	for _, name := range names {
+		quoted = append(quoted, d.quoted(name))
+		values = append(values, old[name])
+	}
+	quoted = append(quoted, d.quoted("valid_from"), d.quoted("valid_to"))
+	values = append(values, from, time.Now().UTC())
+	for i := range values {
+		placeholders = append(placeholders, d.placeholder(i+1))
+	}
+	q = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", d.quotedTable(history),
+		strings.Join(quoted, ","), strings.Join(placeholders, ","))
+	if _, err := db.ExecContext(ctx, q, values...); err != nil {
+		return d.queryError(op, history, q, values, err)
+	}
+	return nil
+}
+
+// historyTx starts a transaction for a write to table, if it keeps history
+// and db can begin one, so that the row and its history change together.
+// The transaction is an EventTx, so OnChange hears of the write only once
+// it commits. The returned finish function commits or rolls back
+// according to the error of the write, and returns it.
+func (d *Database) historyTx(ctx context.Context, db Querier, table string) (Querier, func(error) error, error) {
+	none := func(err error) error { return err }
+	if !d.History[table] {
+		return db, none, nil
+	}
+	beginner, ok := db.(TxBeginner)
+	if !ok {
+		return db, none, nil
+	}
+	tx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("meddler: starting transaction for history: %w", err)
+	}
+	etx := &EventTx{Tx: tx, d: d, ctx: ctx}
+	return etx, func(err error) error {
+		if err != nil {
+			etx.Rollback()
+			return err
+		}
+		return etx.Commit()
+	}, nil
+}
+
+// LoadAsOf loads the version of a record that was current at time t: the
+// row in the history table of table (see History) that was valid then, or
+// the current row if it has not changed since. Inserts are not recorded,
+// so the first version of a record is taken to have been valid since the
+// beginning, and a t before the record was inserted finds that version
+// too. Returns sql.ErrNoRows if the record has no version at t: it was
+// deleted by then, or it never existed.
+func (d *Database) LoadAsOf(ctx context.Context, db Querier, table string, dst interface{}, pk int64, t time.Time) error {
+	d = d.forTable(table)
+	ctx, db, done := d.begin(ctx, db, "LoadAsOf", table)
+	defer done()
+
+	columns, err := d.ColumnsQuoted(dst, true)
+	if err != nil {
+		return err
+	}
+	pkName, _, err := d.PrimaryKey(dst)
+	if err != nil {
+		return err
+	}
+	if pkName == "" {
+		return fmt.Errorf("meddler.LoadAsOf: %w", ErrNoPrimaryKey)
+	}
+
+	history := table + HistorySuffix
+	t = t.UTC()
+	q := fmt.Sprintf("SELECT %s FROM %s WHERE %s=%s AND %s>%s AND (%s IS NULL OR %s<=%s) ORDER BY %s",
+		columns, d.quotedTable(history), d.quoted(pkName), d.placeholder(1),
+		d.quoted("valid_to"), d.placeholder(2),
+		d.quoted("valid_from"), d.quoted("valid_from"), d.placeholder(3), d.quoted("valid_to"))
+	args := []interface{}{pk, t, t}
+	rows, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return d.queryError("LoadAsOf", history, q, args, err)
+	}
+	err = d.ScanRow(rows, dst)
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	// every recorded change came before t, so the current row is the one
+	return d.Load(ctx, db, table, dst, pk)
+}
+
+// LoadAsOf using the Default Database type
+func LoadAsOf(ctx context.Context, db Querier, table string, dst interface{}, pk int64, t time.Time) error {
+	return Default.LoadAsOf(ctx, db, table, dst, pk, t)
+}
@@ -0,0 +1,190 @@
+package meddlerx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const personHistorySchema = `create table person_history (
+	id integer not null,
+	name text not null,
+	Email text not null,
+	Age integer,
+	opened datetime not null,
+	closed datetime,
+	updated datetime,
+	height integer,
+	valid_from datetime,
+	valid_to datetime not null
+)`
+
+func TestHistory(t *testing.T) {
+	once.Do(setup)
+	if _, err := db.Exec(personHistorySchema); err != nil {
+		t.Fatalf("DB error: %v", err)
+	}
+	defer db.Exec("drop table person_history")
+	defer db.Exec("delete from person")
+
+	d := SQLite.Clone()
+	d.History = map[string]bool{"person": true}
+
+	elt := &Person{Name: "Alice", Email: "alice@alice.com", Age: 30, Opened: time.Now()}
+	if err := d.Insert(testCtx, db, "person", elt); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	created := time.Now()
+	time.Sleep(5 * time.Millisecond)
+
+	elt.Age = 31
+	if err := d.Update(testCtx, db, "person", elt); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	renamed := time.Now()
+	time.Sleep(5 * time.Millisecond)
+
+	elt.Name = "Alicia"
+	if err := d.Save(testCtx, db, "person", elt); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+	saved := time.Now()
+	time.Sleep(5 * time.Millisecond)
+
+	var count int
+	if err := db.QueryRow("select count(*) from person_history where id = ?", elt.ID).Scan(&count); err != nil {
+		t.Fatalf("DB error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("History: expected 2 old versions, got %d", count)
+	}
+
+	tests := []struct {
+		at   time.Time
+		name string
+		age  int
+	}{
+		{created, "Alice", 30},
+		{renamed, "Alice", 31},
+		{saved, "Alicia", 31},
+	}
+	for _, test := range tests {
+		loaded := new(Person)
+		if err := d.LoadAsOf(testCtx, db, "person", loaded, elt.ID, test.at); err != nil {
+			t.Fatalf("LoadAsOf error: %v", err)
+		}
+		if loaded.Name != test.name || loaded.Age != test.age {
+			t.Errorf("LoadAsOf(%v): expected %s %d, got %s %d", test.at, test.name, test.age, loaded.Name, loaded.Age)
+		}
+	}
+
+	// inserts are not recorded, so the first version reaches back
+	loaded := new(Person)
+	if err := d.LoadAsOf(testCtx, db, "person", loaded, elt.ID, created.Add(-time.Hour)); err != nil || loaded.Age != 30 {
+		t.Errorf("LoadAsOf before Insert: expected the first version, got %d, %v", loaded.Age, err)
+	}
+
+	// deleted rows are gone now, but not before
+	if err := d.Delete(testCtx, db, "person", elt); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	if err := d.LoadAsOf(testCtx, db, "person", loaded, elt.ID, time.Now()); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("LoadAsOf after Delete: expected sql.ErrNoRows, got %v", err)
+	}
+	if err := d.LoadAsOf(testCtx, db, "person", loaded, elt.ID, saved); err != nil || loaded.Name != "Alicia" {
+		t.Errorf("LoadAsOf before Delete: expected Alicia, got %s, %v", loaded.Name, err)
+	}
+
+	// a failed history write leaves the row alone
+	bob := &Person{Name: "Bob", Email: "bob@bob.com", Opened: time.Now()}
+	if err := d.Insert(testCtx, db, "person", bob); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	if _, err := db.Exec("drop table person_history"); err != nil {
+		t.Fatalf("DB error: %v", err)
+	}
+	bob.Name = "Robert"
+	if err := d.Update(testCtx, db, "person", bob); err == nil {
+		t.Errorf("Update without a history table: expected err, got nil")
+	}
+	if err := d.Load(testCtx, db, "person", loaded, bob.ID); err != nil || loaded.Name != "Bob" {
+		t.Errorf("Update without a history table: expected Bob, got %s, %v", loaded.Name, err)
+	}
+}
+
+func TestHistoryOnChange(t *testing.T) {
+	once.Do(setup)
+	if _, err := db.Exec(personHistorySchema); err != nil {
+		t.Fatalf("DB error: %v", err)
+	}
+	defer db.Exec("drop table person_history")
+	defer db.Exec("delete from person")
+
+	var events []ChangeEvent
+	d := SQLite.Clone()
+	d.History = map[string]bool{"person": true}
+	d.OnChange = func(ctx context.Context, event ChangeEvent) {
+		events = append(events, event)
+	}
+
+	elt := &Person{Name: "Alice", Email: "alice@alice.com", Age: 30, Opened: time.Now()}
+	if err := d.Insert(testCtx, db, "person", elt); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	elt.Age = 31
+	if err := d.Update(testCtx, db, "person", elt); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	elt.Name = "Alicia"
+	if err := d.Save(testCtx, db, "person", elt); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+	if err := d.Delete(testCtx, db, "person", elt); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	var ops []WriteOp
+	for _, event := range events {
+		ops = append(ops, event.Op)
+	}
+	if expected := []WriteOp{OpInsert, OpUpdate, OpUpdate, OpDelete}; !reflect.DeepEqual(ops, expected) {
+		t.Errorf("OnChange: expected %v, got %v", expected, ops)
+	}
+
+	// a write that is rolled back is not reported
+	bob := &Person{Name: "Bob", Email: "bob@bob.com", Opened: time.Now()}
+	if err := d.Insert(testCtx, db, "person", bob); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	if _, err := db.Exec("drop table person_history"); err != nil {
+		t.Fatalf("DB error: %v", err)
+	}
+	events = nil
+	bob.Name = "Robert"
+	if err := d.Update(testCtx, db, "person", bob); err == nil {
+		t.Errorf("Update without a history table: expected err, got nil")
+	}
+	if len(events) != 0 {
+		t.Errorf("OnChange: expected no events for a failed update, got %+v", events)
+	}
+}
+
+func TestHistoryUpsert(t *testing.T) {
+	once.Do(setup)
+	d := SQLite.Clone()
+	d.History = map[string]bool{"person": true}
+
+	elt := &Person{Name: "Alice", Email: "alice@alice.com", Opened: time.Now()}
+	if _, err := d.Upsert(testCtx, db, "person", elt); err == nil {
+		t.Errorf("Upsert on a history table: expected err, got nil")
+	}
+	if _, err := d.SaveOrUpdateOn(testCtx, db, "person", elt, "email"); err == nil {
+		t.Errorf("SaveOrUpdateOn on a history table: expected err, got nil")
+	}
+	var n int
+	if err := db.QueryRow("select count(*) from person").Scan(&n); err != nil || n != 0 {
+		t.Errorf("expected no rows written, got %d, %v", n, err)
+	}
+}
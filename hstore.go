@@ -0,0 +1,210 @@
+package meddlerx
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// HstoreMeddler stores map[string]string fields as PostgreSQL hstore
+// columns, and as JSON objects in other databases. A nil map is stored as
+// NULL. Either form is accepted when reading, and NULL hstore values are
+// read as empty strings.
+type HstoreMeddler bool
+
+// PreRead is called before a Scan operation for fields that have the HstoreMeddler
+func (elt HstoreMeddler) PreRead(fieldAddr interface{}) (scanTarget interface{}, err error) {
+	return new(sql.NullString), nil
+}
+
+// PostRead is called after a Scan operation for fields that have the HstoreMeddler
+func (elt HstoreMeddler) PostRead(fieldAddr, scanTarget interface{}) error {
+	ptr := scanTarget.(*sql.NullString)
+	if ptr == nil {
+		return fmt.Errorf("HstoreMeddler.PostRead: nil pointer")
+	}
+
+	fieldVal := reflect.ValueOf(fieldAddr).Elem()
+	if !isStringMap(fieldVal.Type()) {
+		return fmt.Errorf("HstoreMeddler.PostRead: unsupported field type %v", fieldVal.Type())
+	}
+	if !ptr.Valid {
+		fieldVal.Set(reflect.Zero(fieldVal.Type()))
+		return nil
+	}
+
+	var m map[string]string
+	if s := strings.TrimSpace(ptr.String); strings.HasPrefix(s, "{") {
+		if err := json.Unmarshal([]byte(s), &m); err != nil {
+			return fmt.Errorf("HstoreMeddler.PostRead: %w", err)
+		}
+	} else {
+		var err error
+		if m, err = parseHstore(s); err != nil {
+			return fmt.Errorf("HstoreMeddler.PostRead: %w", err)
+		}
+	}
+	fieldVal.Set(reflect.ValueOf(m).Convert(fieldVal.Type()))
+	return nil
+}
+
+// PreWrite is called before an Insert or Update operation for fields that
+// have the HstoreMeddler. Without a Database to ask, it writes hstore.
+func (elt HstoreMeddler) PreWrite(field interface{}) (saveValue interface{}, err error) {
+	return elt.PreWriteDialect(PostgreSQL, field)
+}
+
+// PreWriteDialect implements DialectWriter for fields with the HstoreMeddler
+func (elt HstoreMeddler) PreWriteDialect(d *Database, field interface{}) (saveValue interface{}, err error) {
+	v := reflect.ValueOf(field)
+	if !isStringMap(v.Type()) {
+		return nil, fmt.Errorf("HstoreMeddler.PreWrite: unsupported field type %T", field)
+	}
+	if v.IsNil() {
+		return nil, nil
+	}
+	m := v.Convert(reflect.TypeOf(map[string]string(nil))).Interface().(map[string]string)
+
+	if d.Dialect != DialectPostgreSQL {
+		raw, err := json.Marshal(m)
+		if err != nil {
+			return nil, fmt.Errorf("HstoreMeddler.PreWrite: %w", err)
+		}
+		return string(raw), nil
+	}
+	return formatHstore(m), nil
+}
+
+// ColumnType implements ColumnTyper for fields with the HstoreMeddler
+func (elt HstoreMeddler) ColumnType(d *Database, fieldType reflect.Type) (string, bool, error) {
+	switch d.Dialect {
+	case DialectPostgreSQL:
+		return "HSTORE", true, nil
+	case DialectMySQL:
+		return "JSON", true, nil
+	default:
+		return "TEXT", true, nil
+	}
+}
+
+func isStringMap(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.String
+}
+
+// formatHstore returns the hstore text form of m, with keys sorted so the
+// output is stable.
+func formatHstore(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for i, key := range keys {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		writeHstoreString(&b, key)
+		b.WriteString("=>")
+		writeHstoreString(&b, m[key])
+	}
+	return b.String()
+}
+
+func writeHstoreString(b *strings.Builder, s string) {
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteByte('"')
+}
+
+// parseHstore parses the hstore text form, as PostgreSQL outputs it or as
+// it accepts it for input.
+func parseHstore(s string) (map[string]string, error) {
+	m := make(map[string]string)
+	p := &hstoreParser{s: s}
+	for {
+		p.skipSpace()
+		if p.pos == len(p.s) {
+			return m, nil
+		}
+		key, _, err := p.token()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if !strings.HasPrefix(p.s[p.pos:], "=>") {
+			return nil, fmt.Errorf("hstore: expected => at offset %d", p.pos)
+		}
+		p.pos += 2
+		p.skipSpace()
+		value, quoted, err := p.token()
+		if err != nil {
+			return nil, err
+		}
+		if !quoted && strings.EqualFold(value, "NULL") {
+			value = ""
+		}
+		m[key] = value
+
+		p.skipSpace()
+		if p.pos == len(p.s) {
+			return m, nil
+		}
+		if p.s[p.pos] != ',' {
+			return nil, fmt.Errorf("hstore: expected , at offset %d", p.pos)
+		}
+		p.pos++
+	}
+}
+
+type hstoreParser struct {
+	s   string
+	pos int
+}
+
+func (p *hstoreParser) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// token reads a quoted or bare key or value.
+func (p *hstoreParser) token() (string, bool, error) {
+	if p.pos < len(p.s) && p.s[p.pos] == '"' {
+		var b strings.Builder
+		for p.pos++; p.pos < len(p.s); p.pos++ {
+			switch c := p.s[p.pos]; c {
+			case '"':
+				p.pos++
+				return b.String(), true, nil
+			case '\\':
+				p.pos++
+				if p.pos == len(p.s) {
+					return "", true, fmt.Errorf("hstore: unterminated string")
+				}
+				b.WriteByte(p.s[p.pos])
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", true, fmt.Errorf("hstore: unterminated string")
+	}
+
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n,=", p.s[p.pos]) < 0 {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", false, fmt.Errorf("hstore: expected a key or value at offset %d", start)
+	}
+	return p.s[start:p.pos], false, nil
+}
@@ -0,0 +1,79 @@
+package meddlerx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type tagged struct {
+	ID    int64             `meddler:"id,pk"`
+	Attrs map[string]string `meddler:"attrs,hstore"`
+}
+
+func TestHstoreMeddler(t *testing.T) {
+	once.Do(setup)
+	if err := SQLite.EnsureTable(testCtx, db, "tagged", new(tagged)); err != nil {
+		t.Fatalf("EnsureTable error: %v", err)
+	}
+	defer db.Exec("drop table tagged")
+
+	elt := &tagged{Attrs: map[string]string{"color": "red", "size": "10"}}
+	if err := SQLite.Insert(testCtx, db, "tagged", elt); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	var raw string
+	if err := db.QueryRow("select attrs from tagged where id = ?", elt.ID).Scan(&raw); err != nil {
+		t.Fatalf("DB error: %v", err)
+	}
+	if raw != `{"color":"red","size":"10"}` {
+		t.Errorf("HstoreMeddler: expected JSON in SQLite, got %s", raw)
+	}
+
+	loaded := new(tagged)
+	if err := SQLite.Load(testCtx, db, "tagged", loaded, elt.ID); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Attrs, elt.Attrs) {
+		t.Errorf("HstoreMeddler round trip: expected %v, got %v", elt.Attrs, loaded.Attrs)
+	}
+
+	// hstore text, as PostgreSQL returns it
+	if _, err := db.Exec(`update tagged set attrs = ? where id = ?`, `"a b"=>"x\"y", c=>NULL`, elt.ID); err != nil {
+		t.Fatalf("DB error: %v", err)
+	}
+	if err := SQLite.Load(testCtx, db, "tagged", loaded, elt.ID); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if expected := map[string]string{"a b": `x"y`, "c": ""}; !reflect.DeepEqual(loaded.Attrs, expected) {
+		t.Errorf("HstoreMeddler hstore parsing: expected %v, got %v", expected, loaded.Attrs)
+	}
+
+	// nil maps are NULL
+	elt.Attrs = nil
+	if err := SQLite.Update(testCtx, db, "tagged", elt); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	if err := SQLite.Load(testCtx, db, "tagged", loaded, elt.ID); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if loaded.Attrs != nil {
+		t.Errorf("HstoreMeddler: expected nil map, got %v", loaded.Attrs)
+	}
+
+	values, err := PostgreSQL.SomeValues(&tagged{Attrs: map[string]string{"k": `a\b`, "j": "1"}}, []string{"attrs"})
+	if err != nil {
+		t.Fatalf("SomeValues error: %v", err)
+	}
+	if values[0] != `"j"=>"1", "k"=>"a\\b"` {
+		t.Errorf("HstoreMeddler: expected hstore in PostgreSQL, got %v", values[0])
+	}
+
+	q, err := PostgreSQL.CreateTableSQL("tagged", new(tagged))
+	if err != nil {
+		t.Fatalf("CreateTableSQL error: %v", err)
+	}
+	if !strings.Contains(q, `"attrs" HSTORE`) {
+		t.Errorf("CreateTableSQL: expected HSTORE column in\n%s", q)
+	}
+}
@@ -0,0 +1,172 @@
+package meddlerx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type identityMapKey struct{}
+
+// IdentityMap remembers the records loaded by primary key during one unit
+// of work, such as a request or a transaction, so that loading the same row
+// again returns it without another query. Load and LoadKey fill in a deep
+// copy of the held record, so changing a loaded struct, including the
+// contents of its slice, map, or pointer fields, does not affect later
+// loads; LoadShared shares one instance instead. Attach one to a context with
+// WithIdentityMap; Load, LoadKey, and LoadShared then consult it, and
+// writes made through the same context remove the rows they touch.
+//
+// Writes made some other way, such as with a plain Exec, are not seen;
+// call Invalidate or InvalidateTable after them. If a transaction whose
+// context carries an identity map is rolled back, call Clear, since rows
+// read inside it may no longer match the database.
+type IdentityMap struct {
+	mu      sync.Mutex
+	records map[identityEntry]reflect.Value
+}
+
+// identityEntry identifies a record by table, primary key, and Go type, so
+// that structs covering different columns of a table are kept apart.
+type identityEntry struct {
+	table string
+	pk    int64
+	typ   reflect.Type
+}
+
+// WithIdentityMap returns a context carrying a new, empty IdentityMap. If
+// ctx already carries one, ctx is returned unchanged, so nested code can
+// call it freely.
+func WithIdentityMap(ctx context.Context) context.Context {
+	if _, ok := IdentityMapFrom(ctx); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, identityMapKey{}, &IdentityMap{})
+}
+
+// IdentityMapFrom returns the IdentityMap in ctx, if any.
+func IdentityMapFrom(ctx context.Context) (*IdentityMap, bool) {
+	m, ok := ctx.Value(identityMapKey{}).(*IdentityMap)
+	return m, ok && m != nil
+}
+
+// Invalidate forgets the records for table and pk.
+func (m *IdentityMap) Invalidate(table string, pk int64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for entry := range m.records {
+		if entry.table == table && entry.pk == pk {
+			delete(m.records, entry)
+		}
+	}
+}
+
+// InvalidateTable forgets every record from table.
+func (m *IdentityMap) InvalidateTable(table string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for entry := range m.records {
+		if entry.table == table {
+			delete(m.records, entry)
+		}
+	}
+}
+
+// Clear forgets every record.
+func (m *IdentityMap) Clear() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.records = nil
+	m.mu.Unlock()
+}
+
+// Len returns the number of records held.
+func (m *IdentityMap) Len() int {
+	if m == nil {
+		return 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.records)
+}
+
+// lookup returns the record held for table and pk with type ptrType, a
+// pointer to a struct.
+func (m *IdentityMap) lookup(table string, pk int64, ptrType reflect.Type) (reflect.Value, bool) {
+	if m == nil {
+		return reflect.Value{}, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record, ok := m.records[identityEntry{table: table, pk: pk, typ: ptrType}]
+	return record, ok
+}
+
+// store holds record, a pointer to a struct, for table and pk.
+func (m *IdentityMap) store(table string, pk int64, record reflect.Value) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.records == nil {
+		m.records = make(map[identityEntry]reflect.Value)
+	}
+	m.records[identityEntry{table: table, pk: pk, typ: record.Type()}] = record
+}
+
+// forget removes the records for table and pk from the identity map in
+// ctx, if there is one.
+func forget(ctx context.Context, table string, pk int64) {
+	if m, ok := IdentityMapFrom(ctx); ok {
+		m.Invalidate(table, pk)
+	}
+}
+
+// forgetTable removes every record for table from the identity map in
+// ctx, if there is one. It is used by writes that may touch any row.
+func forgetTable(ctx context.Context, table string) {
+	if m, ok := IdentityMapFrom(ctx); ok {
+		m.InvalidateTable(table)
+	}
+}
+
+// LoadShared is like Load, but dst is a pointer to a pointer to a struct,
+// which is set to the instance held by the identity map in ctx, loading it
+// first if there is none. Code loading the same row within one unit of work
+// therefore shares a single struct and sees changes made to it. Without an
+// identity map in ctx, *dst is set to a newly loaded struct every time.
+func (d *Database) LoadShared(ctx context.Context, db Querier, table string, dst interface{}, pk int64) error {
+	ptr := reflect.ValueOf(dst)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Ptr || ptr.Elem().Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("meddler.LoadShared: expected pointer to a pointer to a struct, found %T", dst)
+	}
+	ptrType := ptr.Elem().Type()
+
+	ids, _ := IdentityMapFrom(ctx)
+	if record, ok := ids.lookup(table, pk, ptrType); ok {
+		ptr.Elem().Set(record)
+		return nil
+	}
+	record := reflect.New(ptrType.Elem())
+	if err := d.loadRow(ctx, db, "LoadShared", table, record.Interface(), pk, pk); err != nil {
+		return err
+	}
+	ids.store(table, pk, record)
+	ptr.Elem().Set(record)
+	return nil
+}
+
+// LoadShared using the Default Database type
+func LoadShared(ctx context.Context, db Querier, table string, dst interface{}, pk int64) error {
+	return Default.LoadShared(ctx, db, table, dst, pk)
+}
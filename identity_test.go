@@ -0,0 +1,145 @@
+package meddlerx
+
+import (
+	"testing"
+)
+
+func TestIdentityMap(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	var aliceID int64
+	if err := db.QueryRow("select id from person where name = 'Alice'").Scan(&aliceID); err != nil {
+		t.Fatalf("reading id: %v", err)
+	}
+	rename := func(name string) {
+		if _, err := db.Exec("update person set name = ? where id = ?", name, aliceID); err != nil {
+			t.Fatalf("renaming: %v", err)
+		}
+	}
+
+	ctx := WithIdentityMap(testCtx)
+	if WithIdentityMap(ctx) != ctx {
+		t.Errorf("expected WithIdentityMap to keep an existing map")
+	}
+	ids, _ := IdentityMapFrom(ctx)
+
+	// repeated loads are served from the map, so a change made behind its
+	// back is not seen
+	first := new(Person)
+	if err := SQLite.Load(ctx, db, "person", first, aliceID); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	rename("Alicia")
+	second := new(Person)
+	if err := SQLite.Load(ctx, db, "person", second, aliceID); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if second.Name != "Alice" || second == first {
+		t.Errorf("expected a copy of the first load, got %q", second.Name)
+	}
+	plain := new(Person)
+	if err := SQLite.Load(testCtx, db, "person", plain, aliceID); err != nil || plain.Name != "Alicia" {
+		t.Errorf("expected Alicia without an identity map, got %q, %v", plain.Name, err)
+	}
+
+	// LoadShared hands out the same instance each time
+	var a, b *Person
+	if err := SQLite.LoadShared(ctx, db, "person", &a, aliceID); err != nil {
+		t.Fatalf("LoadShared error: %v", err)
+	}
+	if err := SQLite.LoadShared(ctx, db, "person", &b, aliceID); err != nil {
+		t.Fatalf("LoadShared error: %v", err)
+	}
+	if a != b || a.Name != "Alice" {
+		t.Errorf("expected one shared instance, got %p and %p", a, b)
+	}
+
+	// structs of another type are kept apart
+	half := new(HalfPerson)
+	if err := SQLite.Load(ctx, db, "person", half, aliceID); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if n := ids.Len(); n != 2 {
+		t.Errorf("expected 2 records in the map, got %d", n)
+	}
+
+	// writes through the context forget the row
+	a.Name = "Alison"
+	if err := SQLite.Update(ctx, db, "person", a); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	if n := ids.Len(); n != 0 {
+		t.Errorf("expected the row to be forgotten after Update, got %d records", n)
+	}
+	if err := SQLite.LoadShared(ctx, db, "person", &b, aliceID); err != nil {
+		t.Fatalf("LoadShared error: %v", err)
+	}
+	if b == a || b.Name != "Alison" {
+		t.Errorf("expected a fresh load of Alison, got %q", b.Name)
+	}
+
+	// and so do writes that may touch any row of the table
+	if _, err := SQLite.DeleteWhere(ctx, db, "person", Eq("name", "Nobody")); err != nil {
+		t.Fatalf("DeleteWhere error: %v", err)
+	}
+	if n := ids.Len(); n != 0 {
+		t.Errorf("expected the table to be forgotten after DeleteWhere, got %d records", n)
+	}
+
+	// explicit invalidation
+	if err := SQLite.Load(ctx, db, "person", first, aliceID); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	rename("Ally")
+	ids.Invalidate("person", aliceID)
+	if err := SQLite.Load(ctx, db, "person", first, aliceID); err != nil || first.Name != "Ally" {
+		t.Errorf("expected Ally after Invalidate, got %q, %v", first.Name, err)
+	}
+	ids.Clear()
+	if n := ids.Len(); n != 0 {
+		t.Errorf("expected an empty map after Clear, got %d records", n)
+	}
+
+	var bad Person
+	if err := SQLite.LoadShared(ctx, db, "person", &bad, aliceID); err == nil {
+		t.Errorf("expected an error for a pointer to a struct")
+	}
+}
+
+func TestIdentityMapDeepCopy(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	var bobID int64
+	if err := db.QueryRow("select id from person where name = 'Bob'").Scan(&bobID); err != nil {
+		t.Fatalf("reading id: %v", err)
+	}
+	if _, err := db.Exec("update person set height = 180 where id = ?", bobID); err != nil {
+		t.Fatalf("DB error: %v", err)
+	}
+
+	// changing what a pointer field of a loaded record points to must not
+	// reach the record held by the map, either from the first load or a
+	// later one
+	ctx := WithIdentityMap(testCtx)
+	first := new(Person)
+	if err := SQLite.Load(ctx, db, "person", first, bobID); err != nil || first.Height == nil {
+		t.Fatalf("Load: expected a height, got %v, %v", first.Height, err)
+	}
+	*first.Height = 1
+	second := new(Person)
+	if err := SQLite.Load(ctx, db, "person", second, bobID); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if second.Height == nil || *second.Height != 180 {
+		t.Fatalf("expected the stored height of 180, got %v", second.Height)
+	}
+	*second.Height = 2
+	third := new(Person)
+	if err := SQLite.Load(ctx, db, "person", third, bobID); err != nil || *third.Height != 180 {
+		t.Errorf("expected the stored height of 180, got %v, %v", *third.Height, err)
+	}
+}
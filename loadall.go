@@ -0,0 +1,134 @@
+package meddlerx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// LoadAll loads the rows of table with the given primary keys in a single
+// query, or in several if there are more keys than the database takes
+// parameters in one statement, and appends them to dst, a pointer to a slice of structs or of
+// pointers to structs, in the order of pks. A key given more than once is
+// loaded once but appended each time. If any keys have no row, the rest
+// are still appended and a *MissingKeysError listing them is returned.
+func (d *Database) LoadAll(ctx context.Context, db Querier, table string, dst interface{}, pks []int64) error {
+	d = d.forTable(table)
+	dstVal := reflect.ValueOf(dst)
+	if dstVal.Kind() != reflect.Ptr || dstVal.IsNil() || dstVal.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("meddler.LoadAll: destination must be a pointer to a slice, found %T", dst)
+	}
+	sliceVal := dstVal.Elem()
+	ptrType := sliceVal.Type().Elem()
+	byValue := ptrType.Kind() == reflect.Struct
+	if byValue {
+		ptrType = reflect.PtrTo(ptrType)
+	}
+	data, err := getFields(ptrType)
+	if err != nil {
+		return err
+	}
+	if data.pk == "" {
+		return fmt.Errorf("meddler.LoadAll: %w", ErrNoPrimaryKey)
+	}
+
+	// ask for each key once
+	seen := make(map[int64]bool)
+	var args []interface{}
+	for _, pk := range pks {
+		if !seen[pk] {
+			seen[pk] = true
+			args = append(args, pk)
+		}
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	ctx, db, done := d.begin(ctx, db, "LoadAll", table)
+	defer done()
+
+	columns, err := d.ColumnsQuoted(reflect.New(ptrType.Elem()).Interface(), true)
+	if err != nil {
+		return err
+	}
+	found := reflect.New(reflect.SliceOf(ptrType))
+	if err := d.loadIn(ctx, db, "LoadAll", table, columns, data.pk, args, found); err != nil {
+		return err
+	}
+
+	// index the rows by key and append them in the order asked for
+	byKey := make(map[int64]reflect.Value)
+	for i := 0; i < found.Elem().Len(); i++ {
+		elt := found.Elem().Index(i)
+		key, _, err := intColumn(data, elt, data.pk)
+		if err != nil {
+			return fmt.Errorf("meddler.LoadAll: %w", err)
+		}
+		byKey[key] = elt
+	}
+	var missing []int64
+	for _, pk := range pks {
+		elt, present := byKey[pk]
+		if !present {
+			missing = append(missing, pk)
+			continue
+		}
+		if byValue {
+			elt = elt.Elem()
+		}
+		sliceVal.Set(reflect.Append(sliceVal, elt))
+	}
+	if len(missing) > 0 {
+		return &MissingKeysError{Table: table, Keys: missing}
+	}
+	return nil
+}
+
+// LoadAll using the Default Database type
+func LoadAll(ctx context.Context, db Querier, table string, dst interface{}, pks []int64) error {
+	return Default.LoadAll(ctx, db, table, dst, pks)
+}
+
+// maxParams returns the number of parameters a statement may have in the
+// dialect: 2100 for SQL Server, 999 for SQLite before 3.32, and 65535 for
+// PostgreSQL and MySQL.
+func (d *Database) maxParams() int {
+	switch d.Dialect {
+	case DialectPostgreSQL, DialectMySQL:
+		return 65535
+	case DialectSQLServer:
+		return 2100
+	default:
+		return 999
+	}
+}
+
+// loadIn selects columns from the rows of table whose column is one of
+// keys and appends them to found, a pointer to a slice of struct pointers.
+// The keys are split across as many queries as maxParams requires.
+func (d *Database) loadIn(ctx context.Context, db Querier, op, table, columns, column string, keys []interface{}, found reflect.Value) error {
+	limit := d.maxParams()
+	for start := 0; start < len(keys); start += limit {
+		end := start + limit
+		if end > len(keys) {
+			end = len(keys)
+		}
+		args := keys[start:end]
+		placeholders := make([]string, len(args))
+		for i := range args {
+			placeholders[i] = d.placeholder(i + 1)
+		}
+		q := fmt.Sprintf("SELECT %s FROM %s WHERE %s IN (%s)", columns, d.quotedTable(table),
+			d.quoted(column), strings.Join(placeholders, ","))
+		rows, err := db.QueryContext(ctx, q, args...)
+		if err != nil {
+			return d.queryError(op, table, q, args, err)
+		}
+		if err := d.ScanAllContext(withScanQuery(ctx, q), rows, found.Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package meddlerx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLoadAll(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	var ids []int64
+	rows, err := db.Query("select id from person order by id")
+	if err != nil {
+		t.Fatalf("reading ids: %v", err)
+	}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("reading ids: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	aliceID, bobID := ids[0], ids[1]
+
+	// results follow the order of the keys, with repeats
+	var people []*Person
+	if err := SQLite.LoadAll(testCtx, db, "person", &people, []int64{bobID, aliceID, bobID}); err != nil {
+		t.Fatalf("LoadAll error: %v", err)
+	}
+	var names []string
+	for _, p := range people {
+		names = append(names, p.Name)
+	}
+	if expected := []string{"Bob", "Alice", "Bob"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+
+	// missing keys are reported, and the rest are still loaded
+	var values []Person
+	err = SQLite.LoadAll(testCtx, db, "person", &values, []int64{aliceID + 100, aliceID, aliceID + 200})
+	var missing *MissingKeysError
+	if !errors.As(err, &missing) || !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected a MissingKeysError, got %v", err)
+	}
+	if expected := []int64{aliceID + 100, aliceID + 200}; !reflect.DeepEqual(missing.Keys, expected) || missing.Table != "person" {
+		t.Errorf("expected missing keys %v in person, got %v in %s", expected, missing.Keys, missing.Table)
+	}
+	if len(values) != 1 || values[0].Name != "Alice" || values[0].Email != alice.Email {
+		t.Errorf("expected Alice to be loaded, got %+v", values)
+	}
+
+	// no keys means no query
+	var none []*Person
+	if err := SQLite.LoadAll(testCtx, db, "no_such_table", &none, nil); err != nil || len(none) != 0 {
+		t.Errorf("LoadAll with no keys: got %v, %v", none, err)
+	}
+	if err := SQLite.LoadAll(testCtx, db, "person", new(Person), ids); err == nil {
+		t.Errorf("expected an error for a destination that is not a slice")
+	}
+}
+
+// countingQuerier counts the queries passed to the test database.
+type countingQuerier struct {
+	recordingQuerier
+	queries int
+}
+
+func (c *countingQuerier) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	c.queries++
+	return c.recordingQuerier.QueryContext(ctx, query, args...)
+}
+
+func TestLoadAllChunks(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	// more keys than SQLite takes parameters, most of them missing
+	var alice Person
+	if err := SQLite.LoadWhere(testCtx, db, "person", &alice, Where("name = ?", "Alice")); err != nil {
+		t.Fatalf("LoadWhere error: %v", err)
+	}
+	pks := make([]int64, 2500)
+	for i := range pks {
+		pks[i] = int64(i + 100)
+	}
+	pks[2400] = alice.ID
+
+	q := new(countingQuerier)
+	var people []*Person
+	err := SQLite.LoadAll(testCtx, q, "person", &people, pks)
+	var missing *MissingKeysError
+	if !errors.As(err, &missing) || len(missing.Keys) != 2499 {
+		t.Fatalf("LoadAll: expected 2499 missing keys, got %v", err)
+	}
+	if len(people) != 1 || people[0].Name != "Alice" {
+		t.Errorf("LoadAll: expected Alice from the last chunk, got %+v", people)
+	}
+	if q.queries != 3 {
+		t.Errorf("LoadAll: expected 3 queries of at most 999 keys, got %d", q.queries)
+	}
+}
@@ -4,28 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"reflect"
 	"strings"
+	"time"
 )
 
-type dbErr struct {
-	msg string
-	err error
-}
-
-func (err *dbErr) Error() string {
-	return fmt.Sprintf("%s: %v", err.msg, err.err)
-}
-
-// DriverErr returns the original error as returned by the database driver
-// if the error comes from the driver, with the second value set to true.
-// Otherwise, it returns err itself with false as second value.
-func DriverErr(err error) (error, bool) {
-	if dbe, ok := err.(*dbErr); ok {
-		return dbe.err, true
-	}
-	return err, false
-}
-
 // Querier is a generic interface for database query operations.
 type Querier interface {
 	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
@@ -45,6 +28,85 @@ type DB interface {
 // Load loads a record using a query for the primary key field.
 // Returns sql.ErrNoRows if not found.
 func (d *Database) Load(ctx context.Context, db Querier, table string, dst interface{}, pk int64) error {
+	return d.load(ctx, db, "Load", table, dst, pk, pk)
+}
+
+// Load using the Default Database type
+func Load(ctx context.Context, db Querier, table string, dst interface{}, pk int64) error {
+	return Default.Load(ctx, db, table, dst, pk)
+}
+
+// LoadKey is like Load, but takes a primary key of any type that can be
+// converted to the type of dst's integer primary key field, such as a uint
+// or a decimal string. The key is converted to the field's type and then
+// passed through the field's meddler, as a saved key would be.
+//
+// The primary key field itself must still be an integer: the identity map,
+// the Cache, and the writes all identify rows by an int64 key, so string
+// or UUID keys would need changes throughout and are not supported yet.
+func (d *Database) LoadKey(ctx context.Context, db Querier, table string, dst interface{}, pk interface{}) error {
+	data, err := getFields(reflect.TypeOf(dst))
+	if err != nil {
+		return err
+	}
+	if data.pk == "" {
+		return fmt.Errorf("meddler.LoadKey: %w", ErrNoPrimaryKey)
+	}
+	field := data.fields[data.pk]
+
+	key := reflect.New(reflect.TypeOf(dst).Elem().FieldByIndex(field.index).Type)
+	if err := assignValue(key.Interface(), pk); err != nil {
+		return fmt.Errorf("meddler.LoadKey: primary key %v: %w", pk, err)
+	}
+	arg, err := field.meddler.PreWrite(key.Elem().Interface())
+	if err != nil {
+		return fmt.Errorf("meddler.LoadKey: PreWrite error on primary key: %w", err)
+	}
+
+	var id int64
+	switch key.Elem().Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		id = key.Elem().Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		id = int64(key.Elem().Uint())
+	default:
+		return fmt.Errorf("meddler.LoadKey: primary key field %s is a %v, not an integer type", data.pk, key.Elem().Type())
+	}
+	return d.load(ctx, db, "LoadKey", table, dst, id, arg)
+}
+
+// LoadKey using the Default Database type
+func LoadKey(ctx context.Context, db Querier, table string, dst interface{}, pk interface{}) error {
+	return Default.LoadKey(ctx, db, table, dst, pk)
+}
+
+// load runs Load and LoadKey, consulting the identity map in ctx, if any.
+// id is the key as an integer, for the identity map and the cache, and arg
+// is the key as it is passed to the database.
+func (d *Database) load(ctx context.Context, db Querier, op, table string, dst interface{}, id int64, arg interface{}) error {
+	// a row already loaded in this unit of work is copied from the identity
+	// map; the copies are deep, so that changes to slices, maps, or
+	// pointers in dst do not reach the held record or other loads of it
+	ids, _ := IdentityMapFrom(ctx)
+	if record, ok := ids.lookup(table, id, reflect.TypeOf(dst)); ok {
+		reflect.ValueOf(dst).Elem().Set(deepCopy(record, make(map[copiedPointer]reflect.Value)).Elem())
+		return nil
+	}
+	if err := d.loadRow(ctx, db, op, table, dst, id, arg); err != nil {
+		return err
+	}
+	if ids != nil {
+		ids.store(table, id, deepCopy(reflect.ValueOf(dst), make(map[copiedPointer]reflect.Value)))
+	}
+	return nil
+}
+
+// loadRow queries for the row with primary key arg and scans it into dst.
+func (d *Database) loadRow(ctx context.Context, db Querier, op, table string, dst interface{}, id int64, arg interface{}) error {
+	d = d.forTable(table)
+	ctx, db, done := d.begin(ctx, db, op, table)
+	defer done()
+
 	columns, err := d.ColumnsQuoted(dst, true)
 	if err != nil {
 		return err
@@ -56,181 +118,511 @@ func (d *Database) Load(ctx context.Context, db Querier, table string, dst inter
 		return err
 	}
 	if pkName == "" {
-		return fmt.Errorf("meddler.Load: no primary key field found")
+		return fmt.Errorf("meddler.%s: %w", op, ErrNoPrimaryKey)
 	}
 
 	// run the query
-	q := fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s", columns, d.quotedTable(table), d.quoted(pkName), d.Placeholder)
+	q := fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s", columns, d.quotedTable(table), d.quoted(pkName), d.placeholder(1))
+	if d.ExplainThreshold > 0 {
+		defer d.explainSlow(ctx, db, op, q, []interface{}{arg}, time.Now())
+	}
+	if d.Cache != nil {
+		return d.loadCached(ctx, db, table, dst, id, arg, q)
+	}
 
-	rows, err := db.QueryContext(ctx, q, pk)
+	rows, err := db.QueryContext(ctx, q, arg)
 	if err != nil {
-		return &dbErr{msg: "meddler.Load: DB error in Query", err: err}
+		return d.queryError(op, table, q, []interface{}{arg}, err)
 	}
 
 	// scan the row
-	return d.ScanRow(rows, dst)
-}
-
-// Load using the Default Database type
-func Load(ctx context.Context, db Querier, table string, dst interface{}, pk int64) error {
-	return Default.Load(ctx, db, table, dst, pk)
+	return d.scanSingle(rows, dst, q)
 }
 
 // Insert performs an INSERT query for the given record.
 // If the record has a primary key flagged, it must be zero, and it
 // will be set to the newly-allocated primary key value from the database
 // as returned by LastInsertId.
-func (d *Database) Insert(ctx context.Context, db Querier, table string, src interface{}) error {
+func (d *Database) Insert(ctx context.Context, db Querier, table string, src interface{}, opts ...WriteOption) error {
+	if err := d.writable("Insert", table); err != nil {
+		return err
+	}
+	d = d.forTable(table)
+	ctx, db, done := d.begin(ctx, db, "Insert", table)
+	defer done()
+
 	pkName, pkValue, err := d.PrimaryKey(src)
 	if err != nil {
 		return err
 	}
 	if pkName != "" && pkValue != 0 {
-		return fmt.Errorf("meddler.Insert: primary key must be zero")
+		return fmt.Errorf("meddler.Insert: %w", ErrPrimaryKeyNotZero)
 	}
 
-	// gather the query parts
-	namesPart, err := d.ColumnsQuoted(src, false)
-	if err != nil {
+	return d.insert(ctx, db, table, src, pkName, false, newWriteOptions(opts))
+}
+
+// Insert using the Default Database type
+func Insert(ctx context.Context, db Querier, table string, src interface{}, opts ...WriteOption) error {
+	return Default.Insert(ctx, db, table, src, opts...)
+}
+
+// InsertWithPK performs an INSERT query for the given record that writes
+// its primary key as given, instead of letting the database allocate one.
+// This is meant for migrations and replication, where rows keep their
+// original keys. The record must have a primary key field.
+func (d *Database) InsertWithPK(ctx context.Context, db Querier, table string, src interface{}, opts ...WriteOption) error {
+	if err := d.writable("InsertWithPK", table); err != nil {
 		return err
 	}
-	valuesPart, err := d.PlaceholdersString(src, false)
+	d = d.forTable(table)
+	ctx, db, done := d.begin(ctx, db, "InsertWithPK", table)
+	defer done()
+
+	pkName, _, err := d.PrimaryKey(src)
 	if err != nil {
 		return err
 	}
-	values, err := d.Values(src, false)
+	if pkName == "" {
+		return fmt.Errorf("meddler.InsertWithPK: %w", ErrNoPrimaryKey)
+	}
+
+	return d.insert(ctx, db, table, src, pkName, true, newWriteOptions(opts))
+}
+
+// InsertWithPK using the Default Database type
+func InsertWithPK(ctx context.Context, db Querier, table string, src interface{}, opts ...WriteOption) error {
+	return Default.InsertWithPK(ctx, db, table, src, opts...)
+}
+
+// insert runs the INSERT query for Insert. If includePk is set, the primary
+// key column is written like any other column and the database is not asked
+// for a newly-allocated key.
+func (d *Database) insert(ctx context.Context, db Querier, table string, src interface{}, pkName string, includePk bool, o *writeOptions) error {
+	q, values, err := d.insertQuery(table, src, includePk, o)
 	if err != nil {
 		return err
 	}
 
 	// run the query
-	q := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", d.quotedTable(table), namesPart, valuesPart)
-	if d.UseReturningToGetID && pkName != "" {
+	if includePk {
+		// the caller supplied the key, so there is nothing to look up
+		if _, err := db.ExecContext(ctx, q, values...); err != nil {
+			return d.queryError("Insert", table, q, values, err)
+		}
+	} else if d.UseReturningToGetID && pkName != "" {
 		q += " RETURNING " + d.quoted(pkName)
 		var newPk int64
 		err := db.QueryRowContext(ctx, q, values...).Scan(&newPk)
 		if err != nil {
-			return &dbErr{msg: "meddler.Insert: DB error in QueryRow", err: err}
+			return d.queryError("Insert", table, q, values, err)
 		}
 		if err = d.SetPrimaryKey(src, newPk); err != nil {
-			return fmt.Errorf("meddler.Insert: Error saving updated pk: %v", err)
+			return fmt.Errorf("meddler.Insert: Error saving updated pk: %w", err)
 		}
 	} else if pkName != "" {
 		result, err := db.ExecContext(ctx, q, values...)
 		if err != nil {
-			return &dbErr{msg: "meddler.Insert: DB error in Exec", err: err}
+			return d.queryError("Insert", table, q, values, err)
 		}
 
 		// save the new primary key
 		newPk, err := result.LastInsertId()
 		if err != nil {
-			return &dbErr{msg: "meddler.Insert: DB error getting new primary key value", err: err}
+			return d.queryError("Insert", table, q, values, err)
 		}
 		if err = d.SetPrimaryKey(src, newPk); err != nil {
-			return fmt.Errorf("meddler.Insert: Error saving updated pk: %v", err)
+			return fmt.Errorf("meddler.Insert: Error saving updated pk: %w", err)
 		}
 	} else {
 		// no primary key, so no need to lookup new value
 		_, err := db.ExecContext(ctx, q, values...)
 		if err != nil {
-			return &dbErr{msg: "meddler.Insert: DB error in Exec", err: err}
+			return d.queryError("Insert", table, q, values, err)
 		}
 	}
 
-	return nil
+	return d.written(ctx, db, OpInsert, "Insert", table, src, o, nil, nil)
 }
 
-// Insert using the Default Database type
-func Insert(ctx context.Context, db Querier, table string, src interface{}) error {
-	return Default.Insert(ctx, db, table, src)
-}
+// insertQuery builds the INSERT statement for src and its arguments. o may
+// be nil.
+func (d *Database) insertQuery(table string, src interface{}, includePk bool, o *writeOptions) (string, []interface{}, error) {
+	if err := applyDefaults(src); err != nil {
+		return "", nil, err
+	}
 
-// Update performs and UPDATE query for the given record.
-// The record must have an integer primary key field that is non-zero,
-// and it will be used to select the database row that gets updated.
-func (d *Database) Update(ctx context.Context, db Querier, table string, src interface{}) error {
 	// gather the query parts
-	names, err := d.Columns(src, false)
+	names, values, err := d.writeValues(src, includePk, o)
 	if err != nil {
+		return "", nil, err
+	}
+
+	quoted := make([]string, len(names))
+	placeholders := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = d.quoted(name)
+		placeholders[i] = d.placeholder(i + 1)
+	}
+
+	q := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", d.quotedTable(table),
+		strings.Join(quoted, ","), strings.Join(placeholders, ","))
+	return q, values, nil
+}
+
+// Update performs and UPDATE query for the given record.
+// The record must have an integer primary key field that is greater than
+// zero (or any value if AllowZeroPK is set), and it will be used to select
+// the database row that gets updated.
+func (d *Database) Update(ctx context.Context, db Querier, table string, src interface{}, opts ...WriteOption) (err error) {
+	if err := d.writable("Update", table); err != nil {
 		return err
 	}
-	placeholders, err := d.Placeholders(src, false)
+	d = d.forTable(table)
+	db, finish, err := d.historyTx(ctx, db, table)
 	if err != nil {
 		return err
 	}
-	values, err := d.Values(src, false)
+	defer func() { err = finish(err) }()
+	ctx, db, done := d.begin(ctx, db, "Update", table)
+	defer done()
+
+	o := newWriteOptions(opts)
+	result, err := d.update(ctx, db, table, src, o)
 	if err != nil {
 		return err
 	}
+	return o.checkResult("Update", result)
+}
+
+// update runs the UPDATE query for Update.
+func (d *Database) update(ctx context.Context, db Querier, table string, src interface{}, o *writeOptions) (sql.Result, error) {
+	q, values, err := d.updateQuery(table, src, o)
+	if err != nil {
+		return nil, err
+	}
+	old, err := d.auditOld(ctx, db, "Update", table, src)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.saveHistory(ctx, db, "Update", table, src, old); err != nil {
+		return nil, err
+	}
+
+	// run the query
+	result, err := db.ExecContext(ctx, q, values...)
+	if err != nil {
+		return nil, d.queryError("Update", table, q, values, err)
+	}
+
+	if err := d.written(ctx, db, OpUpdate, "Update", table, src, o, result, old); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// updateQuery builds the UPDATE statement for src and its arguments. o may
+// be nil.
+func (d *Database) updateQuery(table string, src interface{}, o *writeOptions) (string, []interface{}, error) {
+	// gather the query parts
+	names, values, err := d.writeValues(src, false, o)
+	if err != nil {
+		return "", nil, err
+	}
 
 	// form the column=placeholder pairs
 	var pairs []string
-	for i := 0; i < len(names) && i < len(placeholders); i++ {
-		pair := fmt.Sprintf("%s=%s", d.quoted(names[i]), placeholders[i])
+	for i, name := range names {
+		pair := fmt.Sprintf("%s=%s", d.quoted(name), d.placeholder(i+1))
 		pairs = append(pairs, pair)
 	}
 
 	pkName, pkValue, err := d.PrimaryKey(src)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 	if pkName == "" {
-		return fmt.Errorf("meddler.Update: no primary key field")
+		return "", nil, fmt.Errorf("meddler.Update: %w", ErrNoPrimaryKey)
 	}
-	if pkValue < 1 {
-		return fmt.Errorf("meddler.Update: primary key must be an integer > 0")
+	if pkValue < 1 && !d.AllowZeroPK {
+		return "", nil, fmt.Errorf("meddler.Update: primary key must be an integer > 0")
 	}
-	ph := d.placeholder(len(placeholders) + 1)
+	if len(names) == 0 {
+		return "", nil, fmt.Errorf("meddler.Update: no columns to update")
+	}
+	ph := d.placeholder(len(names) + 1)
 
-	// run the query
 	q := fmt.Sprintf("UPDATE %s SET %s WHERE %s=%s", d.quotedTable(table),
 		strings.Join(pairs, ","),
 		d.quoted(pkName), ph)
 	values = append(values, pkValue)
 
-	if _, err := db.ExecContext(ctx, q, values...); err != nil {
-		return &dbErr{msg: "meddler.Update: DB error in Exec", err: err}
+	return q, values, nil
+}
+
+// Update using the Default Database type
+func Update(ctx context.Context, db Querier, table string, src interface{}, opts ...WriteOption) error {
+	return Default.Update(ctx, db, table, src, opts...)
+}
+
+// Delete performs a DELETE query for the row with the primary key of src.
+// It must have a primary key, which must be greater than zero unless
+// AllowZeroPK is set.
+func (d *Database) Delete(ctx context.Context, db Querier, table string, src interface{}, opts ...WriteOption) (err error) {
+	if err := d.writable("Delete", table); err != nil {
+		return err
+	}
+	d = d.forTable(table)
+	db, finish, err := d.historyTx(ctx, db, table)
+	if err != nil {
+		return err
 	}
+	defer func() { err = finish(err) }()
+	ctx, db, done := d.begin(ctx, db, "Delete", table)
+	defer done()
 
-	return nil
+	q, values, err := d.deleteQuery(table, src)
+	if err != nil {
+		return err
+	}
+	old, err := d.auditOld(ctx, db, "Delete", table, src)
+	if err != nil {
+		return err
+	}
+	if err := d.saveHistory(ctx, db, "Delete", table, src, old); err != nil {
+		return err
+	}
+
+	result, err := db.ExecContext(ctx, q, values...)
+	if err != nil {
+		return d.queryError("Delete", table, q, values, err)
+	}
+
+	o := newWriteOptions(opts)
+	if err := d.written(ctx, db, OpDelete, "Delete", table, src, o, result, old); err != nil {
+		return err
+	}
+	return o.checkResult("Delete", result)
 }
 
-// Update using the Default Database type
-func Update(ctx context.Context, db Querier, table string, src interface{}) error {
-	return Default.Update(ctx, db, table, src)
+// Delete using the Default Database type
+func Delete(ctx context.Context, db Querier, table string, src interface{}, opts ...WriteOption) error {
+	return Default.Delete(ctx, db, table, src, opts...)
 }
 
+// deleteQuery builds the DELETE statement for src and its arguments.
+func (d *Database) deleteQuery(table string, src interface{}) (string, []interface{}, error) {
+	pkName, pkValue, err := d.PrimaryKey(src)
+	if err != nil {
+		return "", nil, err
+	}
+	if pkName == "" {
+		return "", nil, fmt.Errorf("meddler.Delete: %w", ErrNoPrimaryKey)
+	}
+	if pkValue < 1 && !d.AllowZeroPK {
+		return "", nil, fmt.Errorf("meddler.Delete: primary key must be an integer > 0")
+	}
+
+	q := fmt.Sprintf("DELETE FROM %s WHERE %s=%s", d.quotedTable(table), d.quoted(pkName), d.placeholder(1))
+	return q, []interface{}{pkValue}, nil
+}
+
+// SaveMode controls what Save does with a record whose primary key is set.
+type SaveMode int
+
+// Save modes
+const (
+	// SaveTrustPK updates the row and assumes it exists. This is the default.
+	SaveTrustPK SaveMode = iota
+
+	// SaveInsertMissing updates the row, and inserts it with its key if the
+	// update matched no rows.
+	SaveInsertMissing
+
+	// SaveRequireExisting updates the row, and returns ErrStaleRow if the
+	// update matched no rows.
+	SaveRequireExisting
+)
+
 // Save performs an INSERT or an UPDATE, depending on whether or not
-// a primary keys exists and is non-zero.
-func (d *Database) Save(ctx context.Context, db Querier, table string, src interface{}) error {
+// a primary keys exists and is non-zero. Save always treats a zero key as
+// a new record, even when AllowZeroPK is set.
+//
+// With a non-zero key, the SaveMode of the Database decides what happens
+// when no row has that key. The check relies on the affected-row count, so
+// with MySQL the connection must report found rows rather than changed
+// rows (clientFoundRows=true for go-sql-driver/mysql); otherwise an update
+// that leaves a row unchanged looks like a missing row.
+func (d *Database) Save(ctx context.Context, db Querier, table string, src interface{}, opts ...WriteOption) (err error) {
+	if err := d.writable("Save", table); err != nil {
+		return err
+	}
+	d = d.forTable(table)
+	db, finish, err := d.historyTx(ctx, db, table)
+	if err != nil {
+		return err
+	}
+	defer func() { err = finish(err) }()
+	ctx, db, done := d.begin(ctx, db, "Save", table)
+	defer done()
+
 	pkName, pkValue, err := d.PrimaryKey(src)
 	if err != nil {
 		return err
 	}
-	if pkName != "" && pkValue != 0 {
-		return d.Update(ctx, db, table, src)
+	if pkName == "" || pkValue == 0 {
+		return d.Insert(ctx, db, table, src, opts...)
 	}
 
-	return d.Insert(ctx, db, table, src)
+	o := newWriteOptions(opts)
+	result, err := d.update(ctx, db, table, src, o)
+	if err != nil || d.SaveMode == SaveTrustPK {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	switch d.SaveMode {
+	case SaveInsertMissing:
+		return d.insert(ctx, db, table, src, pkName, true, o)
+	case SaveRequireExisting:
+		return fmt.Errorf("meddler.Save: table %s, primary key %d: %w", table, pkValue, ErrStaleRow)
+	}
+	return nil
 }
 
 // Save using the Default Database type
-func Save(ctx context.Context, db Querier, table string, src interface{}) error {
-	return Default.Save(ctx, db, table, src)
+func Save(ctx context.Context, db Querier, table string, src interface{}, opts ...WriteOption) error {
+	return Default.Save(ctx, db, table, src, opts...)
+}
+
+// Truncate removes every row from table, using the fastest statement the
+// dialect offers. If restartIdentity is set, the auto-increment counter for
+// the table is reset as well, so the next insert gets the first key again.
+//
+// PostgreSQL uses TRUNCATE TABLE ... RESTART/CONTINUE IDENTITY. MySQL uses
+// TRUNCATE TABLE when restarting identity (which always resets
+// AUTO_INCREMENT) and DELETE otherwise. SQLite has no TRUNCATE, so it uses
+// DELETE and clears the table's sqlite_sequence entry when restarting.
+func (d *Database) Truncate(ctx context.Context, db Querier, table string, restartIdentity bool) error {
+	if err := d.writable("Truncate", table); err != nil {
+		return err
+	}
+	forgetTable(ctx, table)
+	ctx, db, done := d.begin(ctx, db, "Truncate", table)
+	defer done()
+
+	return d.truncate(ctx, db, table, restartIdentity, false)
+}
+
+// Truncate using the Default Database type
+func Truncate(ctx context.Context, db Querier, table string, restartIdentity bool) error {
+	return Default.Truncate(ctx, db, table, restartIdentity)
+}
+
+// TruncateCascade is like Truncate, but on PostgreSQL it also truncates
+// every table with a foreign key reference to table (TRUNCATE ... CASCADE).
+// Other dialects behave exactly as Truncate.
+func (d *Database) TruncateCascade(ctx context.Context, db Querier, table string, restartIdentity bool) error {
+	if err := d.writable("TruncateCascade", table); err != nil {
+		return err
+	}
+	forgetTable(ctx, table)
+	ctx, db, done := d.begin(ctx, db, "TruncateCascade", table)
+	defer done()
+
+	return d.truncate(ctx, db, table, restartIdentity, true)
+}
+
+// TruncateCascade using the Default Database type
+func TruncateCascade(ctx context.Context, db Querier, table string, restartIdentity bool) error {
+	return Default.TruncateCascade(ctx, db, table, restartIdentity)
+}
+
+func (d *Database) truncate(ctx context.Context, db Querier, table string, restartIdentity, cascade bool) error {
+	var q string
+	switch {
+	case d.Dialect == DialectPostgreSQL:
+		q = "TRUNCATE TABLE " + d.quotedTable(table)
+		if restartIdentity {
+			q += " RESTART IDENTITY"
+		} else {
+			q += " CONTINUE IDENTITY"
+		}
+		if cascade {
+			q += " CASCADE"
+		}
+	case d.Dialect == DialectMySQL && restartIdentity:
+		q = "TRUNCATE TABLE " + d.quotedTable(table)
+	default:
+		return d.deleteAll(ctx, db, table, restartIdentity && d.Dialect == DialectSQLite)
+	}
+
+	if _, err := db.ExecContext(ctx, q); err != nil {
+		return d.queryError("Truncate", table, q, nil, err)
+	}
+	return nil
+}
+
+// DeleteAll removes every row from table with a plain DELETE statement,
+// which (unlike TRUNCATE) runs inside the current transaction everywhere
+// and fires delete triggers.
+func (d *Database) DeleteAll(ctx context.Context, db Querier, table string) error {
+	if err := d.writable("DeleteAll", table); err != nil {
+		return err
+	}
+	forgetTable(ctx, table)
+	ctx, db, done := d.begin(ctx, db, "DeleteAll", table)
+	defer done()
+
+	return d.deleteAll(ctx, db, table, false)
+}
+
+// DeleteAll using the Default Database type
+func DeleteAll(ctx context.Context, db Querier, table string) error {
+	return Default.DeleteAll(ctx, db, table)
+}
+
+func (d *Database) deleteAll(ctx context.Context, db Querier, table string, resetSequence bool) error {
+	q := "DELETE FROM " + d.quotedTable(table)
+	if _, err := db.ExecContext(ctx, q); err != nil {
+		return d.queryError("DeleteAll", table, q, nil, err)
+	}
+	if !resetSequence {
+		return nil
+	}
+
+	// sqlite_sequence only exists once a table uses AUTOINCREMENT
+	var n int
+	q = "SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'sqlite_sequence'"
+	if err := db.QueryRowContext(ctx, q).Scan(&n); err != nil {
+		return d.queryError("Truncate", table, q, nil, err)
+	}
+	if n == 0 {
+		return nil
+	}
+	q = "DELETE FROM sqlite_sequence WHERE name = " + d.placeholder(1)
+	if _, err := db.ExecContext(ctx, q, table); err != nil {
+		return d.queryError("Truncate", table, q, []interface{}{table}, err)
+	}
+	return nil
 }
 
 // QueryRow performs the given query with the given arguments, scanning a
 // single row of results into dst. Returns sql.ErrNoRows if there was no
 // result row.
 func (d *Database) QueryRow(ctx context.Context, db Querier, dst interface{}, query string, args ...interface{}) error {
+	ctx, db, done := d.begin(ctx, db, "QueryRow", "")
+	defer done()
+
 	// perform the query
 	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return err
+		return d.queryError("QueryRow", "", query, args, err)
 	}
 
 	// gather the result
-	return d.ScanRow(rows, dst)
+	return d.scanSingle(rows, dst, query)
 }
 
 // QueryRow using the Default Database type
@@ -240,15 +632,36 @@ func QueryRow(ctx context.Context, db Querier, dst interface{}, query string, ar
 
 // QueryAll performs the given query with the given arguments, scanning
 // all results rows into dst.
+//
+// If CancelOnDeadline is set, db is a *sql.DB, and ctx has a deadline, the
+// query runs on a dedicated connection whose server-side id is noted
+// first. Should the deadline pass before QueryAll returns, the query is
+// cancelled from another connection (pg_cancel_backend on PostgreSQL,
+// KILL QUERY on MySQL) and the dedicated connection is discarded.
 func (d *Database) QueryAll(ctx context.Context, db Querier, dst interface{}, query string, args ...interface{}) error {
+	if pool, ok := db.(*sql.DB); ok && d.CancelOnDeadline {
+		if _, hasDeadline := ctx.Deadline(); hasDeadline {
+			if idQuery, cancelQuery := d.cancelStatements(); idQuery != "" {
+				return d.queryAllCancelable(ctx, pool, dst, query, args, idQuery, cancelQuery)
+			}
+		}
+	}
+
+	ctx, db, done := d.begin(ctx, db, "QueryAll", "")
+	defer done()
+	if d.ExplainThreshold > 0 {
+		defer d.explainSlow(ctx, db, "QueryAll", query, args, time.Now())
+	}
+
 	// perform the query
 	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return err
+		return d.queryError("QueryAll", "", query, args, err)
 	}
+	ctx = withScanQuery(ctx, query)
 
-	// gather the results
-	return d.ScanAll(rows, dst)
+	// gather the results, stopping if ctx is cancelled mid-scan
+	return d.ScanAllContext(ctx, rows, dst)
 }
 
 // QueryAll using the Default Database type
@@ -256,11 +669,173 @@ func QueryAll(ctx context.Context, db Querier, dst interface{}, query string, ar
 	return Default.QueryAll(ctx, db, dst, query, args...)
 }
 
-// quotedTable returns the properly quoted table name, handling optional schema (e.g., schema.table)
+// QueryAllReuse is like QueryAll, but replaces the contents of dst and
+// reuses its backing array and structs, as described for ScanAllReuse.
+func (d *Database) QueryAllReuse(ctx context.Context, db Querier, dst interface{}, query string, args ...interface{}) error {
+	ctx, db, done := d.begin(ctx, db, "QueryAllReuse", "")
+	defer done()
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return d.queryError("QueryAll", "", query, args, err)
+	}
+	ctx = withScanQuery(ctx, query)
+
+	return d.ScanAllReuse(ctx, rows, dst)
+}
+
+// QueryAllReuse using the Default Database type
+func QueryAllReuse(ctx context.Context, db Querier, dst interface{}, query string, args ...interface{}) error {
+	return Default.QueryAllReuse(ctx, db, dst, query, args...)
+}
+
+// QueryEach performs the given query, scans each row into dst, and calls fn
+// after each one. dst must be a pointer to a struct; it is reset to its
+// zero value before each row, so fn must copy anything it wants to keep.
+// If fn returns ErrStop, QueryEach stops and returns nil; any other error
+// stops it and is returned as is.
+func (d *Database) QueryEach(ctx context.Context, db Querier, dst interface{}, fn func() error, query string, args ...interface{}) error {
+	ctx, db, done := d.begin(ctx, db, "QueryEach", "")
+	defer done()
+
+	dstVal := reflect.ValueOf(dst)
+	if dstVal.Kind() != reflect.Ptr || dstVal.IsNil() || dstVal.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("meddler.QueryEach: destination must be a pointer to a struct, found %T", dst)
+	}
+	data, err := getFields(dstVal.Type())
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return d.queryError("QueryEach", "", query, args, err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	plan := data.plan(query, columns)
+
+	zero := reflect.Zero(dstVal.Elem().Type())
+	for row := 1; ; row++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		dstVal.Elem().Set(zero)
+		if err := d.scanRow(data, plan, rows, dst, nil); err != nil {
+			if err == sql.ErrNoRows {
+				return nil
+			}
+			return atRow(err, row)
+		}
+		if err := fn(); err != nil {
+			if err == ErrStop {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// QueryEach using the Default Database type
+func QueryEach(ctx context.Context, db Querier, dst interface{}, fn func() error, query string, args ...interface{}) error {
+	return Default.QueryEach(ctx, db, dst, fn, query, args...)
+}
+
+// QueryAllMap performs the given query and scans the results into a map
+// keyed by primary key. dst must be a pointer to a map with an integer key
+// type and values of type *T or T, e.g. *map[int64]*Person. A nil map is
+// allocated; otherwise the results are added to what is already there,
+// and later rows replace earlier ones with the same key.
+func (d *Database) QueryAllMap(ctx context.Context, db Querier, dst interface{}, query string, args ...interface{}) error {
+	ctx, db, done := d.begin(ctx, db, "QueryAllMap", "")
+	defer done()
+
+	dstVal := reflect.ValueOf(dst)
+	if dstVal.Kind() != reflect.Ptr || dstVal.IsNil() || dstVal.Elem().Kind() != reflect.Map {
+		return fmt.Errorf("meddler.QueryAllMap: destination must be a pointer to a map, found %T", dst)
+	}
+	mapVal := dstVal.Elem()
+	keyType, valType := mapVal.Type().Key(), mapVal.Type().Elem()
+	switch keyType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		return fmt.Errorf("meddler.QueryAllMap: map key must be an integer type, found %T", dst)
+	}
+	eltType := valType
+	if valType.Kind() == reflect.Ptr {
+		eltType = valType.Elem()
+	}
+	if eltType.Kind() != reflect.Struct {
+		return fmt.Errorf("meddler.QueryAllMap: map values must be structs or pointers to structs, found %T", dst)
+	}
+	data, err := getFields(reflect.PtrTo(eltType))
+	if err != nil {
+		return err
+	}
+	if data.pk == "" {
+		return fmt.Errorf("meddler.QueryAllMap: %w", ErrNoPrimaryKey)
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return d.queryError("QueryAllMap", "", query, args, err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	plan := data.plan(query, columns)
+
+	if mapVal.IsNil() {
+		mapVal.Set(reflect.MakeMap(mapVal.Type()))
+	}
+	for row := 1; ; row++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		eltVal := reflect.New(eltType)
+		if err := d.scanRow(data, plan, rows, eltVal.Interface(), nil); err != nil {
+			if err == sql.ErrNoRows {
+				return rows.Close()
+			}
+			return atRow(err, row)
+		}
+		_, pk, err := d.PrimaryKey(eltVal.Interface())
+		if err != nil {
+			return err
+		}
+		if valType.Kind() != reflect.Ptr {
+			eltVal = eltVal.Elem()
+		}
+		mapVal.SetMapIndex(reflect.ValueOf(pk).Convert(keyType), eltVal)
+	}
+}
+
+// QueryAllMap using the Default Database type
+func QueryAllMap(ctx context.Context, db Querier, dst interface{}, query string, args ...interface{}) error {
+	return Default.QueryAllMap(ctx, db, dst, query, args...)
+}
+
+// quotedTable returns the properly quoted table name, handling optional
+// schema (e.g., schema.table) and any TableConfig for the table
 func (d *Database) quotedTable(table string) string {
-	parts := strings.Split(table, ".")
+	quote, name := d.Quote, table
+	if config, present := d.Tables[table]; present {
+		if config.Quote != "" {
+			quote = config.Quote
+		}
+		if config.Schema != "" && !strings.Contains(table, ".") {
+			name = config.Schema + "." + table
+		}
+	}
+	parts := strings.Split(name, ".")
 	for i, part := range parts {
-		parts[i] = d.quoted(part)
+		parts[i] = quoteIdentifier(quote, part)
 	}
 	return strings.Join(parts, ".")
 }
@@ -1,6 +1,8 @@
 package meddlerx
 
 import (
+	"database/sql"
+	"errors"
 	"io"
 	"testing"
 	"time"
@@ -63,6 +65,42 @@ func TestLoadUint(t *testing.T) {
 	db.Exec("delete from person")
 }
 
+func TestLoadKey(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	for _, key := range []interface{}{uint(2), int32(2), "2", []byte("2")} {
+		elt := new(UintPerson)
+		if err := LoadKey(testCtx, db, "person", elt, key); err != nil {
+			t.Errorf("LoadKey error on Bob with %T key: %v", key, err)
+			continue
+		}
+		if elt.ID != 2 || elt.Name != "Bob" {
+			t.Errorf("LoadKey with %T key: expected Bob, got %+v", key, elt)
+		}
+	}
+
+	elt := new(Person)
+	if err := LoadKey(testCtx, db, "person", elt, "bob"); err == nil {
+		t.Errorf("LoadKey with a non-numeric key: expected err, got nil")
+	}
+	if err := LoadKey(testCtx, db, "person", new(UintPerson), -1); err == nil {
+		t.Errorf("LoadKey with a negative key for a uint field: expected err, got nil")
+	}
+	if err := LoadKey(testCtx, db, "person", elt, int64(99)); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("LoadKey on a missing row: expected sql.ErrNoRows, got %v", err)
+	}
+
+	type stringKeyed struct {
+		ID   string `meddler:"id,pk"`
+		Name string `meddler:"name"`
+	}
+	if err := LoadKey(testCtx, db, "person", new(stringKeyed), "2"); err == nil {
+		t.Errorf("LoadKey with a string primary key field: expected err, got nil")
+	}
+}
+
 func TestQueryAll(t *testing.T) {
 	once.Do(setup)
 	insertAliceBob(t)
@@ -83,6 +121,76 @@ func TestQueryAll(t *testing.T) {
 	}
 }
 
+func TestQueryEach(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	var names []string
+	p := new(Person)
+	err := QueryEach(testCtx, db, p, func() error {
+		names = append(names, p.Name)
+		return nil
+	}, "SELECT * FROM person ORDER BY id")
+	if err != nil {
+		t.Errorf("QueryEach error: %v", err)
+	}
+	if len(names) != 2 || names[0] != "Alice" || names[1] != "Bob" {
+		t.Errorf("QueryEach: expected Alice and Bob, got %v", names)
+	}
+
+	// stop after the first row
+	names = nil
+	err = QueryEach(testCtx, db, p, func() error {
+		names = append(names, p.Name)
+		return ErrStop
+	}, "SELECT * FROM person ORDER BY id")
+	if err != nil || len(names) != 1 {
+		t.Errorf("QueryEach with ErrStop: expected 1 row and no error, got %v, %v", names, err)
+	}
+
+	// other errors are passed through
+	failed := errors.New("failed")
+	err = QueryEach(testCtx, db, p, func() error { return failed }, "SELECT * FROM person")
+	if err != failed {
+		t.Errorf("QueryEach with error: expected %v, got %v", failed, err)
+	}
+
+	var people []*Person
+	if err := QueryEach(testCtx, db, &people, func() error { return nil }, "SELECT * FROM person"); err == nil {
+		t.Errorf("QueryEach with slice destination: expected an error")
+	}
+}
+
+func TestQueryAllMap(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	var byID map[int64]*Person
+	if err := QueryAllMap(testCtx, db, &byID, "SELECT * FROM person"); err != nil {
+		t.Fatalf("QueryAllMap error: %v", err)
+	}
+	if len(byID) != 2 {
+		t.Fatalf("QueryAllMap: expected 2 people, got %d", len(byID))
+	}
+	bob.ID = 2
+	personEqual(t, byID[2], bob)
+
+	byValue := map[int]Person{99: {Name: "existing"}}
+	if err := QueryAllMap(testCtx, db, &byValue, "SELECT * FROM person WHERE id = 2"); err != nil {
+		t.Fatalf("QueryAllMap error: %v", err)
+	}
+	if len(byValue) != 2 || byValue[2].Name != "Bob" {
+		t.Errorf("QueryAllMap by value: got %+v", byValue)
+	}
+
+	var byName map[string]*Person
+	if err := QueryAllMap(testCtx, db, &byName, "SELECT * FROM person"); err == nil {
+		t.Errorf("QueryAllMap with string keys: expected an error")
+	}
+}
+
 func TestSave(t *testing.T) {
 	once.Do(setup)
 	insertAliceBob(t)
@@ -191,3 +299,161 @@ func TestDriverErr(t *testing.T) {
 		t.Errorf("update with primary key 0. want error, got none")
 	}
 }
+
+func TestTruncate(t *testing.T) {
+	once.Do(setup)
+
+	if _, err := db.Exec("create table counter (id integer primary key autoincrement, n integer not null)"); err != nil {
+		t.Fatalf("creating counter table: %v", err)
+	}
+	defer db.Exec("drop table counter")
+
+	type counter struct {
+		ID int64 `meddler:"id,pk"`
+		N  int   `meddler:"n"`
+	}
+	insert := func() int64 {
+		elt := &counter{N: 1}
+		if err := SQLite.Insert(testCtx, db, "counter", elt); err != nil {
+			t.Fatalf("Insert error: %v", err)
+		}
+		return elt.ID
+	}
+
+	insert()
+	insert()
+	if err := SQLite.Truncate(testCtx, db, "counter", false); err != nil {
+		t.Fatalf("Truncate error: %v", err)
+	}
+	if id := insert(); id != 3 {
+		t.Errorf("Truncate without restart: expected next id 3, got %d", id)
+	}
+
+	if err := SQLite.Truncate(testCtx, db, "counter", true); err != nil {
+		t.Fatalf("Truncate error: %v", err)
+	}
+	if id := insert(); id != 1 {
+		t.Errorf("Truncate with restart: expected next id 1, got %d", id)
+	}
+
+	if err := SQLite.DeleteAll(testCtx, db, "counter"); err != nil {
+		t.Fatalf("DeleteAll error: %v", err)
+	}
+	var n int
+	if err := db.QueryRow("select count(*) from counter").Scan(&n); err != nil || n != 0 {
+		t.Errorf("DeleteAll: expected empty table, got %d rows (err %v)", n, err)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	if err := Delete(testCtx, db, "person", alice); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	elt := new(Person)
+	if err := Load(testCtx, db, "person", elt, alice.ID); err == nil {
+		t.Errorf("Load after Delete: expected err, got nil")
+	}
+	if err := Load(testCtx, db, "person", elt, bob.ID); err != nil {
+		t.Errorf("Delete removed the wrong row: %v", err)
+	}
+
+	if err := Delete(testCtx, db, "person", new(Person)); err == nil {
+		t.Errorf("Delete with zero pk: expected err, got nil")
+	}
+}
+
+func TestInsertWithPK(t *testing.T) {
+	once.Do(setup)
+	defer db.Exec("delete from person")
+
+	elt := &Person{ID: 42, Name: "Dave", Email: "dave@dave.com", Opened: when}
+	if err := Insert(testCtx, db, "person", elt); err == nil {
+		t.Errorf("Insert with non-zero pk: expected err, got nil")
+	}
+	if err := InsertWithPK(testCtx, db, "person", elt); err != nil {
+		t.Fatalf("InsertWithPK error: %v", err)
+	}
+	loaded := new(Person)
+	if err := Load(testCtx, db, "person", loaded, 42); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	personEqual(t, loaded, elt)
+
+	// the key is written as given, so a duplicate fails
+	if err := InsertWithPK(testCtx, db, "person", elt); err == nil {
+		t.Errorf("InsertWithPK with duplicate pk: expected err, got nil")
+	}
+}
+
+func TestAllowZeroPK(t *testing.T) {
+	once.Do(setup)
+	defer db.Exec("delete from person")
+
+	sentinel := &Person{ID: -1, Name: "Nobody", Email: "nobody@example.com", Opened: when}
+	if err := SQLite.InsertWithPK(testCtx, db, "person", sentinel); err != nil {
+		t.Fatalf("InsertWithPK error: %v", err)
+	}
+
+	sentinel.Name = "Somebody"
+	if err := SQLite.Update(testCtx, db, "person", sentinel); err == nil {
+		t.Errorf("Update with negative pk: expected err, got nil")
+	}
+
+	lax := *SQLite
+	lax.AllowZeroPK = true
+	if err := lax.Update(testCtx, db, "person", sentinel); err != nil {
+		t.Fatalf("Update with negative pk and AllowZeroPK: %v", err)
+	}
+	loaded := new(Person)
+	if err := lax.Load(testCtx, db, "person", loaded, -1); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if loaded.Name != "Somebody" {
+		t.Errorf("Update with AllowZeroPK: expected Somebody, got %s", loaded.Name)
+	}
+	if err := lax.Delete(testCtx, db, "person", sentinel); err != nil {
+		t.Errorf("Delete with negative pk and AllowZeroPK: %v", err)
+	}
+}
+
+func TestSaveMode(t *testing.T) {
+	once.Do(setup)
+	defer db.Exec("delete from person")
+
+	detached := &Person{ID: 9, Name: "Erin", Email: "erin@erin.com", Opened: when}
+
+	// by default a stale key updates nothing and succeeds
+	if err := SQLite.Save(testCtx, db, "person", detached); err != nil {
+		t.Errorf("Save with SaveTrustPK: %v", err)
+	}
+
+	strict := *SQLite
+	strict.SaveMode = SaveRequireExisting
+	if err := strict.Save(testCtx, db, "person", detached); !errors.Is(err, ErrStaleRow) {
+		t.Errorf("Save with SaveRequireExisting: expected ErrStaleRow, got %v", err)
+	}
+
+	upsert := *SQLite
+	upsert.SaveMode = SaveInsertMissing
+	if err := upsert.Save(testCtx, db, "person", detached); err != nil {
+		t.Fatalf("Save with SaveInsertMissing: %v", err)
+	}
+	loaded := new(Person)
+	if err := Load(testCtx, db, "person", loaded, 9); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	personEqual(t, loaded, detached)
+
+	// now that the row exists, both modes update it
+	detached.Name = "Erin B"
+	if err := strict.Save(testCtx, db, "person", detached); err != nil {
+		t.Errorf("Save with SaveRequireExisting on existing row: %v", err)
+	}
+	if err := upsert.Save(testCtx, db, "person", detached); err != nil {
+		t.Errorf("Save with SaveInsertMissing on existing row: %v", err)
+	}
+}
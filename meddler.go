@@ -3,8 +3,10 @@ package meddlerx
 import (
 	"bytes"
 	"compress/gzip"
+	"database/sql"
 	"encoding/gob"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"reflect"
 	"time"
@@ -29,18 +31,31 @@ type Meddler interface {
 	PreWrite(field interface{}) (saveValue interface{}, err error)
 }
 
+// DialectWriter is an optional interface for a Meddler whose stored form
+// depends on the database. When a meddler implements it, PreWriteDialect is
+// called in place of PreWrite with the Database doing the write.
+type DialectWriter interface {
+	PreWriteDialect(d *Database, field interface{}) (saveValue interface{}, err error)
+}
+
 // Register sets up a meddler type. Meddlers get a chance to meddle with the
 // data being loaded or saved when a field is annotated with the name of the meddler.
 // The registry is global.
 func Register(name string, m Meddler) {
-	if name == "pk" {
-		panic("meddler.Register: pk cannot be used as a meddler name")
+	if name == "pk" || name == "generated" {
+		panic("meddler.Register: " + name + " cannot be used as a meddler name")
 	}
 	registry[name] = m
 }
 
 var registry = make(map[string]Meddler)
 
+// Registered reports whether a meddler has been registered under name.
+func Registered(name string) bool {
+	_, present := registry[name]
+	return present
+}
+
 func init() {
 	Register("identity", IdentityMeddler(false))
 	Register("localtime", TimeMeddler{ZeroIsNull: false, Local: true})
@@ -52,6 +67,16 @@ func init() {
 	Register("jsongzip", JSONMeddler(true))
 	Register("gob", GobMeddler(false))
 	Register("gobgzip", GobMeddler(true))
+	Register("xml", XMLMeddler(false))
+	Register("xmlgzip", XMLMeddler(true))
+	Register("geometry", GeometryMeddler(false))
+	Register("ewkb", GeometryMeddler(true))
+	Register("inet", NetMeddler("inet"))
+	Register("cidr", NetMeddler("cidr"))
+	Register("macaddr", NetMeddler("macaddr"))
+	Register("rawbytes", BytesMeddler(true))
+	Register("hstore", HstoreMeddler(false))
+	Register("bitmask", SetMeddler(""))
 }
 
 // IdentityMeddler is the default meddler, and it passes the original value through with
@@ -73,6 +98,45 @@ func (elt IdentityMeddler) PreWrite(field interface{}) (saveValue interface{}, e
 	return field, nil
 }
 
+// FieldMeddler can be implemented by a field's type to control how it is
+// read and written, without registering a meddler or tagging the field,
+// much as encoding/json honors Marshaler. The methods are called on a
+// pointer to the field. A meddler named in the field's tag takes precedence.
+type FieldMeddler interface {
+	// PreRead returns the value that will be given to the database driver
+	// to scan into.
+	PreRead() (scanTarget interface{}, err error)
+
+	// PostRead is given the value returned by PreRead after the scan, and
+	// fills in the field from it.
+	PostRead(scanTarget interface{}) error
+
+	// PreWrite returns the value that will be given to the database driver
+	// to save.
+	PreWrite() (saveValue interface{}, err error)
+}
+
+var fieldMeddlerType = reflect.TypeOf((*FieldMeddler)(nil)).Elem()
+
+// fieldMeddlerAdapter is the Meddler used for fields whose type implements
+// FieldMeddler.
+type fieldMeddlerAdapter struct{}
+
+func (fieldMeddlerAdapter) PreRead(fieldAddr interface{}) (scanTarget interface{}, err error) {
+	return fieldAddr.(FieldMeddler).PreRead()
+}
+
+func (fieldMeddlerAdapter) PostRead(fieldAddr, scanTarget interface{}) error {
+	return fieldAddr.(FieldMeddler).PostRead(scanTarget)
+}
+
+func (fieldMeddlerAdapter) PreWrite(field interface{}) (saveValue interface{}, err error) {
+	// the methods may have pointer receivers, so work on a copy
+	addr := reflect.New(reflect.TypeOf(field))
+	addr.Elem().Set(reflect.ValueOf(field))
+	return addr.Interface().(FieldMeddler).PreWrite()
+}
+
 // TimeMeddler provides useful operations on time.Time fields. It can convert the zero time
 // to and from a null column, and it can convert the time zone to UTC on save and to Local on load.
 type TimeMeddler struct {
@@ -225,6 +289,63 @@ func (elt ZeroIsNullMeddler) PreWrite(field interface{}) (saveValue interface{},
 	return field, nil
 }
 
+// BytesMeddler handles []byte and sql.RawBytes fields. BytesMeddler(false)
+// is used automatically for sql.RawBytes fields: it copies the data, since a
+// RawBytes scanned directly would point into a buffer the driver reuses for
+// the next row. BytesMeddler(true), registered as "rawbytes", does the
+// opposite: it stores the driver's buffer without copying. The field is then
+// only valid until the next row is read or the rows are closed, e.g. within
+// the callback of QueryEach, and it cannot be used with ScanSingleRow.
+type BytesMeddler bool
+
+var rawBytesType = reflect.TypeOf(sql.RawBytes(nil))
+
+// PreRead is called before a Scan operation for fields that have the BytesMeddler
+func (zeroCopy BytesMeddler) PreRead(fieldAddr interface{}) (scanTarget interface{}, err error) {
+	switch fieldAddr.(type) {
+	case *[]byte, *sql.RawBytes:
+	default:
+		return nil, fmt.Errorf("meddler.BytesMeddler.PreRead: unknown struct field type: %T", fieldAddr)
+	}
+	if zeroCopy {
+		return new(sql.RawBytes), nil
+	}
+	return new([]byte), nil
+}
+
+// PostRead is called after a Scan operation for fields that have the BytesMeddler
+func (zeroCopy BytesMeddler) PostRead(fieldAddr, scanTarget interface{}) error {
+	var b []byte
+	switch target := scanTarget.(type) {
+	case *sql.RawBytes:
+		b = *target
+	case *[]byte:
+		b = *target
+	default:
+		return fmt.Errorf("meddler.BytesMeddler.PostRead: unexpected scan target type: %T", scanTarget)
+	}
+	switch field := fieldAddr.(type) {
+	case *[]byte:
+		*field = b
+	case *sql.RawBytes:
+		*field = b
+	default:
+		return fmt.Errorf("meddler.BytesMeddler.PostRead: unknown struct field type: %T", fieldAddr)
+	}
+	return nil
+}
+
+// PreWrite is called before an Insert or Update operation for fields that have the BytesMeddler
+func (zeroCopy BytesMeddler) PreWrite(field interface{}) (saveValue interface{}, err error) {
+	switch field := field.(type) {
+	case []byte:
+		return field, nil
+	case sql.RawBytes:
+		return []byte(field), nil
+	}
+	return nil, fmt.Errorf("meddler.BytesMeddler.PreWrite: unknown struct field type: %T", field)
+}
+
 // JSONMeddler encodes or decodes the field value to or from JSON
 type JSONMeddler bool
 
@@ -246,15 +367,15 @@ func (zip JSONMeddler) PostRead(fieldAddr, scanTarget interface{}) error {
 		// un-gzip and decode json
 		gzipReader, err := gzip.NewReader(bytes.NewReader(raw))
 		if err != nil {
-			return fmt.Errorf("Error creating gzip Reader: %v", err)
+			return fmt.Errorf("Error creating gzip Reader: %w", err)
 		}
 		defer gzipReader.Close()
 		jsonDecoder := json.NewDecoder(gzipReader)
 		if err := jsonDecoder.Decode(fieldAddr); err != nil {
-			return fmt.Errorf("JSON decoder/gzip error: %v", err)
+			return fmt.Errorf("JSON decoder/gzip error: %w", err)
 		}
 		if err := gzipReader.Close(); err != nil {
-			return fmt.Errorf("Closing gzip reader: %v", err)
+			return fmt.Errorf("Closing gzip reader: %w", err)
 		}
 
 		return nil
@@ -263,7 +384,7 @@ func (zip JSONMeddler) PostRead(fieldAddr, scanTarget interface{}) error {
 	// decode json
 	jsonDecoder := json.NewDecoder(bytes.NewReader(raw))
 	if err := jsonDecoder.Decode(fieldAddr); err != nil {
-		return fmt.Errorf("JSON decode error: %v", err)
+		return fmt.Errorf("JSON decode error: %w", err)
 	}
 
 	return nil
@@ -279,10 +400,10 @@ func (zip JSONMeddler) PreWrite(field interface{}) (saveValue interface{}, err e
 		defer gzipWriter.Close()
 		jsonEncoder := json.NewEncoder(gzipWriter)
 		if err := jsonEncoder.Encode(field); err != nil {
-			return nil, fmt.Errorf("JSON encoding/gzip error: %v", err)
+			return nil, fmt.Errorf("JSON encoding/gzip error: %w", err)
 		}
 		if err := gzipWriter.Close(); err != nil {
-			return nil, fmt.Errorf("Closing gzip writer: %v", err)
+			return nil, fmt.Errorf("Closing gzip writer: %w", err)
 		}
 
 		return buffer.Bytes(), nil
@@ -291,7 +412,7 @@ func (zip JSONMeddler) PreWrite(field interface{}) (saveValue interface{}, err e
 	// json encode
 	jsonEncoder := json.NewEncoder(buffer)
 	if err := jsonEncoder.Encode(field); err != nil {
-		return nil, fmt.Errorf("JSON encoding error: %v", err)
+		return nil, fmt.Errorf("JSON encoding error: %w", err)
 	}
 	return buffer.Bytes(), nil
 }
@@ -317,15 +438,15 @@ func (zip GobMeddler) PostRead(fieldAddr, scanTarget interface{}) error {
 		// un-gzip and decode gob
 		gzipReader, err := gzip.NewReader(bytes.NewReader(raw))
 		if err != nil {
-			return fmt.Errorf("Error creating gzip Reader: %v", err)
+			return fmt.Errorf("Error creating gzip Reader: %w", err)
 		}
 		defer gzipReader.Close()
 		gobDecoder := gob.NewDecoder(gzipReader)
 		if err := gobDecoder.Decode(fieldAddr); err != nil {
-			return fmt.Errorf("Gob decoder/gzip error: %v", err)
+			return fmt.Errorf("Gob decoder/gzip error: %w", err)
 		}
 		if err := gzipReader.Close(); err != nil {
-			return fmt.Errorf("Closing gzip reader: %v", err)
+			return fmt.Errorf("Closing gzip reader: %w", err)
 		}
 
 		return nil
@@ -334,7 +455,7 @@ func (zip GobMeddler) PostRead(fieldAddr, scanTarget interface{}) error {
 	// decode gob
 	gobDecoder := gob.NewDecoder(bytes.NewReader(raw))
 	if err := gobDecoder.Decode(fieldAddr); err != nil {
-		return fmt.Errorf("Gob decode error: %v", err)
+		return fmt.Errorf("Gob decode error: %w", err)
 	}
 
 	return nil
@@ -350,10 +471,10 @@ func (zip GobMeddler) PreWrite(field interface{}) (saveValue interface{}, err er
 		defer gzipWriter.Close()
 		gobEncoder := gob.NewEncoder(gzipWriter)
 		if err := gobEncoder.Encode(field); err != nil {
-			return nil, fmt.Errorf("Gob encoding/gzip error: %v", err)
+			return nil, fmt.Errorf("Gob encoding/gzip error: %w", err)
 		}
 		if err := gzipWriter.Close(); err != nil {
-			return nil, fmt.Errorf("Closing gzip writer: %v", err)
+			return nil, fmt.Errorf("Closing gzip writer: %w", err)
 		}
 
 		return buffer.Bytes(), nil
@@ -362,7 +483,80 @@ func (zip GobMeddler) PreWrite(field interface{}) (saveValue interface{}, err er
 	// gob encode
 	gobEncoder := gob.NewEncoder(buffer)
 	if err := gobEncoder.Encode(field); err != nil {
-		return nil, fmt.Errorf("Gob encoding error: %v", err)
+		return nil, fmt.Errorf("Gob encoding error: %w", err)
 	}
 	return buffer.Bytes(), nil
 }
+
+// XMLMeddler encodes or decodes the field value to or from XML
+type XMLMeddler bool
+
+// PreRead is called before a Scan operation for fields that have the XMLMeddler
+func (zip XMLMeddler) PreRead(fieldAddr interface{}) (scanTarget interface{}, err error) {
+	// give a pointer to a byte buffer to grab the raw data
+	return new([]byte), nil
+}
+
+// PostRead is called after a Scan operation for fields that have the XMLMeddler
+func (zip XMLMeddler) PostRead(fieldAddr, scanTarget interface{}) error {
+	ptr := scanTarget.(*[]byte)
+	if ptr == nil {
+		return fmt.Errorf("XMLMeddler.PostRead: nil pointer")
+	}
+	raw := *ptr
+
+	if zip {
+		// un-gzip and decode xml
+		gzipReader, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return fmt.Errorf("Error creating gzip Reader: %w", err)
+		}
+		defer gzipReader.Close()
+		xmlDecoder := xml.NewDecoder(gzipReader)
+		if err := xmlDecoder.Decode(fieldAddr); err != nil {
+			return fmt.Errorf("XML decoder/gzip error: %w", err)
+		}
+		if err := gzipReader.Close(); err != nil {
+			return fmt.Errorf("Closing gzip reader: %w", err)
+		}
+
+		return nil
+	}
+
+	// decode xml
+	xmlDecoder := xml.NewDecoder(bytes.NewReader(raw))
+	if err := xmlDecoder.Decode(fieldAddr); err != nil {
+		return fmt.Errorf("XML decode error: %w", err)
+	}
+
+	return nil
+}
+
+// PreWrite is called before an Insert or Update operation for fields that
+// have the XMLMeddler. Uncompressed documents are saved as strings, so
+// they can go in text columns.
+func (zip XMLMeddler) PreWrite(field interface{}) (saveValue interface{}, err error) {
+	buffer := new(bytes.Buffer)
+
+	if zip {
+		// xml encode and gzip
+		gzipWriter := gzip.NewWriter(buffer)
+		defer gzipWriter.Close()
+		xmlEncoder := xml.NewEncoder(gzipWriter)
+		if err := xmlEncoder.Encode(field); err != nil {
+			return nil, fmt.Errorf("XML encoding/gzip error: %w", err)
+		}
+		if err := gzipWriter.Close(); err != nil {
+			return nil, fmt.Errorf("Closing gzip writer: %w", err)
+		}
+
+		return buffer.Bytes(), nil
+	}
+
+	// xml encode
+	xmlEncoder := xml.NewEncoder(buffer)
+	if err := xmlEncoder.Encode(field); err != nil {
+		return nil, fmt.Errorf("XML encoding error: %w", err)
+	}
+	return buffer.String(), nil
+}
@@ -2,6 +2,9 @@ package meddlerx
 
 import (
 	"context"
+	"database/sql"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -17,6 +20,17 @@ type ItemGob struct {
 	StuffZ map[string]bool `meddler:"stuffz,gobgzip"`
 }
 
+type Stuff struct {
+	Names []string `xml:"name"`
+	Count int      `xml:"count,attr"`
+}
+
+type ItemXML struct {
+	ID     int64  `meddler:"id,pk"`
+	Stuff  Stuff  `meddler:"stuff,xml"`
+	StuffZ *Stuff `meddler:"stuffz,xmlgzip"`
+}
+
 type ItemZeroes struct {
 	ID      int64      `meddler:"id,pk"`
 	Int     int        `meddler:"nullint,zeroisnull"`
@@ -107,6 +121,41 @@ func TestJsonMeddler(t *testing.T) {
 	}
 }
 
+func TestXMLMeddler(t *testing.T) {
+	once.Do(setup)
+
+	// save a value
+	elt := &ItemXML{
+		Stuff:  Stuff{Names: []string{"hello", "world"}, Count: 2},
+		StuffZ: &Stuff{Names: []string{"goodbye", "cruel", "world"}, Count: 3},
+	}
+
+	if err := Save(testCtx, db, "item", elt); err != nil {
+		t.Errorf("Save error: %v", err)
+	}
+	id := elt.ID
+
+	var raw string
+	if err := db.QueryRow("select stuff from item where id = ?", id).Scan(&raw); err != nil {
+		t.Errorf("DB error: %v", err)
+	}
+	if expected := `<Stuff count="2"><name>hello</name><name>world</name></Stuff>`; raw != expected {
+		t.Errorf("expected stuff saved as %s, found %s", expected, raw)
+	}
+
+	// load it again
+	loaded := new(ItemXML)
+	if err := Load(testCtx, db, "item", loaded, id); err != nil {
+		t.Errorf("Load error: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, elt) {
+		t.Errorf("expected %+v, found %+v", elt, loaded)
+	}
+	if _, err := db.Exec("delete from `item`"); err != nil {
+		t.Errorf("error wiping item table: %v", err)
+	}
+}
+
 func TestGobMeddler(t *testing.T) {
 	once.Do(setup)
 
@@ -154,3 +203,95 @@ func TestGobMeddler(t *testing.T) {
 		t.Errorf("error wiping item table: %v", err)
 	}
 }
+
+// csvTags is stored as a comma-separated string by implementing FieldMeddler.
+type csvTags []string
+
+func (c *csvTags) PreRead() (interface{}, error) {
+	return new(string), nil
+}
+
+func (c *csvTags) PostRead(scanTarget interface{}) error {
+	s := *scanTarget.(*string)
+	*c = nil
+	if s != "" {
+		*c = strings.Split(s, ",")
+	}
+	return nil
+}
+
+func (c *csvTags) PreWrite() (interface{}, error) {
+	return strings.Join(*c, ","), nil
+}
+
+type ItemTags struct {
+	ID     int64   `meddler:"id,pk"`
+	Tags   csvTags `meddler:"stuff"`
+	StuffZ csvTags `meddler:"stuffz,json"`
+}
+
+func TestFieldMeddler(t *testing.T) {
+	once.Do(setup)
+	defer db.Exec("delete from item")
+
+	before := &ItemTags{Tags: csvTags{"a", "b"}, StuffZ: csvTags{"c"}}
+	if err := Insert(testCtx, db, "item", before); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	var stuff, stuffz string
+	if err := db.QueryRow("select stuff, stuffz from item where id = ?", before.ID).Scan(&stuff, &stuffz); err != nil {
+		t.Fatalf("DB error: %v", err)
+	}
+	// the json tag wins over the type's own methods
+	if stuff != "a,b" || stuffz != "[\"c\"]\n" {
+		t.Errorf("FieldMeddler: stored stuff=%q stuffz=%q", stuff, stuffz)
+	}
+
+	after := new(ItemTags)
+	if err := Load(testCtx, db, "item", after, before.ID); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if !reflect.DeepEqual(after, before) {
+		t.Errorf("FieldMeddler: expected %+v, got %+v", before, after)
+	}
+}
+
+type ItemBytes struct {
+	ID     int64        `meddler:"id,pk"`
+	Stuff  sql.RawBytes `meddler:"stuff"`
+	StuffZ []byte       `meddler:"stuffz,rawbytes"`
+}
+
+func TestBytesMeddler(t *testing.T) {
+	once.Do(setup)
+	defer db.Exec("delete from item")
+
+	for _, s := range []string{"first", "second"} {
+		if err := Insert(testCtx, db, "item", &ItemBytes{Stuff: sql.RawBytes(s), StuffZ: []byte(s + "z")}); err != nil {
+			t.Fatalf("Insert error: %v", err)
+		}
+	}
+
+	// sql.RawBytes fields are copied, so they survive the next row
+	var items []*ItemBytes
+	if err := QueryAll(testCtx, db, &items, "select id, stuff from item order by id"); err != nil {
+		t.Fatalf("QueryAll error: %v", err)
+	}
+	if len(items) != 2 || string(items[0].Stuff) != "first" || string(items[1].Stuff) != "second" {
+		t.Errorf("RawBytes field: got %+v", items)
+	}
+
+	// rawbytes fields are valid within the callback
+	var seen []string
+	item := new(ItemBytes)
+	err := QueryEach(testCtx, db, item, func() error {
+		seen = append(seen, string(item.StuffZ))
+		return nil
+	}, "select id, stuff, stuffz from item order by id")
+	if err != nil {
+		t.Fatalf("QueryEach error: %v", err)
+	}
+	if len(seen) != 2 || seen[0] != "firstz" || seen[1] != "secondz" {
+		t.Errorf("rawbytes field: got %q", seen)
+	}
+}
@@ -0,0 +1,328 @@
+// Package meddlerhttp serves JSON CRUD endpoints over a table, using the
+// meddlerx mapping of a struct type. It is meant for internal admin APIs,
+// where a generic List/Get/Create/Update/Delete is all that is needed.
+//
+// Records are encoded as JSON objects keyed by column name, with the
+// columns in struct order and each value encoded from its Go field by
+// encoding/json. Meddlers are not involved in the JSON form, only in
+// reading and writing the database.
+package meddlerhttp
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/scanfully/meddlerx"
+)
+
+// Handler serves CRUD endpoints for the rows of Table, each loaded into a
+// T, which must be a struct type with a primary key. Mounted at a prefix
+// with http.StripPrefix, it answers:
+//
+//	GET    /     List
+//	POST   /     Create
+//	GET    /{pk} Get
+//	PUT    /{pk} Update
+//	DELETE /{pk} Delete
+type Handler[T any] struct {
+	// DB is the Querier used for every request.
+	DB meddlerx.Querier
+
+	// Database is the Database used. If nil, meddlerx.Default is used.
+	Database *meddlerx.Database
+
+	// Table is the table served.
+	Table string
+
+	// MaxLimit caps the number of rows List returns. If zero, 100 is used.
+	MaxLimit int
+}
+
+// New returns a Handler for the rows of table in db.
+func New[T any](db meddlerx.Querier, table string) *Handler[T] {
+	return &Handler[T]{DB: db, Table: table}
+}
+
+func (h *Handler[T]) database() *meddlerx.Database {
+	if h.Database == nil {
+		return meddlerx.Default
+	}
+	return h.Database
+}
+
+// ServeHTTP routes a request to List, Create, Get, Update, or Delete.
+func (h *Handler[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	pk := strings.Trim(r.URL.Path, "/")
+	if strings.Contains(pk, "/") || (pk != "" && !validKey(pk)) {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	switch {
+	case pk == "" && r.Method == http.MethodGet:
+		h.List(w, r)
+	case pk == "" && r.Method == http.MethodPost:
+		h.Create(w, r)
+	case pk != "" && r.Method == http.MethodGet:
+		h.Get(w, r, pk)
+	case pk != "" && r.Method == http.MethodPut:
+		h.Update(w, r, pk)
+	case pk != "" && r.Method == http.MethodDelete:
+		h.Delete(w, r, pk)
+	default:
+		if pk == "" {
+			w.Header().Set("Allow", "GET, POST")
+		} else {
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+		}
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// List writes a JSON array of records. Query parameters limit and offset
+// page through the rows, order names a column to sort by (prefixed with
+// "-" for descending order), and any other parameter named after a column
+// keeps only rows where that column equals the given value.
+func (h *Handler[T]) List(w http.ResponseWriter, r *http.Request) {
+	d := h.database()
+	maxLimit := h.MaxLimit
+	if maxLimit <= 0 {
+		maxLimit = 100
+	}
+	columns, err := d.Columns(new(T), true)
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	known := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		known[column] = true
+	}
+
+	opts := []meddlerx.QueryOption{meddlerx.Limit(maxLimit)}
+	query := r.URL.Query()
+	for name, values := range query {
+		switch {
+		case name == "limit" || name == "offset":
+			n, err := strconv.Atoi(values[0])
+			if err != nil || n < 0 {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s %q", name, values[0]))
+				return
+			}
+			if name == "offset" {
+				opts = append(opts, meddlerx.Offset(n))
+			} else if n < maxLimit {
+				opts = append(opts, meddlerx.Limit(n))
+			}
+		case name == "order":
+			column, dir := values[0], ""
+			if strings.HasPrefix(column, "-") {
+				column, dir = column[1:], " DESC"
+			}
+			if !known[column] {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown column %q", column))
+				return
+			}
+			opts = append(opts, meddlerx.OrderBy(column+dir))
+		case known[name]:
+			opts = append(opts, meddlerx.Where(d.QuoteIdentifier(name)+" = ?", values[0]))
+		default:
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown parameter %q", name))
+			return
+		}
+	}
+
+	var records []*T
+	if err := d.LoadAllWhere(r.Context(), h.DB, h.Table, &records, opts...); err != nil {
+		h.fail(w, err)
+		return
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, record := range records {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := h.encode(&buf, record); err != nil {
+			h.fail(w, err)
+			return
+		}
+	}
+	buf.WriteByte(']')
+	writeJSON(w, http.StatusOK, buf.Bytes())
+}
+
+// Get writes the record with primary key pk.
+func (h *Handler[T]) Get(w http.ResponseWriter, r *http.Request, pk string) {
+	record := new(T)
+	if err := h.database().LoadKey(r.Context(), h.DB, h.Table, record, pk); err != nil {
+		h.fail(w, err)
+		return
+	}
+	h.respond(w, http.StatusOK, record)
+}
+
+// Create inserts a record from the JSON object in the request body, and
+// writes it back with its new primary key. Any primary key in the body is
+// ignored.
+func (h *Handler[T]) Create(w http.ResponseWriter, r *http.Request) {
+	record := new(T)
+	if err := h.decode(r, record); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.database().SetPrimaryKey(record, 0); err != nil {
+		h.fail(w, err)
+		return
+	}
+	if err := h.database().Insert(r.Context(), h.DB, h.Table, record); err != nil {
+		h.fail(w, err)
+		return
+	}
+	h.respond(w, http.StatusCreated, record)
+}
+
+// Update applies the JSON object in the request body to the record with
+// primary key pk and saves it. Columns missing from the body keep their
+// values. It writes back the updated record.
+func (h *Handler[T]) Update(w http.ResponseWriter, r *http.Request, pk string) {
+	d := h.database()
+	record := new(T)
+	if err := d.LoadKey(r.Context(), h.DB, h.Table, record, pk); err != nil {
+		h.fail(w, err)
+		return
+	}
+	_, key, err := d.PrimaryKey(record)
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	if err := h.decode(r, record); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := d.SetPrimaryKey(record, key); err != nil {
+		h.fail(w, err)
+		return
+	}
+	if err := d.Update(r.Context(), h.DB, h.Table, record); err != nil {
+		h.fail(w, err)
+		return
+	}
+	h.respond(w, http.StatusOK, record)
+}
+
+// Delete deletes the record with primary key pk.
+func (h *Handler[T]) Delete(w http.ResponseWriter, r *http.Request, pk string) {
+	d := h.database()
+	record := new(T)
+	if err := d.LoadKey(r.Context(), h.DB, h.Table, record, pk); err != nil {
+		h.fail(w, err)
+		return
+	}
+	if err := d.Delete(r.Context(), h.DB, h.Table, record); err != nil {
+		h.fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// encode writes record to buf as a JSON object keyed by column.
+func (h *Handler[T]) encode(buf *bytes.Buffer, record *T) error {
+	d := h.database()
+	columns, err := d.Columns(record, true)
+	if err != nil {
+		return err
+	}
+	fields, err := d.FieldsFor(record, columns)
+	if err != nil {
+		return err
+	}
+	buf.WriteByte('{')
+	for i, column := range columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(column)
+		if err != nil {
+			return err
+		}
+		value, err := json.Marshal(fields[i])
+		if err != nil {
+			return fmt.Errorf("column %s: %w", column, err)
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// decode applies the JSON object in the body of r to record.
+func (h *Handler[T]) decode(r *http.Request, record *T) error {
+	var object map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&object); err != nil {
+		return fmt.Errorf("invalid JSON: %v", err)
+	}
+	for column, raw := range object {
+		fields, err := h.database().FieldsFor(record, []string{column})
+		if err != nil {
+			return fmt.Errorf("unknown column %q", column)
+		}
+		if err := json.Unmarshal(raw, fields[0]); err != nil {
+			return fmt.Errorf("column %s: %v", column, err)
+		}
+	}
+	return nil
+}
+
+func (h *Handler[T]) respond(w http.ResponseWriter, status int, record *T) {
+	var buf bytes.Buffer
+	if err := h.encode(&buf, record); err != nil {
+		h.fail(w, err)
+		return
+	}
+	writeJSON(w, status, buf.Bytes())
+}
+
+// fail writes the response for an error from meddlerx. Missing rows are a
+// 404; anything else is a 500 that does not reveal the error.
+func (h *Handler[T]) fail(w http.ResponseWriter, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if logger := h.database().Logger; logger != nil {
+		logger.Printf("meddlerhttp: %s: %v", h.Table, err)
+	} else {
+		log.Printf("meddlerhttp: %s: %v", h.Table, err)
+	}
+	writeError(w, http.StatusInternalServerError, "internal error")
+}
+
+// validKey reports whether pk can be an integer primary key.
+func validKey(pk string) bool {
+	if _, err := strconv.ParseInt(pk, 10, 64); err == nil {
+		return true
+	}
+	_, err := strconv.ParseUint(pk, 10, 64)
+	return err == nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	body, _ := json.Marshal(map[string]string{"error": msg})
+	writeJSON(w, status, body)
+}
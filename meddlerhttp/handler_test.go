@@ -0,0 +1,71 @@
+package meddlerhttp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/scanfully/meddlerx"
+	"github.com/scanfully/meddlerx/meddlertest/sqlitetest"
+)
+
+type widget struct {
+	ID    int64    `meddler:"id,pk"`
+	Name  string   `meddler:"name"`
+	Price float64  `meddler:"price"`
+	Tags  []string `meddler:"tags,json"`
+}
+
+func TestHandler(t *testing.T) {
+	db := sqlitetest.NewDB(t, sqlitetest.Table{Name: "widget", Model: (*widget)(nil)})
+	h := New[widget](db, "widget")
+	h.Database = meddlerx.SQLite
+	server := httptest.NewServer(http.StripPrefix("/widgets", h))
+	defer server.Close()
+
+	do := func(method, path, body string) (int, string) {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+"/widgets"+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("NewRequest error: %v", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s error: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		out, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("reading response: %v", err)
+		}
+		return resp.StatusCode, strings.TrimSpace(string(out))
+	}
+
+	tests := []struct {
+		method, path, body string
+		status             int
+		expected           string
+	}{
+		{"POST", "/", `{"id":9,"name":"bolt","price":0.25,"tags":["small"]}`, 201, `{"id":1,"name":"bolt","price":0.25,"tags":["small"]}`},
+		{"POST", "/", `{"name":"nut","price":0.1}`, 201, `{"id":2,"name":"nut","price":0.1,"tags":null}`},
+		{"POST", "/", `{"colour":"red"}`, 400, `{"error":"unknown column \"colour\""}`},
+		{"GET", "/1", "", 200, `{"id":1,"name":"bolt","price":0.25,"tags":["small"]}`},
+		{"PUT", "/2", `{"price":0.15}`, 200, `{"id":2,"name":"nut","price":0.15,"tags":null}`},
+		{"GET", "/", "", 200, `[{"id":1,"name":"bolt","price":0.25,"tags":["small"]},{"id":2,"name":"nut","price":0.15,"tags":null}]`},
+		{"GET", "/?order=-price&limit=1", "", 200, `[{"id":1,"name":"bolt","price":0.25,"tags":["small"]}]`},
+		{"GET", "/?name=nut", "", 200, `[{"id":2,"name":"nut","price":0.15,"tags":null}]`},
+		{"GET", "/?order=weight", "", 400, `{"error":"unknown column \"weight\""}`},
+		{"DELETE", "/1", "", 204, ``},
+		{"GET", "/1", "", 404, `{"error":"not found"}`},
+		{"GET", "/bolt", "", 404, `{"error":"not found"}`},
+		{"PATCH", "/2", "", 405, `{"error":"method not allowed"}`},
+	}
+	for _, test := range tests {
+		status, body := do(test.method, test.path, test.body)
+		if status != test.status || body != test.expected {
+			t.Errorf("%s %s: expected %d %s, got %d %s", test.method, test.path, test.status, test.expected, status, body)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package meddlertest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+)
+
+// Rows is a canned result set returned by a RecordingQuerier or Mock.
+type Rows struct {
+	Columns []string
+	Values  [][]driver.Value
+}
+
+// NewRows returns an empty result set with the given columns.
+func NewRows(columns ...string) *Rows {
+	return &Rows{Columns: columns}
+}
+
+// AddRow appends a row of values to the result set. There must be one
+// value per column.
+func (r *Rows) AddRow(values ...driver.Value) *Rows {
+	r.Values = append(r.Values, values)
+	return r
+}
+
+// Result is a canned result for an Exec call.
+type Result struct {
+	LastInsertID int64
+	RowsAffected int64
+}
+
+// response is what the fake driver hands back for a single call.
+type response struct {
+	rows   *Rows
+	result Result
+	err    error
+}
+
+// cannedQuery is the query text sent to the fake driver. The real query is
+// recorded before the call reaches database/sql, and the response travels
+// as the only argument.
+const cannedQuery = "meddlertest"
+
+var (
+	fakeDB     *sql.DB
+	fakeDBOnce sync.Once
+)
+
+// serve runs a call through database/sql so that callers get real *sql.Rows
+// and *sql.Row values built from the canned response.
+func serve() *sql.DB {
+	fakeDBOnce.Do(func() {
+		fakeDB = sql.OpenDB(connector{})
+	})
+	return fakeDB
+}
+
+type connector struct{}
+
+func (connector) Connect(context.Context) (driver.Conn, error) { return conn{}, nil }
+func (connector) Driver() driver.Driver                        { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return conn{}, nil }
+
+type conn struct{}
+
+func (conn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("meddlertest: prepared statements are not supported")
+}
+func (conn) Close() error { return nil }
+func (conn) Begin() (driver.Tx, error) {
+	return nil, errors.New("meddlertest: transactions are not supported")
+}
+
+// CheckNamedValue accepts every argument as-is, so that the response can
+// be passed through database/sql untouched.
+func (conn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	resp := args[0].Value.(*response)
+	if resp.err != nil {
+		return nil, resp.err
+	}
+	if resp.rows == nil {
+		return &rows{}, nil
+	}
+	return &rows{canned: resp.rows}, nil
+}
+
+func (conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	resp := args[0].Value.(*response)
+	if resp.err != nil {
+		return nil, resp.err
+	}
+	return result{resp.result}, nil
+}
+
+type result struct {
+	canned Result
+}
+
+func (r result) LastInsertId() (int64, error) { return r.canned.LastInsertID, nil }
+func (r result) RowsAffected() (int64, error) { return r.canned.RowsAffected, nil }
+
+type rows struct {
+	canned *Rows
+	next   int
+}
+
+func (r *rows) Columns() []string {
+	if r.canned == nil {
+		return nil
+	}
+	return r.canned.Columns
+}
+
+func (r *rows) Close() error { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.canned == nil || r.next >= len(r.canned.Values) {
+		return io.EOF
+	}
+	copy(dest, r.canned.Values[r.next])
+	r.next++
+	return nil
+}
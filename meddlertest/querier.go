@@ -0,0 +1,226 @@
+// Package meddlertest provides test doubles for code that uses meddlerx.
+//
+// RecordingQuerier and Mock both implement meddlerx.Querier. They capture
+// the SQL and arguments that meddlerx generates and answer with canned
+// rows and results, so application code can be unit tested without a
+// real database.
+package meddlertest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sync"
+)
+
+// Call is a single query recorded by a RecordingQuerier or Mock.
+type Call struct {
+	Method string // "Query", "QueryRow", or "Exec"
+	Query  string
+	Args   []interface{}
+}
+
+// RecordingQuerier records every call made through it and answers with
+// canned responses, in the order they were queued. When no response is
+// queued, queries return an empty result set and execs a zero Result.
+type RecordingQuerier struct {
+	mu        sync.Mutex
+	calls     []Call
+	responses []*response
+}
+
+// NewRecordingQuerier returns a RecordingQuerier with no queued responses.
+func NewRecordingQuerier() *RecordingQuerier {
+	return new(RecordingQuerier)
+}
+
+// ReturnRows queues a result set for the next query.
+func (r *RecordingQuerier) ReturnRows(rows *Rows) *RecordingQuerier {
+	return r.queue(&response{rows: rows})
+}
+
+// ReturnResult queues a result for the next exec.
+func (r *RecordingQuerier) ReturnResult(res Result) *RecordingQuerier {
+	return r.queue(&response{result: res})
+}
+
+// ReturnError queues an error for the next call.
+func (r *RecordingQuerier) ReturnError(err error) *RecordingQuerier {
+	return r.queue(&response{err: err})
+}
+
+func (r *RecordingQuerier) queue(resp *response) *RecordingQuerier {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.responses = append(r.responses, resp)
+	return r
+}
+
+// Calls returns the calls recorded so far.
+func (r *RecordingQuerier) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// Reset forgets all recorded calls and queued responses.
+func (r *RecordingQuerier) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+	r.responses = nil
+}
+
+func (r *RecordingQuerier) record(method, query string, args []interface{}) *response {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Query: query, Args: args})
+	if len(r.responses) == 0 {
+		return &response{}
+	}
+	resp := r.responses[0]
+	r.responses = r.responses[1:]
+	return resp
+}
+
+// QueryContext implements meddlerx.Querier
+func (r *RecordingQuerier) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return serve().QueryContext(ctx, cannedQuery, r.record("Query", query, args))
+}
+
+// QueryRowContext implements meddlerx.Querier
+func (r *RecordingQuerier) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return serve().QueryRowContext(ctx, cannedQuery, r.record("QueryRow", query, args))
+}
+
+// ExecContext implements meddlerx.Querier
+func (r *RecordingQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return serve().ExecContext(ctx, cannedQuery, r.record("Exec", query, args))
+}
+
+// Expectation is a single expected call on a Mock.
+type Expectation struct {
+	method  string
+	pattern *regexp.Regexp
+	args    []interface{}
+	checked bool
+	resp    response
+}
+
+// WithArgs requires the call to have exactly these arguments.
+func (e *Expectation) WithArgs(args ...interface{}) *Expectation {
+	e.args = args
+	e.checked = true
+	return e
+}
+
+// WillReturnRows sets the result set returned for the expected query.
+func (e *Expectation) WillReturnRows(rows *Rows) *Expectation {
+	e.resp.rows = rows
+	return e
+}
+
+// WillReturnResult sets the result returned for the expected exec.
+func (e *Expectation) WillReturnResult(res Result) *Expectation {
+	e.resp.result = res
+	return e
+}
+
+// WillReturnError makes the expected call fail with err.
+func (e *Expectation) WillReturnError(err error) *Expectation {
+	e.resp.err = err
+	return e
+}
+
+// Mock is a Querier that checks calls against a list of expectations, in
+// order. A call that does not match the next expectation fails with an
+// error describing the mismatch, and the mismatch is also reported by
+// ExpectationsWereMet.
+type Mock struct {
+	mu       sync.Mutex
+	expected []*Expectation
+	failures []string
+}
+
+// NewMock returns a Mock with no expectations.
+func NewMock() *Mock {
+	return new(Mock)
+}
+
+// ExpectQuery expects a query (through QueryContext or QueryRowContext)
+// whose SQL matches the regular expression pattern.
+func (m *Mock) ExpectQuery(pattern string) *Expectation {
+	return m.expect("Query", pattern)
+}
+
+// ExpectExec expects an exec whose SQL matches the regular expression pattern.
+func (m *Mock) ExpectExec(pattern string) *Expectation {
+	return m.expect("Exec", pattern)
+}
+
+func (m *Mock) expect(method, pattern string) *Expectation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := &Expectation{method: method, pattern: regexp.MustCompile(pattern)}
+	m.expected = append(m.expected, e)
+	return e
+}
+
+// ExpectationsWereMet returns an error if any expectation was not
+// consumed or any call did not match.
+func (m *Mock) ExpectationsWereMet() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.failures) > 0 {
+		return fmt.Errorf("meddlertest: %s", m.failures[0])
+	}
+	if len(m.expected) > 0 {
+		e := m.expected[0]
+		return fmt.Errorf("meddlertest: expected %s matching %q was not called", e.method, e.pattern)
+	}
+	return nil
+}
+
+func (m *Mock) match(method, query string, args []interface{}) *response {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fail := func(format string, a ...interface{}) *response {
+		msg := fmt.Sprintf(format, a...)
+		m.failures = append(m.failures, msg)
+		return &response{err: fmt.Errorf("meddlertest: %s", msg)}
+	}
+
+	if len(m.expected) == 0 {
+		return fail("unexpected %s: %s", method, query)
+	}
+	e := m.expected[0]
+	if e.method != method {
+		return fail("expected %s matching %q, got %s: %s", e.method, e.pattern, method, query)
+	}
+	if !e.pattern.MatchString(query) {
+		return fail("query %q does not match %q", query, e.pattern)
+	}
+	if e.checked && (len(e.args) != len(args) || len(args) > 0 && !reflect.DeepEqual(e.args, args)) {
+		return fail("query %q called with args %v, expected %v", query, args, e.args)
+	}
+	m.expected = m.expected[1:]
+	return &e.resp
+}
+
+// QueryContext implements meddlerx.Querier
+func (m *Mock) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return serve().QueryContext(ctx, cannedQuery, m.match("Query", query, args))
+}
+
+// QueryRowContext implements meddlerx.Querier
+func (m *Mock) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return serve().QueryRowContext(ctx, cannedQuery, m.match("Query", query, args))
+}
+
+// ExecContext implements meddlerx.Querier
+func (m *Mock) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return serve().ExecContext(ctx, cannedQuery, m.match("Exec", query, args))
+}
@@ -0,0 +1,84 @@
+package meddlertest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/scanfully/meddlerx"
+)
+
+type person struct {
+	ID   int64  `meddler:"id,pk"`
+	Name string `meddler:"name"`
+	Age  int    `meddler:"age"`
+}
+
+var ctx = context.Background()
+
+func TestRecordingQuerier(t *testing.T) {
+	rec := NewRecordingQuerier()
+	rec.ReturnResult(Result{LastInsertID: 42, RowsAffected: 1})
+	rec.ReturnRows(NewRows("id", "name", "age").AddRow(int64(42), "Alice", int64(30)))
+
+	p := &person{Name: "Alice", Age: 30}
+	if err := meddlerx.SQLite.Insert(ctx, rec, "person", p); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	if p.ID != 42 {
+		t.Errorf("Insert: expected pk 42 from canned result, got %d", p.ID)
+	}
+
+	loaded := new(person)
+	if err := meddlerx.SQLite.Load(ctx, rec, "person", loaded, 42); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if *loaded != *p {
+		t.Errorf("Load: expected %+v, got %+v", p, loaded)
+	}
+
+	calls := rec.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 recorded calls, got %d", len(calls))
+	}
+	if calls[0].Method != "Exec" || calls[0].Query != `INSERT INTO "person" ("name","age") VALUES (?,?)` {
+		t.Errorf("unexpected insert call: %+v", calls[0])
+	}
+	if len(calls[0].Args) != 2 || calls[0].Args[0] != "Alice" || calls[0].Args[1] != 30 {
+		t.Errorf("unexpected insert args: %v", calls[0].Args)
+	}
+	if calls[1].Method != "Query" || !strings.HasPrefix(calls[1].Query, "SELECT") {
+		t.Errorf("unexpected load call: %+v", calls[1])
+	}
+
+	// an empty queue yields no rows
+	if err := meddlerx.SQLite.Load(ctx, rec, "person", loaded, 42); err == nil {
+		t.Errorf("Load without canned rows: expected sql.ErrNoRows, got nil")
+	}
+}
+
+func TestMock(t *testing.T) {
+	mock := NewMock()
+	mock.ExpectExec(`^UPDATE "person" SET`).WithArgs("Bob", 31, int64(7)).WillReturnResult(Result{RowsAffected: 1})
+	failure := errors.New("boom")
+	mock.ExpectQuery(`FROM "person"`).WillReturnError(failure)
+
+	if err := meddlerx.SQLite.Update(ctx, mock, "person", &person{ID: 7, Name: "Bob", Age: 31}); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	if err := meddlerx.SQLite.Load(ctx, mock, "person", new(person), 7); !errors.Is(err, failure) {
+		t.Errorf("Load: expected canned error, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("ExpectationsWereMet: %v", err)
+	}
+
+	// unexpected calls fail and are reported
+	if err := meddlerx.SQLite.Update(ctx, mock, "person", &person{ID: 7}); err == nil {
+		t.Errorf("unexpected Update: expected err, got nil")
+	}
+	if err := mock.ExpectationsWereMet(); err == nil {
+		t.Errorf("ExpectationsWereMet after unexpected call: expected err, got nil")
+	}
+}
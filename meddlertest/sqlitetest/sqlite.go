@@ -0,0 +1,58 @@
+// Package sqlitetest opens throwaway SQLite databases for tests of code
+// that uses meddlerx. It is kept apart from meddlertest because it needs
+// the go-sqlite3 driver, and so cgo.
+package sqlitetest
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/scanfully/meddlerx"
+)
+
+// Table pairs a table name with the struct used to create it, for tables
+// whose name is not derived from the struct type name. Model is a pointer
+// to the struct, e.g. Table{"people", (*Person)(nil)}.
+type Table struct {
+	Name  string
+	Model interface{}
+}
+
+// NewDB opens a fresh in-memory SQLite database for the duration of a test
+// and creates a table for each of the given structs, using
+// meddlerx.SQLite.EnsureTable. Each struct is given as a pointer, and its
+// table is named after the struct type in snake_case (so *OrderLine maps
+// to order_line); pass a Table to choose the name explicitly.
+//
+// The database is closed when the test finishes. It is limited to a single
+// connection so that every query sees the same in-memory database, which
+// means a result set must be closed before the next query is run.
+func NewDB(t testing.TB, structs ...interface{}) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("sqlitetest.NewDB: opening database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	for _, elt := range structs {
+		table, ok := elt.(Table)
+		if !ok {
+			typ := reflect.TypeOf(elt)
+			if typ == nil || typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
+				t.Fatalf("sqlitetest.NewDB: expected a pointer to a struct or a Table, found %T", elt)
+			}
+			table = Table{Name: meddlerx.SnakeCase(typ.Elem().Name()), Model: elt}
+		}
+		if err := meddlerx.SQLite.EnsureTable(context.Background(), db, table.Name, table.Model); err != nil {
+			t.Fatalf("sqlitetest.NewDB: creating table %s: %v", table.Name, err)
+		}
+	}
+
+	return db
+}
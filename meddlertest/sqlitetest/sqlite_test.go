@@ -0,0 +1,70 @@
+package sqlitetest
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/scanfully/meddlerx"
+)
+
+type person struct {
+	ID   int64  `meddler:"id,pk"`
+	Name string `meddler:"name"`
+	Age  int    `meddler:"age"`
+}
+
+type orderLine struct {
+	ID       int64  `meddler:"id,pk"`
+	Product  string `meddler:"product"`
+	Quantity int    `meddler:"quantity"`
+}
+
+var ctx = context.Background()
+
+func TestNewDB(t *testing.T) {
+	db := NewDB(t, (*orderLine)(nil), Table{"people", (*person)(nil)})
+
+	line := &orderLine{Product: "widget", Quantity: 3}
+	if err := meddlerx.SQLite.Insert(ctx, db, "order_line", line); err != nil {
+		t.Fatalf("Insert into order_line: %v", err)
+	}
+	loaded := new(orderLine)
+	if err := meddlerx.SQLite.Load(ctx, db, "order_line", loaded, line.ID); err != nil {
+		t.Fatalf("Load from order_line: %v", err)
+	}
+	if *loaded != *line {
+		t.Errorf("Load: expected %+v, got %+v", line, loaded)
+	}
+
+	if err := meddlerx.SQLite.Insert(ctx, db, "people", &person{Name: "Alice", Age: 30}); err != nil {
+		t.Errorf("Insert into people: %v", err)
+	}
+}
+
+// fatalRecorder records the message passed to Fatalf and stops the
+// goroutine, as testing.T does, without failing the real test.
+type fatalRecorder struct {
+	testing.TB
+	msg string
+}
+
+func (f *fatalRecorder) Fatalf(format string, args ...interface{}) {
+	f.msg = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+func TestNewDBNotPointer(t *testing.T) {
+	rec := &fatalRecorder{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		NewDB(rec, orderLine{})
+	}()
+	<-done
+	if !strings.Contains(rec.msg, "expected a pointer to a struct") {
+		t.Errorf("NewDB with a struct value: expected a fatal error, got %q", rec.msg)
+	}
+}
@@ -0,0 +1,36 @@
+package meddlervet
+
+import (
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Analyzer reports the problems Check finds in the struct tags of a
+// package. It can be run by any driver of golang.org/x/tools/go/analysis
+// analyzers, such as gopls or a multichecker; the meddlervet command runs
+// it under go vet. Its meddlers flag lists meddler names that the program
+// registers itself, comma-separated.
+var Analyzer = &analysis.Analyzer{
+	Name: "meddlervet",
+	Doc:  "check meddler struct tags\n\nReports duplicate columns, more than one pk field, unknown meddlers and options, and tags on unexported fields.",
+	Run:  run,
+}
+
+// meddlers holds the value of Analyzer's meddlers flag.
+var meddlers string
+
+func init() {
+	Analyzer.Flags.StringVar(&meddlers, "meddlers", "", "comma-separated names of meddlers registered by the program")
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	c := &Checker{}
+	if meddlers != "" {
+		c.Meddlers = strings.Split(meddlers, ",")
+	}
+	for _, d := range c.Check(pass.Files) {
+		pass.Report(analysis.Diagnostic{Pos: d.Pos, Message: d.Message})
+	}
+	return nil, nil
+}
@@ -0,0 +1,48 @@
+package meddlervet
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+func TestAnalyzer(t *testing.T) {
+	if err := analysis.Validate([]*analysis.Analyzer{Analyzer}); err != nil {
+		t.Fatalf("Validate error: %v", err)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "models.go", source, 0)
+	if err != nil {
+		t.Fatalf("parsing: %v", err)
+	}
+	if err := Analyzer.Flags.Set("meddlers", "money"); err != nil {
+		t.Fatalf("setting meddlers: %v", err)
+	}
+	defer Analyzer.Flags.Set("meddlers", "")
+
+	var diags []analysis.Diagnostic
+	pass := &analysis.Pass{
+		Analyzer: Analyzer,
+		Fset:     fset,
+		Files:    []*ast.File{file},
+		Report:   func(d analysis.Diagnostic) { diags = append(diags, d) },
+	}
+	if _, err := Analyzer.Run(pass); err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+
+	// the same problems as Check, with the registered meddler accepted
+	expected := (&Checker{Meddlers: []string{"money"}}).Check([]*ast.File{file})
+	if len(diags) != len(expected) {
+		t.Fatalf("expected %d diagnostics, got %d: %v", len(expected), len(diags), diags)
+	}
+	for i, d := range diags {
+		if d.Pos != expected[i].Pos || d.Message != expected[i].Message {
+			t.Errorf("diagnostic %d: expected %v, got %v", i, expected[i], d)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// Package meddlervet checks meddler struct tags without running the
+// program, so that mistakes which meddlerx would otherwise only report at
+// run time, or silently ignore, are caught at build time. It reports:
+//
+//   - two fields of a struct mapped to the same column
+//   - more than one field marked pk
+//   - meddler names that are not registered and unknown options
+//   - unexported fields with a meddler tag, which meddlerx skips
+//
+// Column names of untagged fields are assumed to come from the default
+// Mapper. Run checks by hand with Check, run Analyzer from any go/analysis
+// driver, or use the meddlervet command as a go vet tool:
+//
+//	go vet -vettool=$(which meddlervet) ./...
+package meddlervet
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/scanfully/meddlerx"
+)
+
+// Diagnostic is a problem found in a struct tag.
+type Diagnostic struct {
+	Pos     token.Pos
+	Message string
+}
+
+// Checker validates the meddler tags of struct types.
+type Checker struct {
+	// Meddlers lists meddler names that the program registers itself, in
+	// addition to those meddlerx provides.
+	Meddlers []string
+}
+
+// Check returns the problems found in the struct types of files, in
+// source order. Structs without any meddler tags are not examined.
+func (c *Checker) Check(files []*ast.File) []Diagnostic {
+	var diags []Diagnostic
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			if st, ok := n.(*ast.StructType); ok {
+				diags = append(diags, c.checkStruct(st)...)
+			}
+			return true
+		})
+	}
+	sort.SliceStable(diags, func(i, j int) bool { return diags[i].Pos < diags[j].Pos })
+	return diags
+}
+
+// checkStruct checks the fields of one struct type.
+func (c *Checker) checkStruct(st *ast.StructType) []Diagnostic {
+	if !hasTags(st) {
+		return nil
+	}
+
+	var diags []Diagnostic
+	report := func(pos token.Pos, format string, args ...interface{}) {
+		diags = append(diags, Diagnostic{Pos: pos, Message: fmt.Sprintf(format, args...)})
+	}
+	columns := make(map[string]string) // column to field name
+	pk := ""
+	for _, field := range st.Fields.List {
+		tag, tagged := meddlerTag(field)
+		for _, name := range fieldNames(field) {
+			if !ast.IsExported(name) {
+				if tagged {
+					report(field.Pos(), "field %s has a meddler tag but is unexported, so it is not mapped", name)
+				}
+				continue
+			}
+
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				c.checkRelationOptions(field, name, parts[1:], report)
+				continue
+			}
+			column := parts[0]
+			if column == "" {
+				column = meddlerx.Mapper(name)
+			}
+
+			nested := false
+			for _, opt := range parts[1:] {
+				key, value, hasValue := strings.Cut(opt, "=")
+				switch {
+				case opt == "pk":
+					if pk != "" {
+						report(field.Pos(), "field %s is marked pk, but field %s already is", name, pk)
+					} else {
+						pk = name
+					}
+				case opt == "generated":
+				case hasValue && key == "prefix":
+					nested = true
+				case hasValue && key == "default":
+				case hasValue && key == "comment":
+				case hasValue && key == "index":
+					if value == "" || value == "unique:" {
+						report(field.Pos(), "field %s has an empty index name", name)
+					}
+				case hasValue && (key == "hasmany" || key == "belongsto"):
+					report(field.Pos(), "field %s has option %s, which is only allowed on fields tagged \"-\"", name, key)
+				case hasValue:
+					report(field.Pos(), "field %s has unknown option %s", name, key)
+				case !c.registered(opt):
+					report(field.Pos(), "field %s uses meddler %s, which is not registered", name, opt)
+				}
+			}
+
+			// the columns of a nested struct depend on its own fields
+			if nested {
+				continue
+			}
+			if other, present := columns[column]; present {
+				report(field.Pos(), "field %s is mapped to column %s, which field %s already uses", name, column, other)
+			} else {
+				columns[column] = name
+			}
+		}
+	}
+	return diags
+}
+
+// checkRelationOptions checks the options of a field tagged "-", which may
+// only declare a relation for Preload.
+func (c *Checker) checkRelationOptions(field *ast.Field, name string, opts []string, report func(token.Pos, string, ...interface{})) {
+	relations := 0
+	for _, opt := range opts {
+		key, value, _ := strings.Cut(opt, "=")
+		if key != "hasmany" && key != "belongsto" {
+			report(field.Pos(), "field %s is tagged \"-\" with unknown option %s", name, opt)
+			continue
+		}
+		if relations++; relations == 2 {
+			report(field.Pos(), "field %s has more than one relation", name)
+		}
+		if dot := strings.LastIndex(value, "."); dot <= 0 || dot == len(value)-1 {
+			report(field.Pos(), "field %s has %s=%s, expected table.column", name, key, value)
+		}
+	}
+}
+
+// registered reports whether name is a known meddler.
+func (c *Checker) registered(name string) bool {
+	if meddlerx.Registered(name) {
+		return true
+	}
+	for _, m := range c.Meddlers {
+		if m == name {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTags reports whether any field of st has a meddler tag.
+func hasTags(st *ast.StructType) bool {
+	for _, field := range st.Fields.List {
+		if _, tagged := meddlerTag(field); tagged {
+			return true
+		}
+	}
+	return false
+}
+
+// meddlerTag returns the meddler tag of field, if it has one.
+func meddlerTag(field *ast.Field) (string, bool) {
+	if field.Tag == nil {
+		return "", false
+	}
+	raw, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return "", false
+	}
+	return reflect.StructTag(raw).Lookup("meddler")
+}
+
+// fieldNames returns the names declared by field; an embedded field is
+// named after its type.
+func fieldNames(field *ast.Field) []string {
+	if len(field.Names) > 0 {
+		names := make([]string, len(field.Names))
+		for i, ident := range field.Names {
+			names[i] = ident.Name
+		}
+		return names
+	}
+	t := field.Type
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+	switch t := t.(type) {
+	case *ast.Ident:
+		return []string{t.Name}
+	case *ast.SelectorExpr:
+		return []string{t.Sel.Name}
+	}
+	return nil
+}
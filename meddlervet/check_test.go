@@ -0,0 +1,69 @@
+package meddlervet
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+const source = "package models\n" + `
+type Person struct {
+	ID      int64     ` + "`meddler:\"id,pk\"`" + `
+	Other   int64     ` + "`meddler:\"other,pk\"`" + `
+	Name    string    ` + "`meddler:\"name,jsn\"`" + `
+	Nick    string    ` + "`meddler:\"name\"`" + `
+	age     int       ` + "`meddler:\"age\"`" + `
+	Kids    []*Person ` + "`meddler:\"-,hasmny=person.parent\"`" + `
+	Parent  *Person   ` + "`meddler:\"-,belongsto=person\"`" + `
+	Created string    ` + "`meddler:\"created,default=now,colour=red\"`" + `
+	Address Address   ` + "`meddler:\"address,prefix=addr_\"`" + `
+	Orders  []int     ` + "`meddler:\"orders,hasmany=orders.person_id\"`" + `
+	Email   string
+	Mail    string    ` + "`meddler:\"Email,money\"`" + `
+}
+
+type Address struct {
+	Street string
+	City   string
+}
+
+type Good struct {
+	ID      int64     ` + "`meddler:\"id,pk\"`" + `
+	Tags    []string  ` + "`meddler:\"tags,json\"`" + `
+	Email   string    ` + "`meddler:\"email,comment=login name,index=unique\"`" + `
+	Opened  string    ` + "`meddler:\"opened,utctimez,generated\"`" + `
+	Friends []*Good   ` + "`meddler:\"-,hasmany=good.friend_id\"`" + `
+	private int
+}
+`
+
+func TestCheck(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "models.go", source, 0)
+	if err != nil {
+		t.Fatalf("parsing: %v", err)
+	}
+
+	c := &Checker{Meddlers: []string{"money"}}
+	var got []string
+	for _, d := range c.Check([]*ast.File{file}) {
+		got = append(got, fmt.Sprintf("%d: %s", fset.Position(d.Pos).Line, d.Message))
+	}
+	expected := []string{
+		`5: field Other is marked pk, but field ID already is`,
+		`6: field Name uses meddler jsn, which is not registered`,
+		`7: field Nick is mapped to column name, which field Name already uses`,
+		`8: field age has a meddler tag but is unexported, so it is not mapped`,
+		`9: field Kids is tagged "-" with unknown option hasmny=person.parent`,
+		`10: field Parent has belongsto=person, expected table.column`,
+		`11: field Created has unknown option colour`,
+		`13: field Orders has option hasmany, which is only allowed on fields tagged "-"`,
+		`15: field Mail is mapped to column Email, which field Email already uses`,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected:\n%q\ngot:\n%q", expected, got)
+	}
+}
@@ -0,0 +1,99 @@
+package meddlervet
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+// Main runs the meddlervet command. Run by go vet -vettool, it hands over
+// to unitchecker, which runs Analyzer on each package go vet describes;
+// the meddlers flag is then spelled -meddlervet.meddlers. Run by hand, it
+// checks the Go files in each directory named on the command line. It
+// exits with status 1 if it finds problems.
+func Main() {
+	if vetMode(os.Args[1:]) {
+		unitchecker.Main(Analyzer)
+		return
+	}
+
+	progname := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	flag.StringVar(&meddlers, "meddlers", "", "comma-separated names of meddlers registered by the program")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [-meddlers=a,b] dir...\n   or: go vet -vettool=$(which %s) [-meddlervet.meddlers=a,b] packages\n", progname, progname)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	c := &Checker{}
+	if meddlers != "" {
+		c.Meddlers = strings.Split(meddlers, ",")
+	}
+	found, err := runDirs(c, flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", progname, err)
+		os.Exit(2)
+	}
+	if found {
+		os.Exit(1)
+	}
+}
+
+// vetMode reports whether args are those go vet passes to a vet tool: a
+// package configuration file, or a request for its flags or version.
+func vetMode(args []string) bool {
+	for _, arg := range args {
+		if arg == "-flags" || strings.HasPrefix(arg, "-V=") || strings.HasSuffix(arg, ".cfg") {
+			return true
+		}
+	}
+	return false
+}
+
+// runDirs checks the Go files, including tests, in each of dirs.
+func runDirs(c *Checker, dirs []string) (bool, error) {
+	found := false
+	for _, dir := range dirs {
+		paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil {
+			return found, err
+		}
+		dirFound, err := checkFiles(c, os.Stdout, paths)
+		if err != nil {
+			return found, err
+		}
+		found = found || dirFound
+	}
+	return found, nil
+}
+
+// checkFiles parses and checks the files at paths, writing what it finds
+// to w, and reports whether it found anything.
+func checkFiles(c *Checker, w io.Writer, paths []string) (bool, error) {
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, path := range paths {
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return false, err
+		}
+		files = append(files, file)
+	}
+	diags := c.Check(files)
+	for _, d := range diags {
+		fmt.Fprintf(w, "%s: %s\n", fset.Position(d.Pos), d.Message)
+	}
+	return len(diags) > 0, nil
+}
@@ -0,0 +1,187 @@
+package meddlerx
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"net/netip"
+	"reflect"
+	"strings"
+)
+
+// NetMeddler converts network address fields to and from PostgreSQL inet,
+// cidr, and macaddr columns, or text columns in other databases. Its value
+// names the PostgreSQL column type. Fields may be net.IP, netip.Addr,
+// netip.Prefix, net.IPNet, or net.HardwareAddr, or pointers to them; nil
+// and invalid zero values are stored as NULL.
+//
+// Addresses read from an inet column that carry a netmask keep only the
+// address when scanned into net.IP or netip.Addr, while a bare address
+// scanned into netip.Prefix or net.IPNet becomes a single-host prefix.
+type NetMeddler string
+
+var (
+	netIPType   = reflect.TypeOf(net.IP(nil))
+	netIPNetTyp = reflect.TypeOf(net.IPNet{})
+	macType     = reflect.TypeOf(net.HardwareAddr(nil))
+	addrType    = reflect.TypeOf(netip.Addr{})
+	prefixType  = reflect.TypeOf(netip.Prefix{})
+)
+
+// PreRead is called before a Scan operation for fields that have a NetMeddler
+func (kind NetMeddler) PreRead(fieldAddr interface{}) (scanTarget interface{}, err error) {
+	return new(sql.NullString), nil
+}
+
+// PostRead is called after a Scan operation for fields that have a NetMeddler
+func (kind NetMeddler) PostRead(fieldAddr, scanTarget interface{}) error {
+	ptr := scanTarget.(*sql.NullString)
+	if ptr == nil {
+		return fmt.Errorf("NetMeddler.PostRead: nil pointer")
+	}
+
+	fieldVal := reflect.ValueOf(fieldAddr).Elem()
+	if !ptr.Valid {
+		fieldVal.Set(reflect.Zero(fieldVal.Type()))
+		return nil
+	}
+
+	baseType := fieldVal.Type()
+	if baseType.Kind() == reflect.Ptr {
+		baseType = baseType.Elem()
+	}
+	val, err := parseNetValue(ptr.String, baseType)
+	if err != nil {
+		return fmt.Errorf("NetMeddler.PostRead: %w", err)
+	}
+	if fieldVal.Kind() == reflect.Ptr {
+		p := reflect.New(baseType)
+		p.Elem().Set(val)
+		val = p
+	}
+	fieldVal.Set(val)
+	return nil
+}
+
+// PreWrite is called before an Insert or Update operation for fields that have a NetMeddler
+func (kind NetMeddler) PreWrite(field interface{}) (saveValue interface{}, err error) {
+	v := reflect.ValueOf(field)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	switch value := v.Interface().(type) {
+	case net.IP:
+		if value == nil {
+			return nil, nil
+		}
+		return value.String(), nil
+	case netip.Addr:
+		if !value.IsValid() {
+			return nil, nil
+		}
+		return value.String(), nil
+	case netip.Prefix:
+		if !value.IsValid() {
+			return nil, nil
+		}
+		return value.String(), nil
+	case net.IPNet:
+		if value.IP == nil {
+			return nil, nil
+		}
+		return value.String(), nil
+	case net.HardwareAddr:
+		if value == nil {
+			return nil, nil
+		}
+		return value.String(), nil
+	}
+	return nil, fmt.Errorf("NetMeddler.PreWrite: unsupported field type %T", field)
+}
+
+// ColumnType implements ColumnTyper for fields with a NetMeddler
+func (kind NetMeddler) ColumnType(d *Database, fieldType reflect.Type) (string, bool, error) {
+	if d.Dialect == DialectPostgreSQL {
+		return strings.ToUpper(string(kind)), true, nil
+	}
+	if d.Dialect == DialectMySQL {
+		// long enough for an IPv6 prefix
+		return "VARCHAR(64)", true, nil
+	}
+	return "TEXT", true, nil
+}
+
+// parseNetValue parses the text form of a network address into a value of
+// type t.
+func parseNetValue(s string, t reflect.Type) (reflect.Value, error) {
+	switch t {
+	case netIPType:
+		if ip, _, err := net.ParseCIDR(s); err == nil {
+			return reflect.ValueOf(ip), nil
+		}
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return reflect.Value{}, fmt.Errorf("invalid IP address %q", s)
+		}
+		return reflect.ValueOf(ip), nil
+	case addrType:
+		if strings.Contains(s, "/") {
+			prefix, err := netip.ParsePrefix(s)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			return reflect.ValueOf(prefix.Addr()), nil
+		}
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(addr), nil
+	case prefixType:
+		if !strings.Contains(s, "/") {
+			addr, err := netip.ParseAddr(s)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			return reflect.ValueOf(netip.PrefixFrom(addr, addr.BitLen())), nil
+		}
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(prefix), nil
+	case netIPNetTyp:
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return reflect.Value{}, fmt.Errorf("invalid IP address %q", s)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			return reflect.ValueOf(net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}), nil
+		}
+		ip, ipnet, err := net.ParseCIDR(s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		// keep the host bits, as inet does
+		ipnet.IP = ip
+		if ip4 := ip.To4(); ip4 != nil {
+			ipnet.IP = ip4
+		}
+		return reflect.ValueOf(*ipnet), nil
+	case macType:
+		mac, err := net.ParseMAC(s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(mac), nil
+	}
+	return reflect.Value{}, fmt.Errorf("unsupported field type %v", t)
+}
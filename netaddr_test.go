@@ -0,0 +1,77 @@
+package meddlerx
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+type host struct {
+	ID      int64            `meddler:"id,pk"`
+	IP      net.IP           `meddler:"ip,inet"`
+	Addr    netip.Addr       `meddler:"addr,inet"`
+	Subnet  netip.Prefix     `meddler:"subnet,cidr"`
+	Network *net.IPNet       `meddler:"network,cidr"`
+	MAC     net.HardwareAddr `meddler:"mac,macaddr"`
+	Gateway *netip.Addr      `meddler:"gateway,inet"`
+}
+
+func TestNetMeddler(t *testing.T) {
+	once.Do(setup)
+	if err := SQLite.EnsureTable(testCtx, db, "host", new(host)); err != nil {
+		t.Fatalf("EnsureTable error: %v", err)
+	}
+	defer db.Exec("drop table host")
+
+	_, network, _ := net.ParseCIDR("10.0.0.0/8")
+	mac, _ := net.ParseMAC("08:00:2b:01:02:03")
+	elt := &host{
+		IP:      net.ParseIP("192.168.1.10"),
+		Addr:    netip.MustParseAddr("2001:db8::1"),
+		Subnet:  netip.MustParsePrefix("192.168.1.0/24"),
+		Network: network,
+		MAC:     mac,
+	}
+	if err := SQLite.Insert(testCtx, db, "host", elt); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+
+	loaded := new(host)
+	if err := SQLite.Load(testCtx, db, "host", loaded, elt.ID); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if !loaded.IP.Equal(elt.IP) || loaded.Addr != elt.Addr || loaded.Subnet != elt.Subnet {
+		t.Errorf("NetMeddler round trip: got %v %v %v", loaded.IP, loaded.Addr, loaded.Subnet)
+	}
+	if loaded.Network == nil || loaded.Network.String() != "10.0.0.0/8" {
+		t.Errorf("NetMeddler IPNet round trip: got %v", loaded.Network)
+	}
+	if loaded.MAC.String() != mac.String() || loaded.Gateway != nil {
+		t.Errorf("NetMeddler round trip: got %v %v", loaded.MAC, loaded.Gateway)
+	}
+
+	// inet text with a netmask, as PostgreSQL returns it
+	if _, err := db.Exec("update host set addr = '10.1.2.3/16', subnet = '10.1.2.3', gateway = '10.1.0.1' where id = ?", elt.ID); err != nil {
+		t.Fatalf("DB error: %v", err)
+	}
+	if err := SQLite.Load(testCtx, db, "host", loaded, elt.ID); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if loaded.Addr.String() != "10.1.2.3" || loaded.Subnet.String() != "10.1.2.3/32" {
+		t.Errorf("NetMeddler inet parsing: got %v %v", loaded.Addr, loaded.Subnet)
+	}
+	if loaded.Gateway == nil || loaded.Gateway.String() != "10.1.0.1" {
+		t.Errorf("NetMeddler pointer field: got %v", loaded.Gateway)
+	}
+
+	q, err := PostgreSQL.CreateTableSQL("host", new(host))
+	if err != nil {
+		t.Fatalf("CreateTableSQL error: %v", err)
+	}
+	for _, want := range []string{`"ip" INET`, `"subnet" CIDR`, `"mac" MACADDR`} {
+		if !strings.Contains(q, want) {
+			t.Errorf("CreateTableSQL: expected %s in\n%s", want, q)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package meddlerx
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+// WriteOption changes how Insert, Update, and Save write a record.
+type WriteOption interface {
+	applyWrite(o *writeOptions)
+}
+
+// writeOptions is the combined effect of a list of WriteOptions.
+type writeOptions struct {
+	masked          bool
+	fields          []string
+	rowsAffected    *int64
+	mustAffect      bool
+	continueOnError bool
+}
+
+func newWriteOptions(opts []WriteOption) *writeOptions {
+	o := new(writeOptions)
+	for _, opt := range opts {
+		opt.applyWrite(o)
+	}
+	return o
+}
+
+// FieldMask limits a write to the named fields. Names may be column names
+// or Go field names. The primary key is handled as usual whether or not it
+// is listed, so a masked Update still finds the row by its key. Giving
+// several masks writes the union of their fields.
+type FieldMask []string
+
+// Fields returns a FieldMask for the named fields, e.g.
+//
+//	err := meddlerx.Update(ctx, db, "person", p, meddlerx.Fields("name", "email"))
+func Fields(names ...string) FieldMask {
+	return FieldMask(names)
+}
+
+func (m FieldMask) applyWrite(o *writeOptions) {
+	o.masked = true
+	o.fields = append(o.fields, m...)
+}
+
+// RowsAffected stores the number of rows matched by an Update or Delete in
+// n. Other operations ignore it.
+func RowsAffected(n *int64) WriteOption {
+	return rowsAffectedOption{n}
+}
+
+type rowsAffectedOption struct {
+	n *int64
+}
+
+func (opt rowsAffectedOption) applyWrite(o *writeOptions) {
+	o.rowsAffected = opt.n
+}
+
+// MustAffect makes an Update or Delete that matches no rows fail with an
+// error wrapping sql.ErrNoRows. Other operations ignore it.
+func MustAffect() WriteOption {
+	return mustAffectOption{}
+}
+
+type mustAffectOption struct{}
+
+func (mustAffectOption) applyWrite(o *writeOptions) {
+	o.mustAffect = true
+}
+
+// ContinueOnError makes SaveAll and InsertAll carry on past elements that
+// fail, saving all the others, instead of stopping and rolling back at the
+// first error. Each element is written under its own savepoint, so a
+// failure does not spoil the rest of the transaction. Other operations
+// ignore it.
+func ContinueOnError() WriteOption {
+	return continueOnErrorOption{}
+}
+
+type continueOnErrorOption struct{}
+
+func (continueOnErrorOption) applyWrite(o *writeOptions) {
+	o.continueOnError = true
+}
+
+// checkResult applies the RowsAffected and MustAffect options to the result
+// of an Update or Delete.
+func (o *writeOptions) checkResult(op string, result sql.Result) error {
+	if o.rowsAffected == nil && !o.mustAffect {
+		return nil
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("meddler.%s: %w", op, err)
+	}
+	if o.rowsAffected != nil {
+		*o.rowsAffected = n
+	}
+	if o.mustAffect && n == 0 {
+		return fmt.Errorf("meddler.%s: %w", op, sql.ErrNoRows)
+	}
+	return nil
+}
+
+// writeColumns returns the columns of src to write, in struct order. If
+// includePk is false, the primary key column is omitted. Generated columns
+// are never written.
+func (d *Database) writeColumns(src interface{}, includePk bool, o *writeOptions) ([]string, error) {
+	data, err := getFields(reflect.TypeOf(src))
+	if err != nil {
+		return nil, err
+	}
+	var columns []string
+	for _, column := range data.columns {
+		if (!includePk && column == data.pk) || data.generated[column] {
+			continue
+		}
+		columns = append(columns, column)
+	}
+	if o == nil || !o.masked {
+		return columns, nil
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range o.fields {
+		column, err := data.resolve(reflect.TypeOf(src), name)
+		if err != nil {
+			return nil, fmt.Errorf("meddler.Fields: %w", err)
+		}
+		wanted[column] = true
+	}
+
+	var masked []string
+	for _, column := range columns {
+		if wanted[column] || column == data.pk {
+			masked = append(masked, column)
+		}
+	}
+	return masked, nil
+}
+
+// resolve returns the column for name, which may be a column name or the
+// Go name of a mapped field of srcType.
+func (data *structData) resolve(srcType reflect.Type, name string) (string, error) {
+	if _, present := data.fields[name]; present {
+		return name, nil
+	}
+	structType := srcType.Elem()
+	for _, column := range data.columns {
+		if structType.FieldByIndex(data.fields[column].index).Name == name {
+			return column, nil
+		}
+	}
+	return "", fmt.Errorf("%s is not a field of %s", name, structType.Name())
+}
+
+// ColumnsFor returns the column names for the given fields of src, in the
+// order given. Fields may be named by column or by Go field name. It is
+// meant for building custom SQL that uses only part of a struct.
+func (d *Database) ColumnsFor(src interface{}, fields []string) ([]string, error) {
+	data, err := getFields(reflect.TypeOf(src))
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]string, len(fields))
+	for i, name := range fields {
+		if columns[i], err = data.resolve(reflect.TypeOf(src), name); err != nil {
+			return nil, fmt.Errorf("meddler.ColumnsFor: %w", err)
+		}
+	}
+	return columns, nil
+}
+
+// ColumnsFor using the Default Database type
+func ColumnsFor(src interface{}, fields []string) ([]string, error) {
+	return Default.ColumnsFor(src, fields)
+}
+
+// ValuesFor returns the values of the given fields of src, ready to be
+// passed as query arguments, in the order given. Fields are named as for
+// ColumnsFor.
+func (d *Database) ValuesFor(src interface{}, fields []string) ([]interface{}, error) {
+	columns, err := d.ColumnsFor(src, fields)
+	if err != nil {
+		return nil, err
+	}
+	return d.SomeValues(src, columns)
+}
+
+// ValuesFor using the Default Database type
+func ValuesFor(src interface{}, fields []string) ([]interface{}, error) {
+	return Default.ValuesFor(src, fields)
+}
+
+// FieldsFor returns pointers to the given fields of src, in the order
+// given, without any meddling. Fields are named as for ColumnsFor. It is
+// meant for code that maps records to and from other formats by column
+// name.
+func (d *Database) FieldsFor(src interface{}, fields []string) ([]interface{}, error) {
+	data, err := getFields(reflect.TypeOf(src))
+	if err != nil {
+		return nil, err
+	}
+	structVal := reflect.ValueOf(src).Elem()
+	addrs := make([]interface{}, len(fields))
+	for i, name := range fields {
+		column, err := data.resolve(reflect.TypeOf(src), name)
+		if err != nil {
+			return nil, fmt.Errorf("meddler.FieldsFor: %w", err)
+		}
+		addrs[i] = structVal.FieldByIndex(data.fields[column].index).Addr().Interface()
+	}
+	return addrs, nil
+}
+
+// FieldsFor using the Default Database type
+func FieldsFor(src interface{}, fields []string) ([]interface{}, error) {
+	return Default.FieldsFor(src, fields)
+}
@@ -0,0 +1,123 @@
+package meddlerx
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFieldMask(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	// only the masked columns are written, by column or Go field name
+	changed := *alice
+	changed.Name = "Alicia"
+	changed.Email = "alicia@alice.com"
+	changed.Age = 99
+	if err := Update(testCtx, db, "person", &changed, Fields("name", "Email")); err != nil {
+		t.Fatalf("masked Update error: %v", err)
+	}
+	elt := new(Person)
+	if err := Load(testCtx, db, "person", elt, alice.ID); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if elt.Name != "Alicia" || elt.Email != "alicia@alice.com" || elt.Age != alice.Age {
+		t.Errorf("masked Update: got name=%s email=%s age=%d", elt.Name, elt.Email, elt.Age)
+	}
+
+	rec := new(recordingQuerier)
+	SQLite.Update(testCtx, rec, "person", &changed, Fields("Age"), Fields("name"))
+	if expected := `UPDATE "person" SET "name"=?,"Age"=? WHERE "id"=?`; rec.query != expected {
+		t.Errorf("masked Update: expected %s, got %s", expected, rec.query)
+	}
+	SQLite.Insert(testCtx, rec, "person", &Person{Name: "Carol"}, Fields("name"))
+	if expected := `INSERT INTO "person" ("name") VALUES (?)`; rec.query != expected {
+		t.Errorf("masked Insert: expected %s, got %s", expected, rec.query)
+	}
+
+	if err := Update(testCtx, db, "person", &changed, Fields("nonesuch")); err == nil {
+		t.Errorf("Update with unknown field: expected err, got nil")
+	}
+	if err := Update(testCtx, db, "person", &changed, Fields()); err == nil {
+		t.Errorf("Update with empty mask: expected err, got nil")
+	}
+}
+
+func TestRowsAffected(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	var n int64 = -1
+	if err := Update(testCtx, db, "person", alice, RowsAffected(&n)); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Update RowsAffected: expected 1, got %d", n)
+	}
+
+	ghost := *alice
+	ghost.ID = 99
+	if err := Update(testCtx, db, "person", &ghost, RowsAffected(&n)); err != nil {
+		t.Errorf("Update of missing row without MustAffect: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("Update RowsAffected of missing row: expected 0, got %d", n)
+	}
+	if err := Update(testCtx, db, "person", &ghost, MustAffect()); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Update of missing row with MustAffect: expected sql.ErrNoRows, got %v", err)
+	}
+
+	if err := Delete(testCtx, db, "person", bob, MustAffect(), RowsAffected(&n)); err != nil || n != 1 {
+		t.Errorf("Delete with MustAffect: got n=%d err=%v", n, err)
+	}
+	if err := Delete(testCtx, db, "person", bob, MustAffect()); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Delete of missing row with MustAffect: expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestColumnsValuesFor(t *testing.T) {
+	p := &Person{ID: 5, Name: "Alice", Email: "alice@alice.com", Age: 0}
+	columns, err := ColumnsFor(p, []string{"Email", "name", "Age"})
+	if err != nil {
+		t.Fatalf("ColumnsFor error: %v", err)
+	}
+	if expected := []string{"Email", "name", "Age"}; !reflect.DeepEqual(columns, expected) {
+		t.Errorf("ColumnsFor: expected %v, got %v", expected, columns)
+	}
+	columns, err = ColumnsFor(p, []string{"ID", "Updated"})
+	if err != nil {
+		t.Fatalf("ColumnsFor error: %v", err)
+	}
+	if expected := []string{"id", "updated"}; !reflect.DeepEqual(columns, expected) {
+		t.Errorf("ColumnsFor: expected %v, got %v", expected, columns)
+	}
+
+	// values go through the meddlers, so a zero Age is NULL
+	values, err := ValuesFor(p, []string{"Email", "name", "Age"})
+	if err != nil {
+		t.Fatalf("ValuesFor error: %v", err)
+	}
+	if expected := []interface{}{"alice@alice.com", "Alice", nil}; !reflect.DeepEqual(values, expected) {
+		t.Errorf("ValuesFor: expected %#v, got %#v", expected, values)
+	}
+
+	if _, err := ColumnsFor(p, []string{"Ephemeral"}); err == nil {
+		t.Errorf("ColumnsFor with an unmapped field: expected an error")
+	}
+
+	// fields are the raw struct fields
+	addrs, err := FieldsFor(p, []string{"Age", "name"})
+	if err != nil {
+		t.Fatalf("FieldsFor error: %v", err)
+	}
+	if addrs[0] != &p.Age || addrs[1] != &p.Name {
+		t.Errorf("FieldsFor: expected pointers to Age and Name, got %v", addrs)
+	}
+	if _, err := FieldsFor(p, []string{"Ephemeral"}); err == nil {
+		t.Errorf("FieldsFor with an unmapped field: expected an error")
+	}
+}
@@ -0,0 +1,122 @@
+package meddlerx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// QueryAllParallel loads the rows of table into dst, a pointer to a slice
+// as for QueryAll, by splitting the range of primary keys into parts
+// ranges of equal width and querying them concurrently. db should be a
+// pool such as *sql.DB, so that each range can use its own connection.
+// where, if not empty, is a condition the rows must also meet, and args
+// are its arguments. The rows are in primary key order.
+//
+// Ranges are split by key value, not by row count, so keys should be
+// spread fairly evenly for the work to be shared well. If any range fails,
+// the others are cancelled and the first error is returned.
+func (d *Database) QueryAllParallel(ctx context.Context, db Querier, table string, dst interface{}, parts int, where string, args ...interface{}) error {
+	d = d.forTable(table)
+	dstVal := reflect.ValueOf(dst)
+	if dstVal.Kind() != reflect.Ptr || dstVal.IsNil() || dstVal.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("meddler.QueryAllParallel: destination must be a pointer to a slice, found %T", dst)
+	}
+	if parts < 1 {
+		return fmt.Errorf("meddler.QueryAllParallel: parts must be at least 1, found %d", parts)
+	}
+	eltType := dstVal.Elem().Type().Elem()
+	if eltType.Kind() == reflect.Struct {
+		eltType = reflect.PtrTo(eltType)
+	}
+	data, err := getFields(eltType)
+	if err != nil {
+		return err
+	}
+	if data.pk == "" {
+		return fmt.Errorf("meddler.QueryAllParallel: %w", ErrNoPrimaryKey)
+	}
+	columns, err := d.ColumnsQuoted(reflect.New(eltType.Elem()).Interface(), true)
+	if err != nil {
+		return err
+	}
+
+	cond := ""
+	if where != "" {
+		cond = "(" + where + ") AND "
+	}
+	pk := d.quoted(data.pk)
+
+	// find the range of keys to split up
+	q := fmt.Sprintf("SELECT MIN(%s), MAX(%s) FROM %s", pk, pk, d.quotedTable(table))
+	if where != "" {
+		q += " WHERE " + where
+	}
+	var lo, hi sql.NullInt64
+	if err := db.QueryRowContext(ctx, q, args...).Scan(&lo, &hi); err != nil {
+		return d.queryError("QueryAllParallel", table, q, args, err)
+	}
+	dstVal.Elem().Set(reflect.MakeSlice(dstVal.Elem().Type(), 0, 0))
+	if !lo.Valid {
+		return nil
+	}
+	step := uint64(hi.Int64-lo.Int64)/uint64(parts) + 1
+
+	q = fmt.Sprintf("SELECT %s FROM %s WHERE %s%s >= %s AND %s <= %s ORDER BY %s",
+		columns, d.quotedTable(table), cond,
+		pk, d.placeholder(len(args)+1), pk, d.placeholder(len(args)+2), pk)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	results := make([]reflect.Value, 0, parts)
+	for start := lo.Int64; ; {
+		end := hi.Int64
+		if uint64(hi.Int64-start) >= step {
+			end = start + int64(step) - 1
+		}
+		part := reflect.New(dstVal.Elem().Type())
+		results = append(results, part)
+
+		wg.Add(1)
+		go func(start, end int64) {
+			defer wg.Done()
+			partArgs := append(append([]interface{}(nil), args...), start, end)
+			if err := d.QueryAll(ctx, db, part.Interface(), q, partArgs...); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}(start, end)
+
+		if end == hi.Int64 {
+			break
+		}
+		start = end + 1
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+
+	merged := dstVal.Elem()
+	for _, part := range results {
+		merged = reflect.AppendSlice(merged, part.Elem())
+	}
+	dstVal.Elem().Set(merged)
+	return nil
+}
+
+// QueryAllParallel using the Default Database type
+func QueryAllParallel(ctx context.Context, db Querier, table string, dst interface{}, parts int, where string, args ...interface{}) error {
+	return Default.QueryAllParallel(ctx, db, table, dst, parts, where, args...)
+}
@@ -0,0 +1,57 @@
+package meddlerx
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+)
+
+func TestQueryAllParallel(t *testing.T) {
+	// a shared in-memory database, so each connection sees the same rows
+	pool, err := sql.Open("sqlite3", "file:parallel?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	defer pool.Close()
+	if _, err := pool.Exec(schema1); err != nil {
+		t.Fatalf("DB error: %v", err)
+	}
+	for i := 1; i <= 25; i++ {
+		p := &Person{Name: fmt.Sprintf("p%d", i), Age: i % 3}
+		if err := SQLite.Insert(testCtx, pool, "person", p); err != nil {
+			t.Fatalf("Insert error: %v", err)
+		}
+	}
+
+	for _, parts := range []int{1, 4, 100} {
+		var people []*Person
+		if err := SQLite.QueryAllParallel(testCtx, pool, "person", &people, parts, ""); err != nil {
+			t.Fatalf("QueryAllParallel error with %d parts: %v", parts, err)
+		}
+		if len(people) != 25 {
+			t.Fatalf("QueryAllParallel with %d parts: expected 25 rows, got %d", parts, len(people))
+		}
+		for i, p := range people {
+			if p.ID != int64(i+1) {
+				t.Errorf("QueryAllParallel with %d parts: expected id %d at %d, got %d", parts, i+1, i, p.ID)
+				break
+			}
+		}
+	}
+
+	var people []Person
+	if err := SQLite.QueryAllParallel(testCtx, pool, "person", &people, 3, "age = ?", 1); err != nil {
+		t.Fatalf("QueryAllParallel error: %v", err)
+	}
+	if len(people) != 9 || people[0].ID != 1 || people[8].ID != 25 {
+		t.Errorf("QueryAllParallel with a condition: expected 9 rows from id 1 to 25, got %d", len(people))
+	}
+
+	people = []Person{{}}
+	if err := SQLite.QueryAllParallel(testCtx, pool, "person", &people, 3, "age > 5"); err != nil || len(people) != 0 {
+		t.Errorf("QueryAllParallel with no rows: expected an empty slice, got %d, %v", len(people), err)
+	}
+	if err := SQLite.QueryAllParallel(testCtx, pool, "person", &people, 3, "nosuchcolumn = 1"); err == nil {
+		t.Errorf("QueryAllParallel with a bad condition: expected err, got nil")
+	}
+}
@@ -0,0 +1,252 @@
+package meddlerx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// PartitionPeriod is the span of time covered by each partition of a
+// TimePartitions.
+type PartitionPeriod int
+
+// Partition periods
+const (
+	PartitionMonthly PartitionPeriod = iota
+	PartitionDaily
+	PartitionYearly
+)
+
+// TimePartitions routes the rows of a logical table to one table per
+// period, chosen by a timestamp field of the record, such as events_2024_06
+// for an event in June 2024. It is for databases without native
+// partitioning, such as SQLite and older MySQL deployments. The partition
+// tables must already exist, with the same columns; see Partitions for the
+// names to create.
+//
+// Writes take the partition from the record's TimeColumn field. Reads take
+// a time, or a range of times that may span several partitions.
+type TimePartitions struct {
+	// Database is the Database used for every partition. If nil, Default
+	// is used.
+	Database *Database
+
+	// TimeColumn is the column of the time.Time or *time.Time field that
+	// picks a record's partition. It may also be given as the Go field name.
+	TimeColumn string
+
+	// Period is the span of each partition; the zero value is monthly.
+	Period PartitionPeriod
+
+	// Location is the time zone in which periods begin. If nil, UTC is used.
+	Location *time.Location
+
+	// Name returns the name of the partition of table that begins at start.
+	// If nil, the period's start is appended to the table name as
+	// _2006_01 for monthly partitions, _2006_01_02 for daily, and _2006 for
+	// yearly.
+	Name func(table string, start time.Time) string
+}
+
+func (p *TimePartitions) database() *Database {
+	if p.Database == nil {
+		return Default
+	}
+	return p.Database
+}
+
+// periodStart returns the start of the period containing t.
+func (p *TimePartitions) periodStart(t time.Time) time.Time {
+	loc := p.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	switch p.Period {
+	case PartitionDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	case PartitionYearly:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	}
+}
+
+// nextPeriod returns the start of the period after the one beginning at
+// start.
+func (p *TimePartitions) nextPeriod(start time.Time) time.Time {
+	switch p.Period {
+	case PartitionDaily:
+		return start.AddDate(0, 0, 1)
+	case PartitionYearly:
+		return start.AddDate(1, 0, 0)
+	default:
+		return start.AddDate(0, 1, 0)
+	}
+}
+
+// Partition returns the name of the partition of table that holds rows
+// for time t.
+func (p *TimePartitions) Partition(table string, t time.Time) string {
+	start := p.periodStart(t)
+	if p.Name != nil {
+		return p.Name(table, start)
+	}
+	switch p.Period {
+	case PartitionDaily:
+		return table + start.Format("_2006_01_02")
+	case PartitionYearly:
+		return table + start.Format("_2006")
+	default:
+		return table + start.Format("_2006_01")
+	}
+}
+
+// Partitions returns the names of the partitions of table that hold rows
+// for times from from up to but not including to, oldest first.
+func (p *TimePartitions) Partitions(table string, from, to time.Time) []string {
+	if !from.Before(to) {
+		return nil
+	}
+	var names []string
+	for start := p.periodStart(from); start.Before(to); start = p.nextPeriod(start) {
+		names = append(names, p.Partition(table, start))
+	}
+	return names
+}
+
+// timeField returns the column and struct field index of TimeColumn in
+// records of type srcType.
+func (p *TimePartitions) timeField(op string, srcType reflect.Type) (string, []int, error) {
+	data, err := getFields(srcType)
+	if err != nil {
+		return "", nil, err
+	}
+	column, err := data.resolve(srcType, p.TimeColumn)
+	if err != nil {
+		return "", nil, fmt.Errorf("meddler.TimePartitions.%s: time column: %w", op, err)
+	}
+	index := data.fields[column].index
+	if t := srcType.Elem().FieldByIndex(index).Type; t != timeType && t != reflect.PtrTo(timeType) {
+		return "", nil, fmt.Errorf("meddler.TimePartitions.%s: time column %s is a %v, not a time.Time", op, p.TimeColumn, t)
+	}
+	return column, index, nil
+}
+
+// partitionFor returns the partition of table for the record src.
+func (p *TimePartitions) partitionFor(op, table string, src interface{}) (string, error) {
+	_, index, err := p.timeField(op, reflect.TypeOf(src))
+	if err != nil {
+		return "", err
+	}
+	switch t := reflect.ValueOf(src).Elem().FieldByIndex(index).Interface().(type) {
+	case *time.Time:
+		if t == nil {
+			return "", fmt.Errorf("meddler.TimePartitions.%s: time column %s is nil", op, p.TimeColumn)
+		}
+		return p.Partition(table, *t), nil
+	default:
+		return p.Partition(table, t.(time.Time)), nil
+	}
+}
+
+// Insert inserts a record into the partition for its time.
+func (p *TimePartitions) Insert(ctx context.Context, db Querier, table string, src interface{}, opts ...WriteOption) error {
+	partition, err := p.partitionFor("Insert", table, src)
+	if err != nil {
+		return err
+	}
+	return p.database().Insert(ctx, db, partition, src, opts...)
+}
+
+// Update updates a record in the partition for its time. A record whose
+// time has moved to another period is not moved.
+func (p *TimePartitions) Update(ctx context.Context, db Querier, table string, src interface{}, opts ...WriteOption) error {
+	partition, err := p.partitionFor("Update", table, src)
+	if err != nil {
+		return err
+	}
+	return p.database().Update(ctx, db, partition, src, opts...)
+}
+
+// Delete deletes a record from the partition for its time.
+func (p *TimePartitions) Delete(ctx context.Context, db Querier, table string, src interface{}, opts ...WriteOption) error {
+	partition, err := p.partitionFor("Delete", table, src)
+	if err != nil {
+		return err
+	}
+	return p.database().Delete(ctx, db, partition, src, opts...)
+}
+
+// Load loads a record by primary key from the partition of table for time
+// at.
+func (p *TimePartitions) Load(ctx context.Context, db Querier, table string, at time.Time, dst interface{}, pk int64) error {
+	return p.database().Load(ctx, db, p.Partition(table, at), dst, pk)
+}
+
+// LoadAllWhere loads the rows with times from from up to but not including
+// to that meet the conditions in opts, as LoadAllWhere does, querying each
+// partition in the range in turn, oldest first. Any OrderBy, Limit, or
+// Offset applies within each partition.
+func (p *TimePartitions) LoadAllWhere(ctx context.Context, db Querier, table string, from, to time.Time, dst interface{}, opts ...QueryOption) error {
+	dstType := reflect.TypeOf(dst)
+	if dstType == nil || dstType.Kind() != reflect.Ptr || dstType.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("meddler.TimePartitions.LoadAllWhere: destination must be a pointer to a slice, found %T", dst)
+	}
+	eltType := dstType.Elem().Elem()
+	if eltType.Kind() == reflect.Struct {
+		eltType = reflect.PtrTo(eltType)
+	}
+	column, index, err := p.timeField("LoadAllWhere", eltType)
+	if err != nil {
+		return err
+	}
+
+	// compare with the times as they are written, e.g. in UTC for utctime
+	data, err := getFields(eltType)
+	if err != nil {
+		return err
+	}
+	bounds := make([]interface{}, 2)
+	for i, t := range []time.Time{from, to} {
+		value := reflect.New(eltType.Elem().FieldByIndex(index).Type).Elem()
+		if value.Kind() == reflect.Ptr {
+			value.Set(reflect.ValueOf(&t))
+		} else {
+			value.Set(reflect.ValueOf(t))
+		}
+		if bounds[i], err = data.fields[column].meddler.PreWrite(value.Interface()); err != nil {
+			return fmt.Errorf("meddler.TimePartitions.LoadAllWhere: PreWrite error on time column: %w", err)
+		}
+	}
+
+	d := p.database()
+	quoted := d.quoted(column)
+	opts = append([]QueryOption{Where(quoted+" >= ? AND "+quoted+" < ?", bounds...)}, opts...)
+	for _, partition := range p.Partitions(table, from, to) {
+		if err := d.LoadAllWhere(ctx, db, partition, dst, opts...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QueryAll runs query on each partition of table from from up to but not
+// including to, oldest first, appending the results to dst as QueryAll
+// does. In query, {table} stands for the quoted name of the partition.
+// Unlike LoadAllWhere, no condition on the time column is added.
+func (p *TimePartitions) QueryAll(ctx context.Context, db Querier, table string, from, to time.Time, dst interface{}, query string, args ...interface{}) error {
+	if !strings.Contains(query, "{table}") {
+		return fmt.Errorf("meddler.TimePartitions.QueryAll: query does not refer to {table}")
+	}
+	d := p.database()
+	for _, partition := range p.Partitions(table, from, to) {
+		q := strings.ReplaceAll(query, "{table}", d.forTable(partition).quotedTable(partition))
+		if err := d.QueryAll(ctx, db, dst, q, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
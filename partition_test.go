@@ -0,0 +1,118 @@
+package meddlerx
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type partitionEvent struct {
+	ID   int64     `meddler:"id,pk"`
+	Name string    `meddler:"name"`
+	At   time.Time `meddler:"at,utctime"`
+}
+
+func TestTimePartitionNames(t *testing.T) {
+	at := time.Date(2024, 6, 30, 23, 30, 0, 0, time.UTC)
+	p := &TimePartitions{TimeColumn: "at"}
+	if name := p.Partition("events", at); name != "events_2024_06" {
+		t.Errorf("expected events_2024_06, got %s", name)
+	}
+
+	// periods begin in Location
+	tokyo := time.FixedZone("JST", 9*60*60)
+	p = &TimePartitions{TimeColumn: "at", Period: PartitionDaily, Location: tokyo}
+	if name := p.Partition("events", at); name != "events_2024_07_01" {
+		t.Errorf("expected events_2024_07_01, got %s", name)
+	}
+
+	p = &TimePartitions{TimeColumn: "at", Period: PartitionYearly}
+	if names := p.Partitions("events", at.AddDate(-1, 0, 0), at.AddDate(1, 0, 0)); !reflect.DeepEqual(names, []string{"events_2023", "events_2024", "events_2025"}) {
+		t.Errorf("unexpected yearly partitions %v", names)
+	}
+
+	p = &TimePartitions{TimeColumn: "at", Name: func(table string, start time.Time) string {
+		return table + "_q" + start.Format("2006_01")
+	}}
+	from := time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)
+	if names := p.Partitions("events", from, from.AddDate(0, 1, 0)); !reflect.DeepEqual(names, []string{"events_q2024_05", "events_q2024_06"}) {
+		t.Errorf("unexpected custom partitions %v", names)
+	}
+	if names := p.Partitions("events", from, from); names != nil {
+		t.Errorf("expected no partitions for an empty range, got %v", names)
+	}
+}
+
+func TestTimePartitions(t *testing.T) {
+	once.Do(setup)
+	p := &TimePartitions{Database: SQLite, TimeColumn: "At"}
+	for _, table := range []string{"events_2024_05", "events_2024_06"} {
+		if err := SQLite.EnsureTable(testCtx, db, table, new(partitionEvent)); err != nil {
+			t.Fatalf("EnsureTable error: %v", err)
+		}
+		defer db.Exec("drop table " + table)
+	}
+
+	events := []*partitionEvent{
+		{Name: "may", At: time.Date(2024, 5, 31, 23, 0, 0, 0, time.UTC)},
+		{Name: "june", At: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{Name: "late june", At: time.Date(2024, 6, 20, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, e := range events {
+		if err := p.Insert(testCtx, db, "events", e); err != nil {
+			t.Fatalf("Insert error: %v", err)
+		}
+	}
+	var n int
+	if err := db.QueryRow("select count(*) from events_2024_06").Scan(&n); err != nil || n != 2 {
+		t.Errorf("expected 2 rows in events_2024_06, got %d, %v", n, err)
+	}
+
+	// a record is routed by its time when written
+	events[1].Name = "early june"
+	if err := p.Update(testCtx, db, "events", events[1]); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	loaded := new(partitionEvent)
+	if err := p.Load(testCtx, db, "events", events[1].At, loaded, events[1].ID); err != nil || loaded.Name != "early june" {
+		t.Errorf("expected early june, got %q, %v", loaded.Name, err)
+	}
+
+	// range reads span partitions and are bounded by the time column
+	var found []*partitionEvent
+	from := time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	if err := p.LoadAllWhere(testCtx, db, "events", from, to, &found, OrderBy("at")); err != nil {
+		t.Fatalf("LoadAllWhere error: %v", err)
+	}
+	var names []string
+	for _, e := range found {
+		names = append(names, e.Name)
+	}
+	if expected := []string{"may", "early june"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+
+	var all []partitionEvent
+	if err := p.QueryAll(testCtx, db, "events", from, to, &all, "select * from {table} where name <> ?", "may"); err != nil {
+		t.Fatalf("QueryAll error: %v", err)
+	}
+	if len(all) != 2 || all[0].Name != "early june" || all[1].Name != "late june" {
+		t.Errorf("unexpected QueryAll results %+v", all)
+	}
+	if err := p.QueryAll(testCtx, db, "events", from, to, &all, "select * from events"); err == nil {
+		t.Errorf("expected an error for a query without {table}")
+	}
+
+	if err := p.Delete(testCtx, db, "events", events[0]); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	if err := db.QueryRow("select count(*) from events_2024_05").Scan(&n); err != nil || n != 0 {
+		t.Errorf("expected events_2024_05 to be empty, got %d, %v", n, err)
+	}
+
+	bad := &TimePartitions{Database: SQLite, TimeColumn: "name"}
+	if err := bad.Insert(testCtx, db, "events", &partitionEvent{Name: "x"}); err == nil {
+		t.Errorf("expected an error for a non-time column")
+	}
+}
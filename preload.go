@@ -0,0 +1,207 @@
+package meddlerx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// relation describes a struct field that holds related rows from another
+// table, as declared by a hasmany= or belongsto= tag option.
+type relation struct {
+	hasMany bool   // hasmany if set, belongsto otherwise
+	table   string // the table holding the related rows
+	column  string // hasmany: the foreign key column in table; belongsto: the foreign key column in this struct
+	index   int    // the index of the field in the struct
+	target  reflect.Type
+}
+
+// parseRelation reads the relation options from the tag of a field that is
+// not mapped to a column. It returns nil if the field has none.
+func parseRelation(f reflect.StructField, index int, options []string) (*relation, error) {
+	var rel *relation
+	for _, opt := range options {
+		key, value, _ := strings.Cut(opt, "=")
+		if key != "hasmany" && key != "belongsto" {
+			continue
+		}
+		if rel != nil {
+			return nil, fmt.Errorf("meddler found field %s with more than one relation", f.Name)
+		}
+		dot := strings.LastIndex(value, ".")
+		if dot <= 0 || dot == len(value)-1 {
+			return nil, fmt.Errorf("meddler found field %s with %s=%s, expected table.column", f.Name, key, value)
+		}
+		rel = &relation{hasMany: key == "hasmany", table: value[:dot], column: value[dot+1:], index: index}
+
+		// hasmany fields are slices of struct pointers, belongsto fields
+		// are struct pointers
+		t := f.Type
+		if rel.hasMany {
+			if t.Kind() != reflect.Slice {
+				return nil, fmt.Errorf("meddler found hasmany field %s, but it is not a slice", f.Name)
+			}
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+			return nil, fmt.Errorf("meddler found %s field %s, but it does not hold pointers to structs", key, f.Name)
+		}
+		rel.target = t
+	}
+	return rel, nil
+}
+
+// Preload fills in the relation field named field for every element of
+// dst, which must be a pointer to a slice of struct pointers, using a single
+// query for the whole slice instead of one per element, or a few if there
+// are more keys than the database takes parameters in one statement.
+// Relation fields are not mapped to a column and declare the related table
+// in their tag:
+//
+//	type Customer struct {
+//		ID     int64    `meddler:"id,pk"`
+//		Orders []*Order `meddler:"-,hasmany=orders.customer_id"`
+//	}
+//
+//	type Order struct {
+//		ID         int64     `meddler:"id,pk"`
+//		CustomerID int64     `meddler:"customer_id"`
+//		Customer   *Customer `meddler:"-,belongsto=customer.customer_id"`
+//	}
+//
+// hasmany=table.column loads the rows of table whose column holds the
+// primary key of an element, and sets the field to a slice of them.
+// belongsto=table.column loads the row of table whose primary key is held
+// by column of the element, and sets the field to point to it. Elements
+// with no related rows have the field set to nil.
+func (d *Database) Preload(ctx context.Context, db Querier, dst interface{}, field string) error {
+	ctx, db, done := d.begin(ctx, db, "Preload", "")
+	defer done()
+
+	dstVal := reflect.ValueOf(dst)
+	if dstVal.Kind() != reflect.Ptr || dstVal.IsNil() || dstVal.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("meddler.Preload called with non-pointer-to-slice destination: %T", dst)
+	}
+	sliceVal := dstVal.Elem()
+	data, err := getFields(sliceVal.Type().Elem())
+	if err != nil {
+		return err
+	}
+	rel, present := data.relations[field]
+	if !present {
+		return fmt.Errorf("meddler.Preload: %s is not a hasmany or belongsto field of %v", field, sliceVal.Type().Elem())
+	}
+	targetData, err := getFields(rel.target)
+	if err != nil {
+		return err
+	}
+
+	// the column in the parent that identifies the related rows, and the
+	// column in the related table that it is matched against
+	parentColumn, childColumn := data.pk, rel.column
+	if !rel.hasMany {
+		parentColumn, childColumn = rel.column, targetData.pk
+	}
+	if parentColumn == "" || childColumn == "" {
+		return fmt.Errorf("meddler.Preload: %w", ErrNoPrimaryKey)
+	}
+	if _, present := data.fields[parentColumn]; !present {
+		return fmt.Errorf("meddler.Preload: column %s not found in %v", parentColumn, sliceVal.Type().Elem())
+	}
+	if _, present := targetData.fields[childColumn]; !present {
+		return fmt.Errorf("meddler.Preload: column %s not found in %v", childColumn, rel.target)
+	}
+
+	// gather the distinct keys
+	keys := make([]int64, sliceVal.Len())
+	valid := make([]bool, sliceVal.Len())
+	seen := make(map[int64]bool)
+	var args []interface{}
+	for i := 0; i < sliceVal.Len(); i++ {
+		elt := sliceVal.Index(i)
+		if elt.IsNil() {
+			continue
+		}
+		key, ok, err := intColumn(data, elt, parentColumn)
+		if err != nil {
+			return fmt.Errorf("meddler.Preload: %w", err)
+		}
+		keys[i], valid[i] = key, ok
+		if ok && !seen[key] {
+			seen[key] = true
+			args = append(args, key)
+		}
+	}
+
+	// load the related rows and index them by key
+	related := make(map[int64][]reflect.Value)
+	if len(args) > 0 {
+		columns, err := d.ColumnsQuoted(reflect.New(rel.target.Elem()).Interface(), true)
+		if err != nil {
+			return err
+		}
+		found := reflect.New(reflect.SliceOf(rel.target))
+		if err := d.loadIn(ctx, db, "Preload", rel.table, columns, childColumn, args, found); err != nil {
+			return err
+		}
+		for i := 0; i < found.Elem().Len(); i++ {
+			child := found.Elem().Index(i)
+			key, ok, err := intColumn(targetData, child, childColumn)
+			if err != nil {
+				return fmt.Errorf("meddler.Preload: %w", err)
+			}
+			if ok {
+				related[key] = append(related[key], child)
+			}
+		}
+	}
+
+	// wire them into the parents
+	for i := 0; i < sliceVal.Len(); i++ {
+		elt := sliceVal.Index(i)
+		if elt.IsNil() {
+			continue
+		}
+		fieldVal := elt.Elem().Field(rel.index)
+		fieldVal.Set(reflect.Zero(fieldVal.Type()))
+		if !valid[i] {
+			continue
+		}
+		children := related[keys[i]]
+		if len(children) == 0 {
+			continue
+		}
+		if rel.hasMany {
+			fieldVal.Set(reflect.Append(fieldVal, children...))
+		} else {
+			fieldVal.Set(children[0])
+		}
+	}
+
+	return nil
+}
+
+// Preload using the Default Database type
+func Preload(ctx context.Context, db Querier, dst interface{}, field string) error {
+	return Default.Preload(ctx, db, dst, field)
+}
+
+// intColumn reads an integer key column from a struct pointer. ok is false
+// if the column holds a nil pointer.
+func intColumn(data *structData, elt reflect.Value, column string) (key int64, ok bool, err error) {
+	v := elt.Elem().FieldByIndex(data.fields[column].index)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return 0, false, nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), true, nil
+	}
+	return 0, false, fmt.Errorf("column %s is not an integer", column)
+}
@@ -0,0 +1,99 @@
+package meddlerx
+
+import (
+	"testing"
+)
+
+type customer struct {
+	ID        int64       `meddler:"id,pk"`
+	Name      string      `meddler:"name"`
+	Purchases []*purchase `meddler:"-,hasmany=purchase.customer_id"`
+}
+
+type purchase struct {
+	ID         int64     `meddler:"id,pk"`
+	CustomerID *int64    `meddler:"customer_id"`
+	Item       string    `meddler:"item"`
+	Customer   *customer `meddler:"-,belongsto=customer.customer_id"`
+}
+
+func TestPreload(t *testing.T) {
+	once.Do(setup)
+	for _, q := range []string{
+		"create table customer (id integer primary key, name text not null)",
+		"create table purchase (id integer primary key, customer_id integer, item text not null)",
+	} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatalf("creating tables: %v", err)
+		}
+	}
+	defer db.Exec("drop table customer")
+	defer db.Exec("drop table purchase")
+
+	ann, ben, cat := &customer{Name: "Ann"}, &customer{Name: "Ben"}, &customer{Name: "Cat"}
+	for _, c := range []*customer{ann, ben, cat} {
+		if err := SQLite.Insert(testCtx, db, "customer", c); err != nil {
+			t.Fatalf("Insert error: %v", err)
+		}
+	}
+	for _, p := range []*purchase{
+		{CustomerID: &ann.ID, Item: "apple"},
+		{CustomerID: &ben.ID, Item: "bread"},
+		{CustomerID: &ann.ID, Item: "avocado"},
+		{Item: "orphan"},
+	} {
+		if err := SQLite.Insert(testCtx, db, "purchase", p); err != nil {
+			t.Fatalf("Insert error: %v", err)
+		}
+	}
+
+	var customers []*customer
+	if err := SQLite.QueryAll(testCtx, db, &customers, "select * from customer order by id"); err != nil {
+		t.Fatalf("QueryAll error: %v", err)
+	}
+	if err := SQLite.Preload(testCtx, db, &customers, "Purchases"); err != nil {
+		t.Fatalf("Preload hasmany error: %v", err)
+	}
+	counts := []int{2, 1, 0}
+	for i, c := range customers {
+		if len(c.Purchases) != counts[i] {
+			t.Errorf("Preload hasmany: %s has %d purchases, expected %d", c.Name, len(c.Purchases), counts[i])
+		}
+	}
+	if customers[0].Purchases[1].Item != "avocado" {
+		t.Errorf("Preload hasmany: expected avocado, got %s", customers[0].Purchases[1].Item)
+	}
+
+	var purchases []*purchase
+	if err := SQLite.QueryAll(testCtx, db, &purchases, "select * from purchase order by id"); err != nil {
+		t.Fatalf("QueryAll error: %v", err)
+	}
+	if err := SQLite.Preload(testCtx, db, &purchases, "Customer"); err != nil {
+		t.Fatalf("Preload belongsto error: %v", err)
+	}
+	names := []string{"Ann", "Ben", "Ann", ""}
+	for i, p := range purchases {
+		name := ""
+		if p.Customer != nil {
+			name = p.Customer.Name
+		}
+		if name != names[i] {
+			t.Errorf("Preload belongsto: %s belongs to %q, expected %q", p.Item, name, names[i])
+		}
+	}
+	if purchases[0].Customer != purchases[2].Customer {
+		t.Errorf("Preload belongsto: expected purchases of the same customer to share the struct")
+	}
+
+	if err := SQLite.Preload(testCtx, db, &customers, "Name"); err == nil {
+		t.Errorf("Preload of non-relation field: expected err, got nil")
+	}
+
+	type badRelation struct {
+		ID    int64  `meddler:"id,pk"`
+		Items string `meddler:"items,hasmany=purchase.customer_id"`
+	}
+	if _, err := Columns(new(badRelation), true); err == nil {
+		t.Errorf("hasmany on a mapped column: expected err, got nil")
+	}
+}
@@ -0,0 +1,58 @@
+package meddlerx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// CallProc calls the stored procedure name with args and scans the rows it
+// returns into dst. SQL Server procedures are run with EXEC name @p1, ...,
+// and other databases with CALL name(?, ...). SQLite has no stored
+// procedures, so CallProc always fails there.
+//
+// dst may be a pointer to a slice of struct pointers, which receives every
+// returned row as with QueryAll, a pointer to a struct, which receives the
+// single returned row as with QueryRow, or nil for procedures that return
+// no rows.
+func (d *Database) CallProc(ctx context.Context, db Querier, name string, dst interface{}, args ...interface{}) error {
+	ctx, db, done := d.begin(ctx, db, "CallProc", "")
+	defer done()
+
+	if d.Dialect == DialectSQLite {
+		return fmt.Errorf("meddler.CallProc: stored procedures are not supported by SQLite")
+	}
+
+	placeholders := make([]string, len(args))
+	for i := range args {
+		placeholders[i] = d.placeholder(i + 1)
+	}
+	var q string
+	if d.Dialect == DialectSQLServer {
+		q = strings.TrimSpace("EXEC " + d.quotedTable(name) + " " + strings.Join(placeholders, ", "))
+	} else {
+		q = fmt.Sprintf("CALL %s(%s)", d.quotedTable(name), strings.Join(placeholders, ", "))
+	}
+
+	if dst == nil {
+		if _, err := db.ExecContext(ctx, q, args...); err != nil {
+			return d.queryError("CallProc", name, q, args, err)
+		}
+		return nil
+	}
+
+	rows, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return d.queryError("CallProc", name, q, args, err)
+	}
+	if dstVal := reflect.ValueOf(dst); dstVal.Kind() == reflect.Ptr && dstVal.Elem().Kind() == reflect.Slice {
+		return d.ScanAllContext(ctx, rows, dst)
+	}
+	return d.ScanRow(rows, dst)
+}
+
+// CallProc using the Default Database type
+func CallProc(ctx context.Context, db Querier, name string, dst interface{}, args ...interface{}) error {
+	return Default.CallProc(ctx, db, name, dst, args...)
+}
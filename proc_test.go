@@ -0,0 +1,31 @@
+package meddlerx
+
+import (
+	"testing"
+)
+
+func TestCallProc(t *testing.T) {
+	once.Do(setup)
+
+	if err := SQLite.CallProc(testCtx, db, "refresh", nil); err == nil {
+		t.Errorf("CallProc on SQLite: expected err, got nil")
+	}
+
+	// sqlite cannot run the generated statements, so only check the SQL
+	rec := new(recordingQuerier)
+	var people []*Person
+	MySQL.CallProc(testCtx, rec, "app.find_people", &people, "Alice", 32)
+	if expected := "CALL `app`.`find_people`(?, ?)"; rec.query != expected {
+		t.Errorf("MySQL CallProc: expected %s, got %s", expected, rec.query)
+	}
+
+	PostgreSQL.CallProc(testCtx, rec, "refresh", nil)
+	if expected := `CALL "refresh"()`; rec.query != expected {
+		t.Errorf("PostgreSQL CallProc: expected %s, got %s", expected, rec.query)
+	}
+
+	SQLServer.CallProc(testCtx, rec, "dbo.find_person", new(Person), 1, "Alice")
+	if expected := `EXEC "dbo"."find_person" @p1, @p2`; rec.query != expected {
+		t.Errorf("SQLServer CallProc: expected %s, got %s", expected, rec.query)
+	}
+}
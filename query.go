@@ -0,0 +1,287 @@
+package meddlerx
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// QueryOption shapes the SELECT built by LoadWhere and LoadAllWhere.
+type QueryOption interface {
+	applyQuery(o *queryOptions)
+}
+
+// queryOptions is the combined effect of a list of QueryOptions.
+type queryOptions struct {
+	where     []string
+	args      []interface{}
+	orderBy   []string
+	limit     int
+	offset    int
+	forUpdate bool
+	fields    []string
+	masked    bool
+}
+
+func newQueryOptions(opts []QueryOption) *queryOptions {
+	o := &queryOptions{limit: -1}
+	for _, opt := range opts {
+		opt.applyQuery(o)
+	}
+	return o
+}
+
+// Where adds a condition that rows must meet, written with ? placeholders
+// whatever the dialect, e.g.
+//
+//	meddlerx.Where("age > ? AND name <> ?", 21, "Bob")
+//
+// The placeholders are renumbered to suit the Database. Several Where
+// options must all be met.
+func Where(cond string, args ...interface{}) QueryOption {
+	return whereOption{cond, args}
+}
+
+type whereOption struct {
+	cond string
+	args []interface{}
+}
+
+func (opt whereOption) applyQuery(o *queryOptions) {
+	o.where = append(o.where, opt.cond)
+	o.args = append(o.args, opt.args...)
+}
+
+// OrderBy sorts the rows by the named fields, which may be column names or
+// Go field names, each optionally followed by " DESC" or " ASC".
+func OrderBy(fields ...string) QueryOption {
+	return orderByOption(fields)
+}
+
+type orderByOption []string
+
+func (opt orderByOption) applyQuery(o *queryOptions) {
+	o.orderBy = append(o.orderBy, opt...)
+}
+
+// Limit returns at most n rows.
+func Limit(n int) QueryOption {
+	return limitOption(n)
+}
+
+type limitOption int
+
+func (opt limitOption) applyQuery(o *queryOptions) {
+	o.limit = int(opt)
+}
+
+// Offset skips the first n rows.
+func Offset(n int) QueryOption {
+	return offsetOption(n)
+}
+
+type offsetOption int
+
+func (opt offsetOption) applyQuery(o *queryOptions) {
+	o.offset = int(opt)
+}
+
+// ForUpdate locks the rows read for the rest of the transaction. SQLite
+// locks the whole database for writes instead, so there it has no effect.
+func ForUpdate() QueryOption {
+	return forUpdateOption{}
+}
+
+type forUpdateOption struct{}
+
+func (forUpdateOption) applyQuery(o *queryOptions) {
+	o.forUpdate = true
+}
+
+// applyQuery makes a FieldMask limit a read to the named fields, as it does
+// a write. The primary key is always read.
+func (m FieldMask) applyQuery(o *queryOptions) {
+	o.masked = true
+	o.fields = append(o.fields, m...)
+}
+
+// selectQuery builds the SELECT for LoadWhere and LoadAllWhere, reading
+// rows of table into values of type eltType, a struct pointer type.
+func (d *Database) selectQuery(op, table string, eltType reflect.Type, o *queryOptions) (string, error) {
+	data, err := getFields(eltType)
+	if err != nil {
+		return "", err
+	}
+
+	columns := data.columns
+	if o.masked {
+		wanted := make(map[string]bool)
+		for _, name := range o.fields {
+			column, err := data.resolve(eltType, name)
+			if err != nil {
+				return "", fmt.Errorf("meddler.%s: %w", op, err)
+			}
+			wanted[column] = true
+		}
+		columns = nil
+		for _, column := range data.columns {
+			if wanted[column] || column == data.pk {
+				columns = append(columns, column)
+			}
+		}
+	}
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = d.quoted(column)
+	}
+
+	var q strings.Builder
+	fmt.Fprintf(&q, "SELECT %s FROM %s", strings.Join(quoted, ","), d.quotedTable(table))
+	if o.forUpdate && d.Dialect == DialectSQLServer {
+		q.WriteString(" WITH (UPDLOCK, ROWLOCK)")
+	}
+	if len(o.where) > 0 {
+		n := 0
+		conds := make([]string, len(o.where))
+		for i, cond := range o.where {
+			conds[i], n = d.rebind(cond, n)
+		}
+		if n != len(o.args) {
+			return "", fmt.Errorf("meddler.%s: conditions have %d placeholders but %d arguments", op, n, len(o.args))
+		}
+		q.WriteString(" WHERE (" + strings.Join(conds, ") AND (") + ")")
+	}
+
+	var order []string
+	for _, field := range o.orderBy {
+		name, dir := field, ""
+		if i := strings.LastIndexByte(field, ' '); i >= 0 {
+			switch strings.ToUpper(field[i+1:]) {
+			case "ASC", "DESC":
+				name, dir = strings.TrimSpace(field[:i]), " "+strings.ToUpper(field[i+1:])
+			}
+		}
+		column, err := data.resolve(eltType, name)
+		if err != nil {
+			return "", fmt.Errorf("meddler.%s: %w", op, err)
+		}
+		order = append(order, d.quoted(column)+dir)
+	}
+	if len(order) > 0 {
+		q.WriteString(" ORDER BY " + strings.Join(order, ","))
+	}
+
+	if o.limit >= 0 || o.offset > 0 {
+		q.WriteString(d.limitClause(o.limit, o.offset, len(order) > 0))
+	}
+	if o.forUpdate && (d.Dialect == DialectMySQL || d.Dialect == DialectPostgreSQL) {
+		q.WriteString(" FOR UPDATE")
+	}
+	return q.String(), nil
+}
+
+// limitClause renders a limit and offset for d's dialect. A negative limit
+// means none.
+func (d *Database) limitClause(limit, offset int, ordered bool) string {
+	if d.Dialect == DialectSQLServer {
+		s := ""
+		if !ordered {
+			// OFFSET needs an ORDER BY
+			s = " ORDER BY (SELECT NULL)"
+		}
+		s += " OFFSET " + strconv.Itoa(offset) + " ROWS"
+		if limit >= 0 {
+			s += " FETCH NEXT " + strconv.Itoa(limit) + " ROWS ONLY"
+		}
+		return s
+	}
+
+	s := ""
+	switch {
+	case limit >= 0:
+		s = " LIMIT " + strconv.Itoa(limit)
+	case d.Dialect == DialectSQLite:
+		s = " LIMIT -1"
+	case d.Dialect == DialectMySQL:
+		// MySQL has no OFFSET without LIMIT
+		s = " LIMIT 18446744073709551615"
+	}
+	if offset > 0 {
+		s += " OFFSET " + strconv.Itoa(offset)
+	}
+	return s
+}
+
+// rebind replaces the ? placeholders in cond, outside of quoted strings,
+// with d's placeholders, numbering them after the n already used. It
+// returns the new text and the number of placeholders used in all.
+func (d *Database) rebind(cond string, n int) (string, int) {
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(cond); i++ {
+		c := cond[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?':
+			n++
+			b.WriteString(d.placeholder(n))
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), n
+}
+
+// LoadWhere loads the first row of table that meets the conditions in
+// opts into dst, a pointer to a struct. It returns sql.ErrNoRows if no row
+// does. Without a Limit option, at most one row is fetched.
+func (d *Database) LoadWhere(ctx context.Context, db Querier, table string, dst interface{}, opts ...QueryOption) error {
+	d = d.forTable(table)
+	o := newQueryOptions(opts)
+	if o.limit < 0 {
+		o.limit = 1
+	}
+	q, err := d.selectQuery("LoadWhere", table, reflect.TypeOf(dst), o)
+	if err != nil {
+		return err
+	}
+	return d.QueryRow(ctx, db, dst, q, o.args...)
+}
+
+// LoadWhere using the Default Database type
+func LoadWhere(ctx context.Context, db Querier, table string, dst interface{}, opts ...QueryOption) error {
+	return Default.LoadWhere(ctx, db, table, dst, opts...)
+}
+
+// LoadAllWhere loads the rows of table that meet the conditions in opts
+// into dst, a pointer to a slice, as QueryAll does.
+func (d *Database) LoadAllWhere(ctx context.Context, db Querier, table string, dst interface{}, opts ...QueryOption) error {
+	d = d.forTable(table)
+	dstType := reflect.TypeOf(dst)
+	if dstType == nil || dstType.Kind() != reflect.Ptr || dstType.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("meddler.LoadAllWhere: destination must be a pointer to a slice, found %T", dst)
+	}
+	eltType := dstType.Elem().Elem()
+	if eltType.Kind() == reflect.Struct {
+		eltType = reflect.PtrTo(eltType)
+	}
+
+	o := newQueryOptions(opts)
+	q, err := d.selectQuery("LoadAllWhere", table, eltType, o)
+	if err != nil {
+		return err
+	}
+	return d.QueryAll(ctx, db, dst, q, o.args...)
+}
+
+// LoadAllWhere using the Default Database type
+func LoadAllWhere(ctx context.Context, db Querier, table string, dst interface{}, opts ...QueryOption) error {
+	return Default.LoadAllWhere(ctx, db, table, dst, opts...)
+}
@@ -0,0 +1,84 @@
+package meddlerx
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLoadWhere(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	elt := new(Person)
+	if err := SQLite.LoadWhere(testCtx, db, "person", elt, Where("name = ?", "Bob")); err != nil {
+		t.Fatalf("LoadWhere error: %v", err)
+	}
+	bob.ID = 2
+	personEqual(t, elt, bob)
+
+	if err := SQLite.LoadWhere(testCtx, db, "person", elt, Where("name = ?", "Carol")); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("LoadWhere with no match: expected sql.ErrNoRows, got %v", err)
+	}
+
+	var people []*Person
+	err := SQLite.LoadAllWhere(testCtx, db, "person", &people,
+		Where("id > ?", 0), Where("name <> '?'"), OrderBy("Name DESC"), Fields("name"))
+	if err != nil {
+		t.Fatalf("LoadAllWhere error: %v", err)
+	}
+	if len(people) != 2 || people[0].Name != "Bob" || people[1].Name != "Alice" {
+		t.Fatalf("LoadAllWhere: expected Bob then Alice, got %d rows", len(people))
+	}
+	if people[0].ID != 2 || people[0].Email != "" {
+		t.Errorf("LoadAllWhere with Fields: expected only id and name, got %+v", people[0])
+	}
+
+	people = nil
+	if err := SQLite.LoadAllWhere(testCtx, db, "person", &people, OrderBy("id"), Offset(1)); err != nil {
+		t.Fatalf("LoadAllWhere error: %v", err)
+	}
+	if len(people) != 1 || people[0].Name != "Bob" {
+		t.Errorf("LoadAllWhere with Offset: expected Bob, got %d rows", len(people))
+	}
+
+	if err := SQLite.LoadAllWhere(testCtx, db, "person", &people, OrderBy("nosuchfield")); err == nil {
+		t.Errorf("LoadAllWhere with a bad field: expected err, got nil")
+	}
+	if err := SQLite.LoadAllWhere(testCtx, db, "person", &people, Where("id = ?")); err == nil {
+		t.Errorf("LoadAllWhere with a missing argument: expected err, got nil")
+	}
+}
+
+func TestSelectQuery(t *testing.T) {
+	eltType := reflect.TypeOf(new(Person))
+	opts := []QueryOption{Where("Age > ? AND name = ?", 1, "x"), OrderBy("id DESC"), Limit(10), Offset(20), ForUpdate(), Fields("Name")}
+	tests := []struct {
+		d        *Database
+		expected string
+	}{
+		{PostgreSQL, `SELECT "id","name" FROM "person" WHERE (Age > $1 AND name = $2) ORDER BY "id" DESC LIMIT 10 OFFSET 20 FOR UPDATE`},
+		{MySQL, "SELECT `id`,`name` FROM `person` WHERE (Age > ? AND name = ?) ORDER BY `id` DESC LIMIT 10 OFFSET 20 FOR UPDATE"},
+		{SQLite, `SELECT "id","name" FROM "person" WHERE (Age > ? AND name = ?) ORDER BY "id" DESC LIMIT 10 OFFSET 20`},
+		{SQLServer, `SELECT "id","name" FROM "person" WITH (UPDLOCK, ROWLOCK) WHERE (Age > @p1 AND name = @p2) ORDER BY "id" DESC OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY`},
+	}
+	for _, test := range tests {
+		q, err := test.d.selectQuery("LoadAllWhere", "person", eltType, newQueryOptions(opts))
+		if err != nil {
+			t.Fatalf("selectQuery error: %v", err)
+		}
+		if q != test.expected {
+			t.Errorf("selectQuery for %s:\nexpected %s\ngot      %s", test.d.Dialect, test.expected, q)
+		}
+	}
+
+	q, err := SQLServer.selectQuery("LoadAllWhere", "person", eltType, newQueryOptions([]QueryOption{Offset(5)}))
+	if err != nil {
+		t.Fatalf("selectQuery error: %v", err)
+	}
+	if expected := ` ORDER BY (SELECT NULL) OFFSET 5 ROWS`; q[len(q)-len(expected):] != expected {
+		t.Errorf("selectQuery with only Offset for SQL Server: got %s", q)
+	}
+}
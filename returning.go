@@ -0,0 +1,66 @@
+package meddlerx
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DetectReturning asks the server whether it supports INSERT ... RETURNING,
+// so that UseReturningToGetID can be set to match. PostgreSQL always does,
+// SQLite does from 3.35, and MariaDB from 10.5; MySQL does not. For
+// example:
+//
+//	sqlite := *meddlerx.SQLite
+//	sqlite.UseReturningToGetID, err = sqlite.DetectReturning(ctx, db)
+//
+// Dialects it does not know about report false.
+func (d *Database) DetectReturning(ctx context.Context, db Querier) (bool, error) {
+	var q string
+	switch d.Dialect {
+	case DialectPostgreSQL:
+		return true, nil
+	case DialectSQLite:
+		q = "SELECT sqlite_version()"
+	case DialectMySQL:
+		q = "SELECT VERSION()"
+	default:
+		return false, nil
+	}
+
+	var version string
+	if err := db.QueryRowContext(ctx, q).Scan(&version); err != nil {
+		return false, d.queryError("DetectReturning", "", q, nil, err)
+	}
+
+	if d.Dialect == DialectSQLite {
+		return versionAtLeast(version, 3, 35)
+	}
+	if !strings.Contains(strings.ToLower(version), "mariadb") {
+		return false, nil
+	}
+	return versionAtLeast(version, 10, 5)
+}
+
+// versionAtLeast reports whether a version string such as "10.6.12-MariaDB"
+// is at least major.minor.
+func versionAtLeast(version string, major, minor int) (bool, error) {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return false, fmt.Errorf("meddler.DetectReturning: cannot parse version %q", version)
+	}
+	gotMajor, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false, fmt.Errorf("meddler.DetectReturning: cannot parse version %q", version)
+	}
+	digits := strings.IndexFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' })
+	if digits >= 0 {
+		parts[1] = parts[1][:digits]
+	}
+	gotMinor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false, fmt.Errorf("meddler.DetectReturning: cannot parse version %q", version)
+	}
+	return gotMajor > major || gotMajor == major && gotMinor >= minor, nil
+}
@@ -0,0 +1,62 @@
+package meddlerx
+
+import (
+	"testing"
+)
+
+func TestDetectReturning(t *testing.T) {
+	once.Do(setup)
+
+	ok, err := SQLite.DetectReturning(testCtx, db)
+	if err != nil || !ok {
+		t.Fatalf("DetectReturning on SQLite: got %v, %v", ok, err)
+	}
+
+	for _, c := range []struct {
+		version      string
+		major, minor int
+		expected     bool
+	}{
+		{"3.35.0", 3, 35, true},
+		{"3.34.1", 3, 35, false},
+		{"10.11.6-MariaDB-0+deb12u1", 10, 5, true},
+		{"10.4.32-MariaDB", 10, 5, false},
+		{"11.0", 10, 5, true},
+	} {
+		if got, err := versionAtLeast(c.version, c.major, c.minor); err != nil || got != c.expected {
+			t.Errorf("versionAtLeast(%s, %d, %d): got %v, %v", c.version, c.major, c.minor, got, err)
+		}
+	}
+}
+
+func TestSQLiteReturning(t *testing.T) {
+	setupEvents(t)
+	defer db.Exec("drop table event")
+
+	sqlite := *SQLite
+	sqlite.UseReturningToGetID = true
+
+	first := &event{Key: "a", Payload: "one"}
+	if err := sqlite.Insert(testCtx, db, "event", first); err != nil {
+		t.Fatalf("Insert with RETURNING: %v", err)
+	}
+	if first.ID != 1 {
+		t.Errorf("Insert with RETURNING: expected pk 1, got %d", first.ID)
+	}
+
+	inserted, err := sqlite.InsertIgnore(testCtx, db, "event", &event{Key: "a"})
+	if err != nil || inserted {
+		t.Errorf("InsertIgnore with RETURNING on duplicate: got %v, %v", inserted, err)
+	}
+
+	second := &event{Key: "a", Payload: "two"}
+	inserted, err = sqlite.Upsert(testCtx, db, "event", second, "key")
+	if err != nil || inserted || second.ID != 1 {
+		t.Errorf("Upsert with RETURNING: got inserted=%v pk=%d err=%v", inserted, second.ID, err)
+	}
+	third := &event{Key: "b", Payload: "three"}
+	inserted, err = sqlite.Upsert(testCtx, db, "event", third, "key")
+	if err != nil || !inserted || third.ID != 2 {
+		t.Errorf("Upsert with RETURNING: got inserted=%v pk=%d err=%v", inserted, third.ID, err)
+	}
+}
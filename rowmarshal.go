@@ -0,0 +1,67 @@
+package meddlerx
+
+import "fmt"
+
+// RowMarshaler can be implemented by a struct to produce its own columns
+// and values for Insert, Update, and Save instead of having them read from
+// its fields by reflection. The primary key is still found from the struct
+// tags, and its column is dropped from the result where it is not written.
+// FieldMask options select from the returned columns by column name.
+type RowMarshaler interface {
+	MarshalRow() (columns []string, values []interface{}, err error)
+}
+
+// RowUnmarshaler can be implemented by a struct to scan rows itself. It is
+// given the column names of the result and the scan function of the row,
+// which it must call exactly once with one target per column.
+type RowUnmarshaler interface {
+	UnmarshalRow(columns []string, scan func(dest ...interface{}) error) error
+}
+
+// writeValues returns the columns of src to write and their values.
+func (d *Database) writeValues(src interface{}, includePk bool, o *writeOptions) ([]string, []interface{}, error) {
+	m, ok := src.(RowMarshaler)
+	if !ok {
+		names, err := d.writeColumns(src, includePk, o)
+		if err != nil {
+			return nil, nil, err
+		}
+		values, err := d.SomeValues(src, names)
+		if err != nil {
+			return nil, nil, err
+		}
+		return names, values, nil
+	}
+
+	columns, values, err := m.MarshalRow()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(columns) != len(values) {
+		return nil, nil, fmt.Errorf("meddler.MarshalRow: %d columns but %d values", len(columns), len(values))
+	}
+	pkName, _, err := d.PrimaryKey(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	wanted := make(map[string]bool)
+	if o != nil && o.masked {
+		for _, name := range o.fields {
+			wanted[name] = true
+		}
+	}
+
+	var names []string
+	var kept []interface{}
+	for i, column := range columns {
+		if column == pkName && !includePk {
+			continue
+		}
+		if o != nil && o.masked && !wanted[column] && column != pkName {
+			continue
+		}
+		names = append(names, column)
+		kept = append(kept, values[i])
+	}
+	return names, kept, nil
+}
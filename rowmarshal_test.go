@@ -0,0 +1,94 @@
+package meddlerx
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// sparseItem maps whatever columns it is given to a map, EAV style.
+type sparseItem struct {
+	ID    int64                  `meddler:"id,pk"`
+	Attrs map[string]interface{} `meddler:"-"`
+}
+
+func (s *sparseItem) MarshalRow() ([]string, []interface{}, error) {
+	columns := []string{"id"}
+	for column := range s.Attrs {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns[1:])
+	values := []interface{}{s.ID}
+	for _, column := range columns[1:] {
+		values = append(values, s.Attrs[column])
+	}
+	return columns, values, nil
+}
+
+func (s *sparseItem) UnmarshalRow(columns []string, scan func(dest ...interface{}) error) error {
+	values := make([]interface{}, len(columns))
+	targets := make([]interface{}, len(columns))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+	if err := scan(targets...); err != nil {
+		return err
+	}
+	s.Attrs = make(map[string]interface{})
+	for i, column := range columns {
+		if column == "id" {
+			s.ID = values[i].(int64)
+			continue
+		}
+		if b, ok := values[i].([]byte); ok {
+			values[i] = string(b)
+		}
+		s.Attrs[column] = values[i]
+	}
+	return nil
+}
+
+func TestRowMarshaler(t *testing.T) {
+	once.Do(setup)
+	defer db.Exec("delete from item")
+
+	rec := new(recordingQuerier)
+	item := &sparseItem{Attrs: map[string]interface{}{"stuff": "a", "stuffz": "b"}}
+	if err := SQLite.Insert(testCtx, rec, "item", item); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	if expected := `INSERT INTO "item" ("stuff","stuffz") VALUES (?,?)`; rec.query != expected {
+		t.Errorf("Insert: expected %s, got %s", expected, rec.query)
+	}
+
+	item.Attrs["stuff"] = "c"
+	if err := SQLite.Update(testCtx, rec, "item", item, Fields("stuff")); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	if expected := `UPDATE "item" SET "stuff"=? WHERE "id"=?`; rec.query != expected {
+		t.Errorf("Update: expected %s, got %s", expected, rec.query)
+	}
+
+	var items []*sparseItem
+	if err := QueryAll(testCtx, db, &items, "select * from item"); err != nil {
+		t.Fatalf("QueryAll error: %v", err)
+	}
+	expected := []*sparseItem{{ID: item.ID, Attrs: map[string]interface{}{"stuff": "c", "stuffz": "b"}}}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf("QueryAll: expected %+v, got %+v", expected[0], items[0])
+	}
+
+	rows, err := db.Query("select id, stuff from item")
+	if err != nil {
+		t.Fatalf("DB error: %v", err)
+	}
+	defer rows.Close()
+	rows.Next()
+	one := new(sparseItem)
+	if err := ScanCurrentRow(rows, one); err != nil {
+		t.Fatalf("ScanCurrentRow error: %v", err)
+	}
+	if one.ID != item.ID || len(one.Attrs) != 1 || one.Attrs["stuff"] != "c" {
+		t.Errorf("ScanCurrentRow: got %+v", one)
+	}
+}
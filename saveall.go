@@ -0,0 +1,186 @@
+package meddlerx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+// TxBeginner is implemented by handles that can start a transaction, such
+// as *sql.DB and *sql.Conn.
+type TxBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// SaveAll saves every element of src, which must be a slice of pointers to
+// structs or of structs, inserting those with a zero primary key and
+// updating the rest as Save does. If db can begin a transaction (a *sql.DB
+// or *sql.Conn), the elements are saved in a new transaction that is
+// committed only if all of them succeed; otherwise, e.g. for a *sql.Tx, they
+// are saved with db as given and the caller decides whether to commit.
+// In a new transaction, OnChange hears of the writes only once it commits.
+//
+// By default SaveAll stops at the first error. With ContinueOnError it
+// saves every element it can. Either way, failures are reported as a
+// *BatchError. The primary keys of inserted elements that were not saved
+// in the end are reset to zero, so the same slice can be saved again.
+func (d *Database) SaveAll(ctx context.Context, db Querier, table string, src interface{}, opts ...WriteOption) error {
+	if err := d.writable("SaveAll", table); err != nil {
+		return err
+	}
+	return d.writeAll(ctx, db, "SaveAll", src, opts, func(q Querier, elt interface{}) error {
+		return d.Save(ctx, q, table, elt, opts...)
+	})
+}
+
+// SaveAll using the Default Database type
+func SaveAll(ctx context.Context, db Querier, table string, src interface{}, opts ...WriteOption) error {
+	return Default.SaveAll(ctx, db, table, src, opts...)
+}
+
+// InsertAll inserts every element of src as Insert does, in a transaction
+// and with errors reported as for SaveAll.
+func (d *Database) InsertAll(ctx context.Context, db Querier, table string, src interface{}, opts ...WriteOption) error {
+	if err := d.writable("InsertAll", table); err != nil {
+		return err
+	}
+	return d.writeAll(ctx, db, "InsertAll", src, opts, func(q Querier, elt interface{}) error {
+		return d.Insert(ctx, q, table, elt, opts...)
+	})
+}
+
+// InsertAll using the Default Database type
+func InsertAll(ctx context.Context, db Querier, table string, src interface{}, opts ...WriteOption) error {
+	return Default.InsertAll(ctx, db, table, src, opts...)
+}
+
+// writeAll runs write for each element of src, as described for SaveAll.
+func (d *Database) writeAll(ctx context.Context, db Querier, op string, src interface{}, opts []WriteOption, write func(q Querier, elt interface{}) error) error {
+	elts, err := sliceElements(op, src)
+	if err != nil {
+		return err
+	}
+	if len(elts) == 0 {
+		return nil
+	}
+	o := newWriteOptions(opts)
+
+	// events are queued on the transaction and published once it commits
+	var tx *EventTx
+	if beginner, ok := db.(TxBeginner); ok {
+		sqlTx, err := beginner.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("meddler.%s: %w", op, err)
+		}
+		tx = &EventTx{Tx: sqlTx, d: d, ctx: ctx}
+		db = tx
+	}
+	savepoints := o.continueOnError && inTx(db)
+	events, _ := db.(*EventTx)
+
+	batchErr := &BatchError{Op: op}
+	var inserted []interface{}
+	for i, elt := range elts {
+		_, pk, err := d.PrimaryKey(elt)
+		if err == nil && savepoints {
+			err = d.savepoint(ctx, db, "meddler_element", "SAVEPOINT", "SAVE TRANSACTION")
+		}
+		if err == nil {
+			queued := events.queued()
+			err = write(db, elt)
+			if savepoints {
+				if err != nil {
+					events.discard(queued)
+					if rbErr := d.savepoint(ctx, db, "meddler_element", "ROLLBACK TO SAVEPOINT", "ROLLBACK TRANSACTION"); rbErr != nil {
+						// the transaction is unusable, so give up on it
+						o.continueOnError = false
+					}
+				} else if d.Dialect != DialectSQLServer {
+					err = d.savepoint(ctx, db, "meddler_element", "RELEASE SAVEPOINT", "")
+				}
+			}
+		}
+		if err != nil {
+			if pk == 0 {
+				d.SetPrimaryKey(elt, 0)
+			}
+			batchErr.Errors = append(batchErr.Errors, ElementError{Index: i, Err: err})
+			if !o.continueOnError {
+				break
+			}
+			continue
+		}
+		if pk == 0 {
+			inserted = append(inserted, elt)
+		}
+	}
+
+	failed := len(batchErr.Errors) > 0
+	keep := !failed || o.continueOnError
+	if tx != nil {
+		if keep {
+			err = tx.Commit()
+		} else {
+			err = tx.Rollback()
+		}
+		if !keep || err != nil {
+			for _, elt := range inserted {
+				d.SetPrimaryKey(elt, 0)
+			}
+		}
+		if keep && err != nil {
+			return fmt.Errorf("meddler.%s: %w", op, err)
+		}
+	}
+	if !failed {
+		return nil
+	}
+	batchErr.Saved = keep
+	return batchErr
+}
+
+// savepoint sends a statement for the savepoint name, using the SQL Server
+// form if needed. An empty statement is skipped.
+func (d *Database) savepoint(ctx context.Context, db Querier, name, stmt, sqlServerStmt string) error {
+	if d.Dialect == DialectSQLServer {
+		stmt = sqlServerStmt
+	}
+	if stmt == "" {
+		return nil
+	}
+	q := stmt + " " + d.quoted(name)
+	if _, err := db.ExecContext(ctx, q); err != nil {
+		return d.queryError("Savepoint", "", q, nil, err)
+	}
+	return nil
+}
+
+// sliceElements returns pointers to the structs in src, a slice (or pointer
+// to a slice) of structs or of pointers to structs.
+func sliceElements(op string, src interface{}) ([]interface{}, error) {
+	srcVal := reflect.ValueOf(src)
+	if srcVal.Kind() == reflect.Ptr && srcVal.Elem().Kind() == reflect.Slice {
+		srcVal = srcVal.Elem()
+	}
+	if srcVal.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("meddler.%s: source must be a slice, found %T", op, src)
+	}
+	eltType := srcVal.Type().Elem()
+	byValue := eltType.Kind() == reflect.Struct
+	if !byValue && (eltType.Kind() != reflect.Ptr || eltType.Elem().Kind() != reflect.Struct) {
+		return nil, fmt.Errorf("meddler.%s: source elements must be structs or pointers to structs, found %T", op, src)
+	}
+
+	elts := make([]interface{}, srcVal.Len())
+	for i := range elts {
+		elt := srcVal.Index(i)
+		if byValue {
+			elt = elt.Addr()
+		} else if elt.IsNil() {
+			return nil, fmt.Errorf("meddler.%s: element %d is nil", op, i)
+		}
+		elts[i] = elt.Interface()
+	}
+	return elts, nil
+}
@@ -0,0 +1,144 @@
+package meddlerx
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSaveAll(t *testing.T) {
+	setupEvents(t)
+	defer db.Exec("drop table event")
+
+	existing := &event{Key: "a", Payload: "old"}
+	if err := SQLite.Insert(testCtx, db, "event", existing); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+
+	existing.Payload = "new"
+	events := []*event{existing, {Key: "b", Payload: "b"}, {Key: "c", Payload: "c"}}
+	if err := SQLite.SaveAll(testCtx, db, "event", events); err != nil {
+		t.Fatalf("SaveAll error: %v", err)
+	}
+	var saved []*event
+	if err := SQLite.QueryAll(testCtx, db, &saved, "select * from event order by id"); err != nil {
+		t.Fatalf("QueryAll error: %v", err)
+	}
+	if len(saved) != 3 || saved[0].Payload != "new" || saved[2].ID != events[2].ID {
+		t.Errorf("SaveAll: got %+v", saved)
+	}
+
+	// a failure rolls back everything and resets the new keys
+	values := []event{{Key: "d"}, {Key: "a"}}
+	err := SQLite.SaveAll(testCtx, db, "event", values)
+	if err == nil || !strings.Contains(err.Error(), "element 1") {
+		t.Fatalf("SaveAll with a duplicate key: expected an error for element 1, got %v", err)
+	}
+	if values[0].ID != 0 {
+		t.Errorf("SaveAll: expected the key of the rolled-back insert to be reset, got %d", values[0].ID)
+	}
+	var count int
+	if err := db.QueryRow("select count(*) from event").Scan(&count); err != nil || count != 3 {
+		t.Errorf("SaveAll: expected 3 rows after rollback, got %d, %v", count, err)
+	}
+
+	// inside the caller's transaction, nothing is committed or rolled back
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Begin error: %v", err)
+	}
+	if err := SQLite.SaveAll(testCtx, tx, "event", []*event{{Key: "e"}}); err != nil {
+		t.Fatalf("SaveAll in a transaction error: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback error: %v", err)
+	}
+	if err := db.QueryRow("select count(*) from event").Scan(&count); err != nil || count != 3 {
+		t.Errorf("SaveAll in a transaction: expected 3 rows after rollback, got %d, %v", count, err)
+	}
+
+	if err := SQLite.SaveAll(testCtx, db, "event", []string{"x"}); err == nil {
+		t.Errorf("SaveAll with a slice of strings: expected an error")
+	}
+}
+
+func TestInsertAllContinueOnError(t *testing.T) {
+	setupEvents(t)
+	defer db.Exec("drop table event")
+
+	events := []*event{{Key: "a"}, {Key: "a"}, {Key: "b"}, {Key: "b"}}
+	err := SQLite.InsertAll(testCtx, db, "event", events, ContinueOnError())
+	batchErr, ok := err.(*BatchError)
+	if !ok {
+		t.Fatalf("InsertAll with duplicates: expected a *BatchError, got %v", err)
+	}
+	if failed := batchErr.Failed(); len(failed) != 2 || failed[0] != 1 || failed[1] != 3 || !batchErr.Saved {
+		t.Errorf("InsertAll: expected elements 1 and 3 to fail and the rest saved, got %v, saved=%v", failed, batchErr.Saved)
+	}
+	if events[0].ID == 0 || events[1].ID != 0 || events[2].ID == 0 || events[3].ID != 0 {
+		t.Errorf("InsertAll: expected keys only on saved elements, got %d %d %d %d",
+			events[0].ID, events[1].ID, events[2].ID, events[3].ID)
+	}
+	var count int
+	if err := db.QueryRow("select count(*) from event").Scan(&count); err != nil || count != 2 {
+		t.Errorf("InsertAll: expected 2 rows, got %d, %v", count, err)
+	}
+
+	// without the option, the first failure undoes everything
+	err = SQLite.InsertAll(testCtx, db, "event", []*event{{Key: "c"}, {Key: "a"}, {Key: "a"}})
+	batchErr, ok = err.(*BatchError)
+	if !ok || len(batchErr.Errors) != 1 || batchErr.Errors[0].Index != 1 || batchErr.Saved {
+		t.Errorf("InsertAll: expected element 1 to fail and nothing saved, got %v", err)
+	}
+	if err := db.QueryRow("select count(*) from event").Scan(&count); err != nil || count != 2 {
+		t.Errorf("InsertAll: expected 2 rows after rollback, got %d, %v", count, err)
+	}
+}
+
+func TestSaveAllOnChange(t *testing.T) {
+	setupEvents(t)
+	defer db.Exec("drop table event")
+
+	var published []ChangeEvent
+	d := SQLite.Clone()
+	d.OnChange = func(ctx context.Context, event ChangeEvent) {
+		published = append(published, event)
+	}
+
+	// events are published once the transaction commits
+	existing := &event{Key: "a", Payload: "old"}
+	if err := d.InsertAll(testCtx, db, "event", []*event{existing}); err != nil {
+		t.Fatalf("InsertAll error: %v", err)
+	}
+	existing.Payload = "new"
+	if err := d.SaveAll(testCtx, db, "event", []*event{existing, {Key: "b"}}); err != nil {
+		t.Fatalf("SaveAll error: %v", err)
+	}
+	var ops []WriteOp
+	for _, event := range published {
+		ops = append(ops, event.Op)
+	}
+	if expected := []WriteOp{OpInsert, OpUpdate, OpInsert}; !reflect.DeepEqual(ops, expected) {
+		t.Errorf("OnChange: expected %v, got %v", expected, ops)
+	}
+
+	// only for the elements that were saved
+	published = nil
+	err := d.InsertAll(testCtx, db, "event", []*event{{Key: "c"}, {Key: "a"}}, ContinueOnError())
+	if _, ok := err.(*BatchError); !ok {
+		t.Fatalf("InsertAll with a duplicate: expected a *BatchError, got %v", err)
+	}
+	if len(published) != 1 || published[0].Record.(*event).Key != "c" {
+		t.Errorf("OnChange: expected only the insert of c, got %+v", published)
+	}
+
+	// and not at all if it rolls back
+	published = nil
+	if err := d.InsertAll(testCtx, db, "event", []*event{{Key: "d"}, {Key: "a"}}); err == nil {
+		t.Fatalf("InsertAll with a duplicate: expected an error")
+	}
+	if len(published) != 0 {
+		t.Errorf("OnChange: expected no events after rollback, got %+v", published)
+	}
+}
@@ -1,25 +1,127 @@
 package meddlerx
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"reflect"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // the name of our struct tag
 const tagName = "meddler"
 
+// Dialect identifies the SQL flavor spoken by a database server. It is
+// used by the helpers that generate dialect-specific statements, such as
+// CreateTableSQL.
+type Dialect string
+
+// Dialects known to meddler
+const (
+	DialectMySQL      Dialect = "mysql"
+	DialectPostgreSQL Dialect = "postgres"
+	DialectSQLite     Dialect = "sqlite"
+	DialectSQLServer  Dialect = "sqlserver"
+	DialectANSI       Dialect = "ansi"
+)
+
 // Database contains database-specific options.
-// MySQL, PostgreSQL, and SQLite are provided for convenience.
+// MySQL, MariaDB, PostgreSQL, SQLite, and SQLServer are provided for convenience.
 // Setting Default to any of these lets you use the package-level convenience functions.
+//
+// A Database is safe for concurrent use as long as its fields are not
+// changed while it is in use; that includes the presets and Default. To
+// derive a different configuration, use New or Clone instead of modifying
+// a shared Database. This is a convention only: the fields stay exported,
+// as code written for meddler sets them directly, so nothing stops a
+// caller from changing them.
 type Database struct {
-	Quote               string // the quote character for table and column names
-	Placeholder         string // the placeholder style to use in generated queries
-	UseReturningToGetID bool   // use PostgreSQL-style RETURNING "ID" instead of calling sql.Result.LastInsertID
+	Quote               string   // the quote character for table and column names
+	Placeholder         string   // the placeholder style to use in generated queries: "?", or numbered from a template such as "$1", "@p1", or ":1"
+	UseReturningToGetID bool     // use RETURNING "ID" (PostgreSQL, SQLite 3.35+, MariaDB 10.5+) instead of calling sql.Result.LastInsertID
+	VerboseErrors       bool     // include generated SQL and bound arguments in QueryError messages
+	Dialect             Dialect  // the SQL flavor used for generated DDL and other dialect-specific statements
+	AllowZeroPK         bool     // let Update and Delete target rows whose primary key is zero or negative
+	SaveMode            SaveMode // what Save does when no row has the record's primary key
+	ReadOnly            bool     // make Insert, Update, Save, Delete, and other writes fail with ErrReadOnly
+
+	// PlaceholderFunc, if set, returns the placeholder for the nth argument
+	// of a generated query, counting from 1, in place of Placeholder. It is
+	// for drivers whose style cannot be written as a template.
+	PlaceholderFunc func(n int) string
+
+	// StatementTimeout limits how long each operation may run, if set.
+	// It can be overridden per call with WithStatementTimeout.
+	StatementTimeout time.Duration
+
+	// CancelOnDeadline makes QueryAll on a *sql.DB ask a PostgreSQL or
+	// MySQL server to stop the query when the context deadline passes,
+	// rather than only closing the rows, so that an abandoned query does
+	// not keep running on the server. See QueryAll.
+	CancelOnDeadline bool
+
+	// Audit, if set, is told about every successful Insert, Update, Save,
+	// Delete, InsertIgnore, Upsert, SaveOrUpdateOn, and Batch. If
+	// AuditOldValues is also set, the previous contents of rows changed by
+	// Update, Delete, and Batch are read first and passed along; Upsert and
+	// SaveOrUpdateOn do not know which row they will change in advance and
+	// report no old values.
+	Audit          AuditHook
+	AuditOldValues bool
+
+	// History lists tables whose old row versions are kept in a history
+	// table; see LoadAsOf.
+	History map[string]bool
+
+	// Tables holds settings that differ for particular tables, keyed by
+	// table name as passed to meddler functions. See TableConfig.
+	Tables map[string]TableConfig
+
+	// OnChange, if set, is called after each successful write made by the
+	// same functions as Audit, once it has been committed. See EventTx.
+	OnChange func(ctx context.Context, event ChangeEvent)
+
+	// Cache, if set, is consulted by Load before querying, and entries are
+	// invalidated by every write of a record: Insert, Update, Save, Delete,
+	// Upsert, SaveOrUpdateOn, and Batch. Other writes, such as DeleteAll or
+	// raw queries, do not invalidate it. CacheTTL is passed to Cache.Set;
+	// zero means no expiry.
+	Cache    Cache
+	CacheTTL time.Duration
+
+	// OnScan, if set, receives statistics for every ScanAll and QueryAll.
+	OnScan func(ctx context.Context, stats *ScanStats)
+
+	// ExplainThreshold, if set, makes Load and QueryAll log the query plan
+	// (see Explain) of any SELECT that takes at least this long. The plan
+	// is estimated with EXPLAIN, even on PostgreSQL, so the query is not run
+	// a second time; other slow statements are logged without a plan.
+	ExplainThreshold time.Duration
+
+	// QueryTags, if set, returns tags that are added to every query meddler
+	// sends as a trailing sqlcommenter-style comment, so that server-side
+	// logs can be tied back to the application. op is the meddler function
+	// (e.g. "Load") and table may be empty. See SQLCommenter.
+	QueryTags func(ctx context.Context, op, table string) map[string]string
+
+	// Coercion selects how scanned values are converted to the types of
+	// their fields. See Coercion.
+	Coercion Coercion
+
+	// NullAsZero makes scans store NULL in a field that cannot hold it,
+	// such as an int or string, as the field's zero value instead of
+	// failing with a ScanError.
+	NullAsZero bool
+
+	// Logger receives debug messages when Debug is set. If nil, they go to
+	// the standard logger.
+	Logger Logger
 }
 
 // MySQL contains database specific options for executing queries in a MySQL database
@@ -27,6 +129,7 @@ var MySQL = &Database{
 	Quote:               "`",
 	Placeholder:         "?",
 	UseReturningToGetID: false,
+	Dialect:             DialectMySQL,
 }
 
 // PostgreSQL contains database specific options for executing queries in a PostgreSQL database
@@ -34,6 +137,16 @@ var PostgreSQL = &Database{
 	Quote:               `"`,
 	Placeholder:         "$1",
 	UseReturningToGetID: true,
+	Dialect:             DialectPostgreSQL,
+}
+
+// MariaDB contains database specific options for executing queries in a
+// MariaDB 10.5 or later database, which supports INSERT ... RETURNING
+var MariaDB = &Database{
+	Quote:               "`",
+	Placeholder:         "?",
+	UseReturningToGetID: true,
+	Dialect:             DialectMySQL,
 }
 
 // SQLite contains database specific options for executing queries in a SQLite database
@@ -41,33 +154,151 @@ var SQLite = &Database{
 	Quote:               `"`,
 	Placeholder:         "?",
 	UseReturningToGetID: false,
+	Dialect:             DialectSQLite,
+}
+
+// SQLServer contains database specific options for executing queries in a Microsoft SQL Server database
+var SQLServer = &Database{
+	Quote:               `"`,
+	Placeholder:         "@p1",
+	UseReturningToGetID: false,
+	Dialect:             DialectSQLServer,
+}
+
+// ANSI contains options for servers that follow standard SQL, such as
+// CockroachDB and H2: identifiers are double-quoted, string literals
+// escape a quote by doubling it, and generated primary keys are identity
+// columns. It uses "?" placeholders; for servers that speak the PostgreSQL
+// protocol, such as CockroachDB, use
+// ANSI.Clone(WithPlaceholder("$1"), WithReturning(true)). For MySQL in
+// ANSI_QUOTES mode, use MySQL.Clone(WithQuote(`"`)).
+var ANSI = &Database{
+	Quote:               `"`,
+	Placeholder:         "?",
+	UseReturningToGetID: false,
+	Dialect:             DialectANSI,
 }
 
 // Default contains the default database options (which defaults to MySQL)
 var Default = MySQL
 
 func (d *Database) quoted(s string) string {
-	return d.Quote + s + d.Quote
+	return quoteIdentifier(d.Quote, s)
 }
 
+// quoteIdentifier quotes s with quote, doubling any quote characters in s
+// as standard SQL and MySQL both expect.
+func quoteIdentifier(quote, s string) string {
+	if quote == "" {
+		return s
+	}
+	return quote + strings.ReplaceAll(s, quote, quote+quote) + quote
+}
+
+// QuoteIdentifier returns name quoted as an identifier, such as a column
+// name, with Quote, for use in hand-written SQL.
+func (d *Database) QuoteIdentifier(name string) string {
+	return d.quoted(name)
+}
+
+// QuoteIdentifier using the Default Database type
+func QuoteIdentifier(name string) string {
+	return Default.QuoteIdentifier(name)
+}
+
+// placeholder returns the placeholder for the nth argument of a query. A
+// Placeholder template is numbered by replacing its "1".
 func (d *Database) placeholder(n int) string {
+	if d.PlaceholderFunc != nil {
+		return d.PlaceholderFunc(n)
+	}
 	return strings.Replace(d.Placeholder, "1", strconv.FormatInt(int64(n), 10), 1)
 }
 
 // Debug enables debug mode, where unused columns and struct fields will be logged
 var Debug = true
 
+// Logger is the interface used for debug output. *log.Logger implements it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+func (d *Database) logf(format string, v ...interface{}) {
+	if d.Logger != nil {
+		d.Logger.Printf(format, v...)
+		return
+	}
+	log.Printf(format, v...)
+}
+
 type structField struct {
 	column     string
-	index      int
+	index      []int // the field's index path, as for reflect.Value.FieldByIndex
 	primaryKey bool
 	meddler    Meddler
 }
 
 type structData struct {
+	columns   []string
+	fields    map[string]*structField
+	pk        string
+	relations map[string]*relation     // keyed by Go field name
+	defaults  map[string]*fieldDefault // keyed by column name
+	generated map[string]bool          // columns computed by the database
+	meta      map[string]*columnMeta   // keyed by column name; see Describe
+
+	plans     sync.Map // query text to *scanPlan; see plan
+	planCount int32    // the number of queries in plans
+}
+
+// scanPlan records which field, if any, receives each column of a result.
+type scanPlan struct {
 	columns []string
-	fields  map[string]*structField
-	pk      string
+	fields  []*structField // nil where the struct has no field for the column
+}
+
+// maxScanPlans limits how many queries have cached plans for each struct
+// type, so that queries built on the fly cannot grow the cache without
+// bound.
+const maxScanPlans = 1024
+
+// plan returns the scan plan for a result with the given columns. Plans
+// are cached by the text of the query that produced the result, which may
+// be empty if it is not known, in which case nothing is cached. A cached
+// plan is only used for a result with the same columns, so a query such as
+// SELECT * keeps working when the table changes.
+func (data *structData) plan(query string, columns []string) *scanPlan {
+	var known bool
+	if query != "" {
+		var cached interface{}
+		if cached, known = data.plans.Load(query); known {
+			if plan := cached.(*scanPlan); plan.matches(columns) {
+				return plan
+			}
+		}
+	}
+
+	plan := &scanPlan{columns: columns, fields: make([]*structField, len(columns))}
+	for i, name := range columns {
+		plan.fields[i] = data.fields[name]
+	}
+	if query != "" && (known || atomic.AddInt32(&data.planCount, 1) <= maxScanPlans) {
+		data.plans.Store(query, plan)
+	}
+	return plan
+}
+
+// matches reports whether the plan was made for these columns.
+func (plan *scanPlan) matches(columns []string) bool {
+	if len(columns) != len(plan.columns) {
+		return false
+	}
+	for i, name := range columns {
+		if plan.columns[i] != name {
+			return false
+		}
+	}
+	return true
 }
 
 // cache reflection data
@@ -95,7 +326,24 @@ func getFields(dstType reflect.Type) (*structData, error) {
 	// gather the list of fields in the struct
 	data := new(structData)
 	data.fields = make(map[string]*structField)
+	data.relations = make(map[string]*relation)
+	data.defaults = make(map[string]*fieldDefault)
+	data.generated = make(map[string]bool)
+	data.meta = make(map[string]*columnMeta)
 
+	if err := data.addFields(structType, nil, ""); err != nil {
+		return nil, err
+	}
+
+	fieldsCache[dstType] = data
+	return data, nil
+}
+
+// addFields adds the columns for the fields of structType, which is found
+// at path within the top-level struct. Fields tagged prefix= must be
+// structs, and their fields are added in place as columns named with the
+// prefix; primary key options inside them are ignored.
+func (data *structData) addFields(structType reflect.Type, path []int, prefix string) error {
 	for i := 0; i < structType.NumField(); i++ {
 		f := structType.Field(i)
 
@@ -109,8 +357,20 @@ func getFields(dstType reflect.Type) (*structData, error) {
 
 		// was this field marked for skipping?
 		if len(tag) > 0 && tag[0] == "-" {
+			// skipped fields may still be filled in by Preload
+			if path != nil {
+				continue
+			}
+			rel, err := parseRelation(f, i, tag[1:])
+			if err != nil {
+				return err
+			}
+			if rel != nil {
+				data.relations[f.Name] = rel
+			}
 			continue
 		}
+		index := append(append([]int(nil), path...), i)
 
 		// default to the field name
 		name := f.Name
@@ -123,12 +383,26 @@ func getFields(dstType reflect.Type) (*structData, error) {
 			name = Mapper(f.Name)
 		}
 
-		// check for a meddler
+		// check for a meddler, starting with one the type provides itself
 		var meddler Meddler = registry["identity"]
+		if reflect.PtrTo(f.Type).Implements(fieldMeddlerType) {
+			meddler = fieldMeddlerAdapter{}
+		} else if f.Type == rawBytesType {
+			meddler = BytesMeddler(false)
+		}
+		nested, nestedPrefix := false, ""
+		var def *fieldDefault
+		var meta *columnMeta
+		generated := false
 		for j := 1; j < len(tag); j++ {
-			if tag[j] == "pk" {
+			if tag[j] == "generated" {
+				generated = true
+			} else if tag[j] == "pk" && path != nil {
+				// the key of a nested struct is an ordinary column here
+				continue
+			} else if tag[j] == "pk" {
 				if f.Type.Kind() == reflect.Ptr {
-					return nil, fmt.Errorf("meddler found field %s which is marked as the primary key but is a pointer", f.Name)
+					return fmt.Errorf("meddler found field %s which is marked as the primary key but is a pointer", f.Name)
 				}
 
 				// make sure it is an int of some kind
@@ -136,37 +410,87 @@ func getFields(dstType reflect.Type) (*structData, error) {
 				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 				case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 				default:
-					return nil, fmt.Errorf("meddler found field %s which is marked as the primary key, but is not an integer type", f.Name)
+					return fmt.Errorf("meddler found field %s which is marked as the primary key, but is not an integer type", f.Name)
 				}
 
 				if data.pk != "" {
-					return nil, fmt.Errorf("meddler found field %s which is marked as the primary key, but a primary key field was already found", f.Name)
+					return fmt.Errorf("meddler found field %s which is marked as the primary key, but a primary key field was already found", f.Name)
 				}
 				data.pk = name
+			} else if key, value, hasValue := strings.Cut(tag[j], "="); hasValue {
+				if key == "prefix" {
+					nested, nestedPrefix = true, value
+					continue
+				}
+				if key == "default" {
+					var err error
+					if def, err = parseDefault(f, value); err != nil {
+						return err
+					}
+					continue
+				}
+				if key == "comment" || key == "index" {
+					if meta == nil {
+						meta = new(columnMeta)
+					}
+					if err := meta.set(f, key, value); err != nil {
+						return err
+					}
+					continue
+				}
+				if key == "hasmany" || key == "belongsto" {
+					return fmt.Errorf("meddler found field %s with option %s, which is only allowed on fields tagged \"-\"", f.Name, key)
+				}
+				return fmt.Errorf("meddler found field %s with unknown option %s", f.Name, key)
 			} else if m, present := registry[tag[j]]; present {
 				meddler = m
 			} else {
-				return nil, fmt.Errorf("meddler found field %s with meddler %s, but that meddler is not registered", f.Name, tag[j])
+				return fmt.Errorf("meddler found field %s with meddler %s, but that meddler is not registered", f.Name, tag[j])
+			}
+		}
+
+		// flatten nested structs into this one
+		if nested {
+			if f.Type.Kind() != reflect.Struct {
+				return fmt.Errorf("meddler found field %s with option prefix, but it is not a struct", f.Name)
+			}
+			if err := data.addFields(f.Type, index, prefix+nestedPrefix); err != nil {
+				return err
 			}
+			continue
 		}
+		name = prefix + name
 
 		if _, present := data.fields[name]; present {
-			return nil, fmt.Errorf("meddler found multiple fields for column %s", name)
+			return fmt.Errorf("meddler found multiple fields for column %s", name)
 		}
 		data.fields[name] = &structField{
 			column:     name,
 			primaryKey: name == data.pk,
-			index:      i,
+			index:      index,
 			meddler:    meddler,
 		}
+		if def != nil {
+			data.defaults[name] = def
+		}
+		if generated {
+			data.generated[name] = true
+		}
+		if meta != nil {
+			data.meta[name] = meta
+		}
 		data.columns = append(data.columns, name)
 	}
 
-	fieldsCache[dstType] = data
-	return data, nil
+	return nil
 }
 
 // Columns returns a list of column names for its input struct.
+// Columns are always listed in struct declaration order, with the fields
+// of prefix= structs in place of the struct field, so the order is the same
+// on every run. All generated SQL (Load, Insert, Update, and so on) uses
+// this order, which keeps the query text stable for prepared statement
+// caches and query fingerprinting.
 func (d *Database) Columns(src interface{}, includePk bool) ([]string, error) {
 	data, err := getFields(reflect.TypeOf(src))
 	if err != nil {
@@ -226,7 +550,7 @@ func (d *Database) PrimaryKey(src interface{}) (name string, pk int64, err error
 	}
 
 	name = data.pk
-	field := reflect.ValueOf(src).Elem().Field(data.fields[name].index)
+	field := reflect.ValueOf(src).Elem().FieldByIndex(data.fields[name].index)
 	switch field.Type().Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		pk = field.Int()
@@ -244,6 +568,32 @@ func PrimaryKey(src interface{}) (name string, pk int64, err error) {
 	return Default.PrimaryKey(src)
 }
 
+// PKInfo describes the primary key of src, which may be a struct pointer
+// or a reflect.Type of one: the name of its column, the name of its struct
+// field, and the kind of the field.
+// If src has no primary key, all are empty and err is nil.
+func (d *Database) PKInfo(src interface{}) (column string, field string, kind reflect.Kind, err error) {
+	t, ok := src.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(src)
+	}
+	data, err := getFields(t)
+	if err != nil {
+		return "", "", reflect.Invalid, err
+	}
+	if data.pk == "" {
+		return "", "", reflect.Invalid, nil
+	}
+
+	f := t.Elem().FieldByIndex(data.fields[data.pk].index)
+	return data.pk, f.Name, f.Type.Kind(), nil
+}
+
+// PKInfo using the Default Database type
+func PKInfo(src interface{}) (column string, field string, kind reflect.Kind, err error) {
+	return Default.PKInfo(src)
+}
+
 // SetPrimaryKey sets the primary key field to the given int value.
 func (d *Database) SetPrimaryKey(src interface{}, pk int64) error {
 	data, err := getFields(reflect.TypeOf(src))
@@ -252,10 +602,10 @@ func (d *Database) SetPrimaryKey(src interface{}, pk int64) error {
 	}
 
 	if data.pk == "" {
-		return fmt.Errorf("meddler.SetPrimaryKey: no primary key field found")
+		return fmt.Errorf("meddler.SetPrimaryKey: %w", ErrNoPrimaryKey)
 	}
 
-	field := reflect.ValueOf(src).Elem().Field(data.fields[data.pk].index)
+	field := reflect.ValueOf(src).Elem().FieldByIndex(data.fields[data.pk].index)
 	switch field.Type().Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		field.SetInt(pk)
@@ -308,14 +658,20 @@ func (d *Database) SomeValues(src interface{}, columns []string) ([]interface{},
 			values = append(values, nil)
 
 			if Debug {
-				log.Printf("meddler.SomeValues: column [%s] not found in struct", name)
+				d.logf("meddler.SomeValues: column [%s] not found in struct", name)
 			}
 			continue
 		}
 
-		saveVal, err := field.meddler.PreWrite(structVal.Field(field.index).Interface())
+		fieldVal := structVal.FieldByIndex(field.index).Interface()
+		var saveVal interface{}
+		if writer, ok := field.meddler.(DialectWriter); ok {
+			saveVal, err = writer.PreWriteDialect(d, fieldVal)
+		} else {
+			saveVal, err = field.meddler.PreWrite(fieldVal)
+		}
 		if err != nil {
-			return nil, fmt.Errorf("meddler.SomeValues: PreWrite error on column [%s]: %v", name, err)
+			return nil, fmt.Errorf("meddler.SomeValues: PreWrite error on column [%s]: %w", name, err)
 		}
 		values = append(values, saveVal)
 	}
@@ -373,34 +729,65 @@ func PlaceholdersString(src interface{}, includePk bool) (string, error) {
 }
 
 // scan a single row of data into a struct.
-func (d *Database) scanRow(data *structData, rows *sql.Rows, dst interface{}, columns []string) error {
+func (d *Database) scanRow(data *structData, plan *scanPlan, rows *sql.Rows, dst interface{}, stats *ScanStats) error {
+	columns := plan.columns
+
 	// check if there is data waiting
-	if !rows.Next() {
+	start := stats.now()
+	more := rows.Next()
+	stats.addDriver(start)
+	if !more {
 		if err := rows.Err(); err != nil {
 			return err
 		}
 		return sql.ErrNoRows
 	}
 
-	// get a list of targets
-	targets, err := d.Targets(dst, columns)
+	if u, ok := dst.(RowUnmarshaler); ok {
+		if err := u.UnmarshalRow(columns, rows.Scan); err != nil {
+			return err
+		}
+		return rows.Err()
+	}
+
+	// get a list of targets, reusing a slice from the pool
+	buf := targetsPool.Get().(*[]interface{})
+	defer func() {
+		// drop references to the scanned values before pooling the slice
+		for i := range *buf {
+			(*buf)[i] = nil
+		}
+		*buf = (*buf)[:0]
+		targetsPool.Put(buf)
+	}()
+	targets, err := d.planTargets(plan, (*buf)[:0], dst)
+	*buf = targets
 	if err != nil {
 		return err
 	}
 
 	// perform the scan
-	if err := rows.Scan(targets...); err != nil {
-		return err
+	start = stats.now()
+	err = rows.Scan(targets...)
+	stats.addDriver(start)
+	if err != nil {
+		return scanError(data, dst, columns, err)
 	}
 
 	// post-process and copy the target values into the struct
-	if err := d.WriteTargets(dst, columns, targets); err != nil {
+	if err := d.writePlanTargets(plan, dst, targets); err != nil {
 		return err
 	}
 
 	return rows.Err()
 }
 
+// targetsPool holds scan target slices so that scanning a row does not
+// allocate a fresh slice each time.
+var targetsPool = sync.Pool{
+	New: func() interface{} { return new([]interface{}) },
+}
+
 // Targets returns a list of values suitable for handing to a
 // Scan function in the sql package, complete with meddling. After
 // the Scan is performed, the same values should be handed to
@@ -411,28 +798,83 @@ func (d *Database) Targets(dst interface{}, columns []string) ([]interface{}, er
 		return nil, err
 	}
 
+	return d.targets(data, nil, dst, columns)
+}
+
+// targets appends the scan targets for dst to list.
+func (d *Database) targets(data *structData, list []interface{}, dst interface{}, columns []string) ([]interface{}, error) {
+	return d.planTargets(data.plan("", columns), list, dst)
+}
+
+// planTargets appends the scan targets for dst to list, following plan.
+func (d *Database) planTargets(plan *scanPlan, list []interface{}, dst interface{}) ([]interface{}, error) {
 	structVal := reflect.ValueOf(dst).Elem()
 
-	var targets []interface{}
-	for _, name := range columns {
-		if field, present := data.fields[name]; present {
-			fieldAddr := structVal.Field(field.index).Addr().Interface()
+	for i, name := range plan.columns {
+		if field := plan.fields[i]; field != nil {
+			fieldAddr := structVal.FieldByIndex(field.index).Addr().Interface()
 			scanTarget, err := field.meddler.PreRead(fieldAddr)
 			if err != nil {
-				return nil, fmt.Errorf("meddler.Targets: PreRead error on column %s: %v", name, err)
+				return list, fmt.Errorf("meddler.Targets: PreRead error on column %s: %w", name, err)
+			}
+			if (d.Coercion != CoerceNone && coercible(scanTarget)) || (d.NullAsZero && !nullable(scanTarget)) {
+				scanTarget = &coercedTarget{target: scanTarget, mode: d.Coercion, nullAsZero: d.NullAsZero}
 			}
-			targets = append(targets, scanTarget)
+			list = append(list, scanTarget)
 		} else {
 			// no destination, so throw this away
-			targets = append(targets, new(interface{}))
+			list = append(list, new(interface{}))
 
 			if Debug {
-				log.Printf("meddler.Targets: column [%s] not found in struct", name)
+				d.logf("meddler.Targets: column [%s] not found in struct", name)
 			}
 		}
 	}
 
-	return targets, nil
+	return list, nil
+}
+
+// nullable reports whether the sql package can scan NULL into target.
+func nullable(target interface{}) bool {
+	if _, ok := target.(sql.Scanner); ok {
+		return true
+	}
+	t := reflect.TypeOf(target)
+	if t.Kind() != reflect.Ptr {
+		return true
+	}
+	switch t.Elem().Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// scanError wraps an error from rows.Scan into dst in a ScanError naming
+// the column and field, using the column index in the sql package's error.
+func scanError(data *structData, dst interface{}, columns []string, err error) error {
+	index, ok := scanErrorIndex(columns, err)
+	if !ok {
+		return err
+	}
+	scanErr := &ScanError{Column: columns[index], Err: err}
+	if inner := errors.Unwrap(err); inner != nil {
+		scanErr.Err = inner
+	}
+	if field, present := data.fields[columns[index]]; present {
+		scanErr.Field = reflect.TypeOf(dst).Elem().FieldByIndex(field.index).Name
+	}
+	return scanErr
+}
+
+// scanErrorIndex returns the index in columns of the column named by an
+// error from rows.Scan.
+func scanErrorIndex(columns []string, err error) (int, bool) {
+	var index int
+	if _, serr := fmt.Sscanf(err.Error(), "sql: Scan error on column index %d", &index); serr != nil || index < 0 || index >= len(columns) {
+		return 0, false
+	}
+	return index, true
 }
 
 // Targets using the Default Database type
@@ -453,19 +895,34 @@ func (d *Database) WriteTargets(dst interface{}, columns []string, targets []int
 	if err != nil {
 		return err
 	}
+
+	return d.writeTargets(data, dst, columns, targets)
+}
+
+// writeTargets runs the PostRead meddlers for targets produced by targets.
+func (d *Database) writeTargets(data *structData, dst interface{}, columns []string, targets []interface{}) error {
+	return d.writePlanTargets(data.plan("", columns), dst, targets)
+}
+
+// writePlanTargets is writeTargets following plan.
+func (d *Database) writePlanTargets(plan *scanPlan, dst interface{}, targets []interface{}) error {
 	structVal := reflect.ValueOf(dst).Elem()
 
-	for i, name := range columns {
-		if field, present := data.fields[name]; present {
-			fieldAddr := structVal.Field(field.index).Addr().Interface()
-			err := field.meddler.PostRead(fieldAddr, targets[i])
+	for i, name := range plan.columns {
+		if field := plan.fields[i]; field != nil {
+			fieldAddr := structVal.FieldByIndex(field.index).Addr().Interface()
+			target := targets[i]
+			if coerced, ok := target.(*coercedTarget); ok {
+				target = coerced.target
+			}
+			err := field.meddler.PostRead(fieldAddr, target)
 			if err != nil {
-				return fmt.Errorf("meddler.WriteTargets: PostRead error on column [%s]: %v", name, err)
+				return fmt.Errorf("meddler.WriteTargets: PostRead error on column [%s]: %w", name, err)
 			}
 		} else {
 			// not destination, so throw this away
 			if Debug {
-				log.Printf("meddler.WriteTargets: column [%s] not found in struct", name)
+				d.logf("meddler.WriteTargets: column [%s] not found in struct", name)
 			}
 		}
 	}
@@ -479,9 +936,18 @@ func WriteTargets(dst interface{}, columns []string, targets []interface{}) erro
 }
 
 // Scan scans a single sql result row into a struct.
-// It leaves rows ready to be scanned again for the next row.
+// It leaves rows open and ready to be scanned again for the next row, so it
+// can be mixed with the caller's own rows.Next and rows.Scan calls; the
+// caller is responsible for closing rows. To scan a row the caller has
+// already advanced to, use ScanCurrentRow.
 // Returns sql.ErrNoRows if there is no data to read.
 func (d *Database) Scan(rows *sql.Rows, dst interface{}) error {
+	return d.scan(rows, dst, "")
+}
+
+// scan is Scan for rows produced by query, if known, which is used to
+// cache the scan plan.
+func (d *Database) scan(rows *sql.Rows, dst interface{}, query string) error {
 	// get the list of struct fields
 	data, err := getFields(reflect.TypeOf(dst))
 	if err != nil {
@@ -494,7 +960,7 @@ func (d *Database) Scan(rows *sql.Rows, dst interface{}) error {
 		return err
 	}
 
-	return d.scanRow(data, rows, dst, columns)
+	return d.scanRow(data, data.plan(query, columns), rows, dst, nil)
 }
 
 // Scan using the Default Database type
@@ -503,14 +969,20 @@ func Scan(rows *sql.Rows, dst interface{}) error {
 }
 
 // ScanRow scans a single sql result row into a struct.
-// It reads exactly one result row and closes rows when finished.
+// It reads exactly one result row and closes rows when finished; use Scan
+// to keep rows open for further rows.
 // Returns sql.ErrNoRows if there is no result row.
 func (d *Database) ScanRow(rows *sql.Rows, dst interface{}) error {
+	return d.scanSingle(rows, dst, "")
+}
+
+// scanSingle is ScanRow for rows produced by query, if known.
+func (d *Database) scanSingle(rows *sql.Rows, dst interface{}, query string) error {
 	// make sure we always close rows, even if there is a scan error
 	defer rows.Close()
 
-	if err := d.Scan(rows, dst); err != nil {
-		return err
+	if err := d.scan(rows, dst, query); err != nil {
+		return atRow(err, 1)
 	}
 
 	return rows.Close()
@@ -521,14 +993,254 @@ func ScanRow(rows *sql.Rows, dst interface{}) error {
 	return Default.ScanRow(rows, dst)
 }
 
+// ScanCurrentRow scans the row that rows is already positioned on into a
+// struct. Unlike Scan, it does not call rows.Next, and like Scan it does
+// not close rows, so it can be used inside a hand-written iteration loop.
+func (d *Database) ScanCurrentRow(rows *sql.Rows, dst interface{}) error {
+	data, err := getFields(reflect.TypeOf(dst))
+	if err != nil {
+		return err
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if u, ok := dst.(RowUnmarshaler); ok {
+		return u.UnmarshalRow(columns, rows.Scan)
+	}
+
+	targets, err := d.targets(data, nil, dst, columns)
+	if err != nil {
+		return err
+	}
+	if err := rows.Scan(targets...); err != nil {
+		return scanError(data, dst, columns, err)
+	}
+	return d.writeTargets(data, dst, columns, targets)
+}
+
+// ScanCurrentRow using the Default Database type
+func ScanCurrentRow(rows *sql.Rows, dst interface{}) error {
+	return Default.ScanCurrentRow(rows, dst)
+}
+
+// ScanSingleRow scans a *sql.Row into a struct. Since a *sql.Row does not
+// report its column names, the query must select exactly the columns of
+// dst in struct order, as given by Columns(dst, true) or
+// ColumnsQuoted(dst, true). Returns sql.ErrNoRows if there is no result row.
+func (d *Database) ScanSingleRow(row *sql.Row, dst interface{}) error {
+	data, err := getFields(reflect.TypeOf(dst))
+	if err != nil {
+		return err
+	}
+
+	if u, ok := dst.(RowUnmarshaler); ok {
+		return u.UnmarshalRow(data.columns, row.Scan)
+	}
+
+	targets, err := d.targets(data, nil, dst, data.columns)
+	if err != nil {
+		return err
+	}
+	if err := row.Scan(targets...); err != nil {
+		if err == sql.ErrNoRows {
+			return err
+		}
+		return atRow(scanError(data, dst, data.columns, err), 1)
+	}
+	return d.writeTargets(data, dst, data.columns, targets)
+}
+
+// ScanSingleRow using the Default Database type
+func ScanSingleRow(row *sql.Row, dst interface{}) error {
+	return Default.ScanSingleRow(row, dst)
+}
+
+// ScanRowMulti scans a single sql result row into several structs, such
+// as a Person and a Company from SELECT person.*, company.*. The columns
+// are split across dsts in order: each column goes to the current struct
+// if it has a field for it that has not been filled yet, and otherwise to
+// the next struct that does. Columns that no struct wants are discarded.
+// Like ScanRow, it reads exactly one row, closes rows when finished, and
+// returns sql.ErrNoRows if there is no result row.
+func (d *Database) ScanRowMulti(rows *sql.Rows, dsts ...interface{}) error {
+	// make sure we always close rows, even if there is a scan error
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	// split the columns between the destinations
+	datas := make([]*structData, len(dsts))
+	for i, dst := range dsts {
+		if datas[i], err = getFields(reflect.TypeOf(dst)); err != nil {
+			return err
+		}
+	}
+	owner := make([]int, len(columns))
+	split := make([][]string, len(dsts))
+	used := make([]map[string]bool, len(dsts))
+	for i := range used {
+		used[i] = make(map[string]bool)
+	}
+	current := 0
+	for i, name := range columns {
+		owner[i] = -1
+		for k := current; k < len(dsts); k++ {
+			if _, present := datas[k].fields[name]; present && !used[k][name] {
+				owner[i], current = k, k
+				used[k][name] = true
+				split[k] = append(split[k], name)
+				break
+			}
+		}
+		if owner[i] < 0 && Debug {
+			d.logf("meddler.ScanRowMulti: column [%s] not found in any struct", name)
+		}
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+
+	// gather the targets for each destination, then interleave them in
+	// column order
+	perDst := make([][]interface{}, len(dsts))
+	for k, dst := range dsts {
+		if perDst[k], err = d.targets(datas[k], nil, dst, split[k]); err != nil {
+			return err
+		}
+	}
+	targets := make([]interface{}, len(columns))
+	next := make([]int, len(dsts))
+	for i := range columns {
+		if k := owner[i]; k >= 0 {
+			targets[i] = perDst[k][next[k]]
+			next[k]++
+		} else {
+			targets[i] = new(interface{})
+		}
+	}
+
+	if err := rows.Scan(targets...); err != nil {
+		// name the field in the struct that the column was split off to
+		if index, ok := scanErrorIndex(columns, err); ok && owner[index] >= 0 {
+			k := owner[index]
+			return scanError(datas[k], dsts[k], columns, err)
+		}
+		return err
+	}
+	for k, dst := range dsts {
+		if err := d.writeTargets(datas[k], dst, split[k], perDst[k]); err != nil {
+			return err
+		}
+	}
+
+	return rows.Close()
+}
+
+// ScanRowMulti using the Default Database type
+func ScanRowMulti(rows *sql.Rows, dsts ...interface{}) error {
+	return Default.ScanRowMulti(rows, dsts...)
+}
+
 // ScanAll scans all sql result rows into a slice of structs.
 // It reads all rows and closes rows when finished.
-// dst should be a pointer to a slice of the appropriate type.
+// dst should be a pointer to a slice of the appropriate type, either
+// []*T or []T; the latter avoids an allocation per row.
 // The new results will be appended to any existing data in dst.
 func (d *Database) ScanAll(rows *sql.Rows, dst interface{}) error {
+	return d.ScanAllContext(context.Background(), rows, dst)
+}
+
+// ScanAll using the Default Database type
+func ScanAll(rows *sql.Rows, dst interface{}) error {
+	return Default.ScanAll(rows, dst)
+}
+
+// ScanAllContext is like ScanAll, but checks ctx between rows and stops
+// with ctx.Err() as soon as the context is cancelled, closing rows so the
+// driver can release the connection.
+func (d *Database) ScanAllContext(ctx context.Context, rows *sql.Rows, dst interface{}) error {
 	// make sure we always close rows
 	defer rows.Close()
 
+	return d.scanAll(ctx, rows, dst, false)
+}
+
+// ScanAllContext using the Default Database type
+func ScanAllContext(ctx context.Context, rows *sql.Rows, dst interface{}) error {
+	return Default.ScanAllContext(ctx, rows, dst)
+}
+
+// ScanAllSets scans each result set of rows into its own slice of structs,
+// for stored procedures and multi-statement queries that return several
+// result sets. The first result set goes into dsts[0], the next (reached
+// with rows.NextResultSet) into dsts[1], and so on; each dst must be a
+// pointer to a slice, as for ScanAll. It is an error for rows to have fewer
+// result sets than dsts, but extra result sets are ignored. rows is closed
+// when finished.
+func (d *Database) ScanAllSets(rows *sql.Rows, dsts ...interface{}) error {
+	defer rows.Close()
+
+	for i, dst := range dsts {
+		if i > 0 && !rows.NextResultSet() {
+			if err := rows.Err(); err != nil {
+				return err
+			}
+			return fmt.Errorf("meddler.ScanAllSets: expected %d result sets, found %d", len(dsts), i)
+		}
+		if err := d.scanAll(context.Background(), rows, dst, false); err != nil {
+			return fmt.Errorf("meddler.ScanAllSets: result set %d: %w", i, err)
+		}
+	}
+
+	return rows.Close()
+}
+
+// ScanAllSets using the Default Database type
+func ScanAllSets(rows *sql.Rows, dsts ...interface{}) error {
+	return Default.ScanAllSets(rows, dsts...)
+}
+
+// ScanAllReuse is like ScanAllContext, but replaces the contents of dst
+// instead of appending to it, and reuses its backing array and the structs
+// it points to. Structs are zeroed before being scanned into, so callers
+// must not hold on to elements of dst across calls. This avoids allocating
+// a new slice and a new struct per row when the same slice is filled over
+// and over, such as on a hot request path.
+func (d *Database) ScanAllReuse(ctx context.Context, rows *sql.Rows, dst interface{}) error {
+	// make sure we always close rows
+	defer rows.Close()
+
+	return d.scanAll(ctx, rows, dst, true)
+}
+
+// ScanAllReuse using the Default Database type
+func ScanAllReuse(ctx context.Context, rows *sql.Rows, dst interface{}) error {
+	return Default.ScanAllReuse(ctx, rows, dst)
+}
+
+// scanAll appends the remaining rows of the current result set to dst,
+// leaving rows open. If reuse is set, dst is truncated first and the
+// structs already in its backing array are scanned into again.
+func (d *Database) scanAll(ctx context.Context, rows *sql.Rows, dst interface{}, reuse bool) (err error) {
+	var stats *ScanStats
+	if d.OnScan != nil {
+		stats = &ScanStats{Query: scanQuery(ctx)}
+		start := time.Now()
+		defer func() {
+			stats.Total = time.Since(start)
+			stats.Err = err
+			d.OnScan(ctx, stats)
+		}()
+	}
+
 	// make sure dst is an appropriate type
 	dstVal := reflect.ValueOf(dst)
 	if dstVal.Kind() != reflect.Ptr || dstVal.IsNil() {
@@ -538,8 +1250,12 @@ func (d *Database) ScanAll(rows *sql.Rows, dst interface{}) error {
 	if sliceVal.Kind() != reflect.Slice {
 		return fmt.Errorf("ScanAll called with pointer to non-slice: %T", dst)
 	}
+	// elements may be structs or pointers to structs
 	ptrType := sliceVal.Type().Elem()
-	if ptrType.Kind() != reflect.Ptr {
+	byValue := ptrType.Kind() == reflect.Struct
+	if byValue {
+		ptrType = reflect.PtrTo(ptrType)
+	} else if ptrType.Kind() != reflect.Ptr {
 		return fmt.Errorf("ScanAll expects element to be pointers, found %T", dst)
 	}
 	eltType := ptrType.Elem()
@@ -558,27 +1274,74 @@ func (d *Database) ScanAll(rows *sql.Rows, dst interface{}) error {
 	if err != nil {
 		return err
 	}
+	plan := data.plan(scanQuery(ctx), columns)
+	if stats != nil {
+		stats.Columns = len(columns)
+		for _, name := range columns {
+			if _, present := data.fields[name]; present {
+				stats.Matched++
+			}
+		}
+	}
+
+	// keep the old elements reachable for reuse
+	var old reflect.Value
+	if reuse {
+		old = sliceVal.Slice(0, sliceVal.Cap())
+		sliceVal.SetLen(0)
+	}
 
 	// gather the results
-	for {
-		// create a new element
-		eltVal := reflect.New(eltType)
+	for row := 1; ; row++ {
+		// bail out early if the caller has gone away
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// structs are scanned in place at the end of the slice
+		if byValue {
+			n := sliceVal.Len()
+			if n < sliceVal.Cap() {
+				sliceVal.SetLen(n + 1)
+				sliceVal.Index(n).Set(reflect.Zero(eltType))
+			} else {
+				sliceVal.Set(reflect.Append(sliceVal, reflect.Zero(eltType)))
+			}
+			if err := d.scanRow(data, plan, rows, sliceVal.Index(n).Addr().Interface(), stats); err != nil {
+				sliceVal.SetLen(n)
+				if err == sql.ErrNoRows {
+					return nil
+				}
+				return atRow(err, row)
+			}
+			if stats != nil {
+				stats.Rows++
+			}
+			continue
+		}
+
+		// create a new element, or recycle an old one
+		var eltVal reflect.Value
+		if n := sliceVal.Len(); reuse && n < old.Len() && !old.Index(n).IsNil() {
+			eltVal = old.Index(n)
+			eltVal.Elem().Set(reflect.Zero(eltType))
+		} else {
+			eltVal = reflect.New(eltType)
+		}
 		elt := eltVal.Interface()
 
 		// scan it
-		if err := d.scanRow(data, rows, elt, columns); err != nil {
+		if err := d.scanRow(data, plan, rows, elt, stats); err != nil {
 			if err == sql.ErrNoRows {
 				return nil
 			}
-			return err
+			return atRow(err, row)
+		}
+		if stats != nil {
+			stats.Rows++
 		}
 
 		// add to the result slice
 		sliceVal.Set(reflect.Append(sliceVal, eltVal))
 	}
 }
-
-// ScanAll using the Default Database type
-func ScanAll(rows *sql.Rows, dst interface{}) error {
-	return Default.ScanAll(rows, dst)
-}
@@ -1,12 +1,14 @@
 package meddlerx
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"reflect"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -129,7 +131,7 @@ func structFieldEqual(t *testing.T, elt *structField, ref *structField) {
 	if elt.primaryKey != ref.primaryKey {
 		t.Errorf("Column %s primaryKey found as %v", ref.column, elt.primaryKey)
 	}
-	if elt.index != ref.index {
+	if !reflect.DeepEqual(elt.index, ref.index) {
 		t.Errorf("Column %s index found as %v", ref.column, elt.index)
 	}
 	if elt.meddler != ref.meddler {
@@ -148,14 +150,14 @@ func TestGetFields(t *testing.T) {
 	if len(data.fields) != 8 || len(data.columns) != 8 {
 		t.Errorf("Found %d/%d fields, expected 8", len(data.fields), len(data.columns))
 	}
-	structFieldEqual(t, data.fields[data.columns[0]], &structField{"id", 0, true, registry["identity"]})
-	structFieldEqual(t, data.fields[data.columns[1]], &structField{"name", 1, false, registry["identity"]})
-	structFieldEqual(t, data.fields[data.columns[2]], &structField{"Email", 3, false, registry["identity"]})
-	structFieldEqual(t, data.fields[data.columns[3]], &structField{"Age", 5, false, registry["zeroisnull"]})
-	structFieldEqual(t, data.fields[data.columns[4]], &structField{"opened", 6, false, registry["utctime"]})
-	structFieldEqual(t, data.fields[data.columns[5]], &structField{"closed", 7, false, registry["utctimez"]})
-	structFieldEqual(t, data.fields[data.columns[6]], &structField{"updated", 8, false, registry["localtime"]})
-	structFieldEqual(t, data.fields[data.columns[7]], &structField{"height", 9, false, registry["identity"]})
+	structFieldEqual(t, data.fields[data.columns[0]], &structField{"id", []int{0}, true, registry["identity"]})
+	structFieldEqual(t, data.fields[data.columns[1]], &structField{"name", []int{1}, false, registry["identity"]})
+	structFieldEqual(t, data.fields[data.columns[2]], &structField{"Email", []int{3}, false, registry["identity"]})
+	structFieldEqual(t, data.fields[data.columns[3]], &structField{"Age", []int{5}, false, registry["zeroisnull"]})
+	structFieldEqual(t, data.fields[data.columns[4]], &structField{"opened", []int{6}, false, registry["utctime"]})
+	structFieldEqual(t, data.fields[data.columns[5]], &structField{"closed", []int{7}, false, registry["utctimez"]})
+	structFieldEqual(t, data.fields[data.columns[6]], &structField{"updated", []int{8}, false, registry["localtime"]})
+	structFieldEqual(t, data.fields[data.columns[7]], &structField{"height", []int{9}, false, registry["identity"]})
 
 	// test with non-pointer
 	if _, err := getFields(reflect.TypeOf(*alice)); err == nil {
@@ -536,3 +538,439 @@ func TestThrowAway(t *testing.T) {
 	Debug = true
 	db.Exec("delete from person")
 }
+
+func TestScanAllContextCancelled(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	rows, err := db.Query("select * from person order by id")
+	if err != nil {
+		t.Fatalf("DB error on query: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var lst []*Person
+	if err := ScanAllContext(ctx, rows, &lst); err != context.Canceled {
+		t.Errorf("ScanAllContext with cancelled context: want context.Canceled, got %v", err)
+	}
+	if len(lst) != 0 {
+		t.Errorf("ScanAllContext with cancelled context: want no rows, got %d", len(lst))
+	}
+
+	// QueryAll honors the same context
+	if err := QueryAll(ctx, db, &lst, "select * from person"); err == nil {
+		t.Errorf("QueryAll with cancelled context: want error, got nil")
+	}
+}
+
+func TestScanAllSets(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	rows, err := db.Query("select * from person order by id")
+	if err != nil {
+		t.Fatalf("DB error on query: %v", err)
+	}
+	var first []*Person
+	if err := ScanAllSets(rows, &first); err != nil {
+		t.Fatalf("ScanAllSets error: %v", err)
+	}
+	if len(first) != 2 {
+		t.Errorf("ScanAllSets: expected 2 rows, got %d", len(first))
+	}
+
+	// sqlite returns a single result set, so asking for a second one fails
+	rows, err = db.Query("select * from person order by id")
+	if err != nil {
+		t.Fatalf("DB error on query: %v", err)
+	}
+	var a, b []*Person
+	if err := ScanAllSets(rows, &a, &b); err == nil {
+		t.Errorf("ScanAllSets with missing result set: expected err, got nil")
+	}
+	if len(a) != 2 || len(b) != 0 {
+		t.Errorf("ScanAllSets with missing result set: got %d and %d rows", len(a), len(b))
+	}
+}
+
+func TestScanAllReuse(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	lst := make([]*Person, 0, 4)
+	if err := QueryAllReuse(testCtx, db, &lst, "select * from person order by id"); err != nil {
+		t.Fatalf("QueryAllReuse error: %v", err)
+	}
+	if len(lst) != 2 {
+		t.Fatalf("QueryAllReuse: expected 2 rows, got %d", len(lst))
+	}
+	first, second := lst[0], lst[1]
+
+	// the second query replaces the contents and reuses the structs
+	if err := QueryAllReuse(testCtx, db, &lst, "select * from person where id = 2"); err != nil {
+		t.Fatalf("QueryAllReuse error: %v", err)
+	}
+	if len(lst) != 1 {
+		t.Fatalf("QueryAllReuse: expected 1 row, got %d", len(lst))
+	}
+	if lst[0] != first {
+		t.Errorf("QueryAllReuse: expected the first struct to be reused")
+	}
+	personEqual(t, lst[0], bob)
+
+	// going past the old length allocates again
+	if err := QueryAllReuse(testCtx, db, &lst, "select * from person order by id"); err != nil {
+		t.Fatalf("QueryAllReuse error: %v", err)
+	}
+	if len(lst) != 2 || lst[0] != first || lst[1] != second {
+		t.Errorf("QueryAllReuse: expected both structs to be reused")
+	}
+}
+
+func BenchmarkScanAll(b *testing.B) {
+	once.Do(setup)
+	for i := 0; i < 50; i++ {
+		p := *alice
+		p.ID = 0
+		if err := Insert(testCtx, db, "person", &p); err != nil {
+			b.Fatalf("Insert error: %v", err)
+		}
+	}
+	defer db.Exec("delete from person")
+
+	b.Run("QueryAll", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var lst []*Person
+			if err := QueryAll(testCtx, db, &lst, "select * from person"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("QueryAllReuse", func(b *testing.B) {
+		b.ReportAllocs()
+		var lst []*Person
+		for i := 0; i < b.N; i++ {
+			if err := QueryAllReuse(testCtx, db, &lst, "select * from person"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestNestedPrefix(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	type pair struct {
+		Boss   Person `meddler:",prefix=boss_"`
+		Report Person `meddler:",prefix=report_"`
+		Note   string `meddler:"note"`
+	}
+
+	data, err := getFields(reflect.TypeOf((*pair)(nil)))
+	if err != nil {
+		t.Fatalf("getFields error: %v", err)
+	}
+	if len(data.columns) != 17 || data.pk != "" {
+		t.Errorf("nested getFields: found %d columns and pk %q", len(data.columns), data.pk)
+	}
+	structFieldEqual(t, data.fields["report_name"], &structField{"report_name", []int{1, 1}, false, registry["identity"]})
+
+	var cols []string
+	for _, prefix := range []string{"boss", "report"} {
+		alias := prefix[:1]
+		for _, c := range []string{"id", "name", "Email", "Age", "opened", "closed", "updated", "height"} {
+			cols = append(cols, fmt.Sprintf("%s.%s as %s_%s", alias, c, prefix, c))
+		}
+	}
+	q := "select " + strings.Join(cols, ", ") + ", 'hi' as note from person b join person r on r.id = b.id + 1"
+	elt := new(pair)
+	if err := QueryRow(testCtx, db, elt, q); err != nil {
+		t.Fatalf("QueryRow error: %v", err)
+	}
+	personEqual(t, &elt.Boss, &Person{alice.ID, "Alice", 0, "alice@alice.com", 0, 32, when, when, &when, &aliceHeight})
+	personEqual(t, &elt.Report, bob)
+	if elt.Note != "hi" {
+		t.Errorf("nested prefix: expected note hi, got %q", elt.Note)
+	}
+
+	type badPrefix struct {
+		Name string `meddler:"name,prefix=x_"`
+	}
+	if _, err := Columns(new(badPrefix), true); err == nil {
+		t.Errorf("prefix on a non-struct field: expected err, got nil")
+	}
+}
+
+func TestScanRowMulti(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+	if _, err := db.Exec("insert into item (id, stuff, stuffz) values (7, '{}', x'')"); err != nil {
+		t.Fatalf("inserting item: %v", err)
+	}
+	defer db.Exec("delete from item")
+
+	// person and item share the id column, which goes to each in turn
+	rows, err := db.Query("select person.*, item.id, 'ignored' as extra from person, item where person.id = 2")
+	if err != nil {
+		t.Fatalf("DB error on query: %v", err)
+	}
+	p := new(Person)
+	item := new(ItemJson)
+	if err := ScanRowMulti(rows, p, item); err != nil {
+		t.Fatalf("ScanRowMulti error: %v", err)
+	}
+	personEqual(t, p, bob)
+	if item.ID != 7 {
+		t.Errorf("ScanRowMulti: expected item id 7, got %d", item.ID)
+	}
+
+	rows, err = db.Query("select * from person where id = 99")
+	if err != nil {
+		t.Fatalf("DB error on query: %v", err)
+	}
+	if err := ScanRowMulti(rows, p, item); err != sql.ErrNoRows {
+		t.Errorf("ScanRowMulti with no rows: expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestScanCurrentRow(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	rows, err := db.Query("select * from person order by id")
+	if err != nil {
+		t.Fatalf("DB error on query: %v", err)
+	}
+	defer rows.Close()
+	var people []*Person
+	for rows.Next() {
+		p := new(Person)
+		if err := ScanCurrentRow(rows, p); err != nil {
+			t.Fatalf("ScanCurrentRow error: %v", err)
+		}
+		people = append(people, p)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("rows error: %v", err)
+	}
+	if len(people) != 2 {
+		t.Fatalf("ScanCurrentRow: expected 2 people, got %d", len(people))
+	}
+	bob.ID = 2
+	personEqual(t, people[1], bob)
+}
+
+func TestScanSingleRow(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	p := new(Person)
+	columns, err := ColumnsQuoted(p, true)
+	if err != nil {
+		t.Fatalf("ColumnsQuoted error: %v", err)
+	}
+	row := db.QueryRow("select "+columns+" from person where id = ?", 2)
+	if err := ScanSingleRow(row, p); err != nil {
+		t.Fatalf("ScanSingleRow error: %v", err)
+	}
+	bob.ID = 2
+	personEqual(t, p, bob)
+
+	row = db.QueryRow("select "+columns+" from person where id = ?", 99)
+	if err := ScanSingleRow(row, p); err != sql.ErrNoRows {
+		t.Errorf("ScanSingleRow with no rows: expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestScanKeepsRowsOpen(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	rows, err := db.Query("select * from person order by id")
+	if err != nil {
+		t.Fatalf("DB error on query: %v", err)
+	}
+	defer rows.Close()
+
+	// scan the first row with meddler, then carry on by hand
+	p := new(Person)
+	if err := Scan(rows, p); err != nil {
+		t.Fatalf("Scan error: %v", err)
+	}
+	if p.Name != "Alice" {
+		t.Errorf("Scan: expected Alice, got %s", p.Name)
+	}
+	if !rows.Next() {
+		t.Fatalf("rows closed after Scan: %v", rows.Err())
+	}
+	if err := ScanCurrentRow(rows, p); err != nil {
+		t.Fatalf("ScanCurrentRow error: %v", err)
+	}
+	if p.Name != "Bob" {
+		t.Errorf("ScanCurrentRow: expected Bob, got %s", p.Name)
+	}
+	if err := Scan(rows, p); err != sql.ErrNoRows {
+		t.Errorf("Scan at end: expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestColumnOrderStable(t *testing.T) {
+	expected := []string{"id", "name", "Email", "Age", "opened", "closed", "updated", "height"}
+	for i := 0; i < 20; i++ {
+		// clear the cache so the struct is examined afresh each time
+		fieldsCacheMutex.Lock()
+		delete(fieldsCache, reflect.TypeOf(new(Person)))
+		fieldsCacheMutex.Unlock()
+		columns, err := Columns(new(Person), true)
+		if err != nil {
+			t.Fatalf("Columns error: %v", err)
+		}
+		if !reflect.DeepEqual(columns, expected) {
+			t.Fatalf("Columns: expected %v, got %v", expected, columns)
+		}
+	}
+
+	once.Do(setup)
+	defer db.Exec("delete from person")
+	rec := new(recordingQuerier)
+	SQLite.Insert(testCtx, rec, "person", &Person{Name: "Carol"})
+	first := rec.query
+	for i := 0; i < 20; i++ {
+		SQLite.Insert(testCtx, rec, "person", &Person{Name: "Carol"})
+		if rec.query != first {
+			t.Fatalf("Insert: query text changed from %s to %s", first, rec.query)
+		}
+	}
+}
+
+func TestScanAllValues(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	people := []Person{{Name: "existing"}}
+	if err := QueryAll(testCtx, db, &people, "select * from person order by id"); err != nil {
+		t.Fatalf("QueryAll error: %v", err)
+	}
+	if len(people) != 3 || people[0].Name != "existing" {
+		t.Fatalf("QueryAll: expected 3 people, got %+v", people)
+	}
+	bob.ID = 2
+	personEqual(t, &people[2], bob)
+
+	// reuse overwrites the elements in place
+	backing := &people[0]
+	if err := QueryAllReuse(testCtx, db, &people, "select * from person where id = 2"); err != nil {
+		t.Fatalf("QueryAllReuse error: %v", err)
+	}
+	if len(people) != 1 || &people[0] != backing {
+		t.Fatalf("QueryAllReuse: expected 1 person in the same array, got %+v", people)
+	}
+	personEqual(t, &people[0], bob)
+
+	var ints []int
+	if err := QueryAll(testCtx, db, &ints, "select id from person"); err == nil {
+		t.Errorf("QueryAll into []int: expected an error")
+	}
+}
+
+func TestPKInfo(t *testing.T) {
+	type keyed struct {
+		Key  uint32 `meddler:"key,pk"`
+		Name string `meddler:"name"`
+	}
+	type unkeyed struct {
+		Name string `meddler:"name"`
+	}
+
+	tests := []struct {
+		src    interface{}
+		column string
+		field  string
+		kind   reflect.Kind
+	}{
+		{new(Person), "id", "ID", reflect.Int64},
+		{reflect.TypeOf(new(UintPerson)), "id", "ID", reflect.Uint64},
+		{new(keyed), "key", "Key", reflect.Uint32},
+		{new(unkeyed), "", "", reflect.Invalid},
+	}
+	for _, test := range tests {
+		column, field, kind, err := PKInfo(test.src)
+		if err != nil {
+			t.Errorf("PKInfo(%T) error: %v", test.src, err)
+			continue
+		}
+		if column != test.column || field != test.field || kind != test.kind {
+			t.Errorf("PKInfo(%T): expected %s %s %v, got %s %s %v", test.src, test.column, test.field, test.kind, column, field, kind)
+		}
+	}
+
+	if _, _, _, err := PKInfo(Person{}); err == nil {
+		t.Errorf("PKInfo on a non-pointer: expected err, got nil")
+	}
+}
+
+func TestScanPlanCache(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	type planPerson struct {
+		ID   int64  `meddler:"id,pk"`
+		Name string `meddler:"name"`
+	}
+	data, err := getFields(reflect.TypeOf(new(planPerson)))
+	if err != nil {
+		t.Fatalf("getFields error: %v", err)
+	}
+
+	query := "select id, name, Email from person order by id"
+	for i := 0; i < 2; i++ {
+		var people []*planPerson
+		if err := SQLite.QueryAll(testCtx, db, &people, query); err != nil {
+			t.Fatalf("QueryAll error: %v", err)
+		}
+		if len(people) != 2 || people[1].Name != "Bob" {
+			t.Errorf("QueryAll got %v", people)
+		}
+	}
+	cached, present := data.plans.Load(query)
+	if !present {
+		t.Fatalf("expected a cached plan for %q", query)
+	}
+	plan := cached.(*scanPlan)
+	if plan.fields[0] != data.fields["id"] || plan.fields[1] != data.fields["name"] || plan.fields[2] != nil {
+		t.Errorf("unexpected plan %+v", plan)
+	}
+	if data.plan(query, []string{"id", "name", "Email"}) != plan {
+		t.Errorf("expected the cached plan to be reused")
+	}
+
+	// a result with other columns gets a new plan
+	other := data.plan(query, []string{"name"})
+	if other == plan || other.fields[0] != data.fields["name"] {
+		t.Errorf("expected a new plan for different columns, got %+v", other)
+	}
+	if data.plan("", []string{"name"}) == data.plan("", []string{"name"}) {
+		t.Errorf("expected plans without a query not to be cached")
+	}
+
+	// the cache stops growing at its limit
+	atomic.StoreInt32(&data.planCount, maxScanPlans)
+	data.plan("select name from person", []string{"name"})
+	if _, present := data.plans.Load("select name from person"); present {
+		t.Errorf("expected no more plans to be cached past the limit")
+	}
+}
@@ -0,0 +1,331 @@
+package meddlerx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SchemaError describes the differences found by ValidateStruct between a
+// struct and the live table it is mapped to.
+type SchemaError struct {
+	Table    string
+	Problems []string
+}
+
+func (err *SchemaError) Error() string {
+	return fmt.Sprintf("meddler.ValidateStruct: table %s does not match struct: %s",
+		err.Table, strings.Join(err.Problems, "; "))
+}
+
+// dbColumn is a column as reported by the database catalog.
+type dbColumn struct {
+	name       string
+	sqlType    string
+	notNull    bool
+	hasDefault bool
+}
+
+// ValidateStruct compares the columns of the live table with the fields of
+// dst. It reports struct columns that are missing from the table, columns
+// whose types are incompatible with the field, and NOT NULL columns without
+// a default that the struct does not map (so inserts would fail). It returns
+// nil if no problems were found, or a *SchemaError listing them.
+//
+// SQLite tables are inspected with PRAGMA table_info, other databases with
+// information_schema.columns in the current schema.
+func (d *Database) ValidateStruct(ctx context.Context, db Querier, table string, dst interface{}) error {
+	data, err := getFields(reflect.TypeOf(dst))
+	if err != nil {
+		return err
+	}
+	structType := reflect.TypeOf(dst).Elem()
+
+	live, err := d.tableColumns(ctx, db, table)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]*dbColumn)
+	for i := range live {
+		byName[live[i].name] = &live[i]
+	}
+
+	var problems []string
+	for _, name := range data.columns {
+		col, present := byName[name]
+		if !present {
+			problems = append(problems, fmt.Sprintf("column %s is missing", name))
+			continue
+		}
+
+		field := data.fields[name]
+		expected, _, ok := d.expectedColumnType(field, structType.FieldByIndex(field.index).Type)
+		if !ok {
+			continue
+		}
+		if !compatibleTypes(expected, col.sqlType) {
+			problems = append(problems, fmt.Sprintf("column %s has type %s, expected %s", name, col.sqlType, expected))
+		}
+	}
+	for _, col := range live {
+		if _, mapped := data.fields[col.name]; !mapped && col.notNull && !col.hasDefault {
+			problems = append(problems, fmt.Sprintf("column %s is NOT NULL without a default but is not mapped", col.name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &SchemaError{Table: table, Problems: problems}
+	}
+	return nil
+}
+
+// ValidateStruct using the Default Database type
+func ValidateStruct(ctx context.Context, db Querier, table string, dst interface{}) error {
+	return Default.ValidateStruct(ctx, db, table, dst)
+}
+
+// Diff compares the live table with dst and returns the ALTER TABLE
+// statements that would bring the table in line with the struct: columns
+// that are missing are added, and columns whose nullability differs from
+// the struct mapping are changed. Columns are never dropped, and type
+// mismatches are left to ValidateStruct to report.
+//
+// The statements are meant for human review before being applied. SQLite
+// cannot alter the nullability of an existing column, so for SQLite such
+// differences are returned as SQL comments instead. A missing NOT NULL
+// column is added as nullable, since the existing rows have no value for
+// it, followed by a comment saying to fill it in and then add the
+// constraint.
+func (d *Database) Diff(ctx context.Context, db Querier, table string, dst interface{}) ([]string, error) {
+	data, err := getFields(reflect.TypeOf(dst))
+	if err != nil {
+		return nil, err
+	}
+	structType := reflect.TypeOf(dst).Elem()
+
+	live, err := d.tableColumns(ctx, db, table)
+	if err != nil {
+		return nil, err
+	}
+	return d.diffStatements(table, data, structType, live)
+}
+
+// diffStatements returns the statements for Diff, given the live columns
+// of table.
+func (d *Database) diffStatements(table string, data *structData, structType reflect.Type, live []dbColumn) ([]string, error) {
+	byName := make(map[string]*dbColumn)
+	for i := range live {
+		byName[live[i].name] = &live[i]
+	}
+
+	// SQL Server has no COLUMN keyword after ADD
+	add := "ADD COLUMN"
+	if d.Dialect == DialectSQLServer {
+		add = "ADD"
+	}
+
+	var stmts []string
+	for _, name := range data.columns {
+		field := data.fields[name]
+		fieldType := structType.FieldByIndex(field.index).Type
+		col, present := byName[name]
+		if !present {
+			def, err := d.columnDefinition(field, fieldType)
+			if err != nil {
+				return nil, err
+			}
+			if !field.primaryKey && strings.HasSuffix(def, " NOT NULL") {
+				// existing rows would violate the constraint
+				def = strings.TrimSuffix(def, " NOT NULL")
+				stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s %s %s", d.quotedTable(table), add, def),
+					fmt.Sprintf("-- column %s was added as nullable; fill it in for existing rows, then make it NOT NULL", name))
+				continue
+			}
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s %s %s", d.quotedTable(table), add, def))
+			continue
+		}
+
+		if field.primaryKey {
+			continue
+		}
+		sqlType, nullable, ok := d.expectedColumnType(field, fieldType)
+		if !ok || nullable == !col.notNull {
+			continue
+		}
+		switch d.Dialect {
+		case DialectMySQL:
+			def := d.quoted(name) + " " + sqlType
+			if !nullable {
+				def += " NOT NULL"
+			}
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s", d.quotedTable(table), def))
+		case DialectSQLServer:
+			want := "NOT NULL"
+			if nullable {
+				want = "NULL"
+			}
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s %s", d.quotedTable(table), d.quoted(name), sqlType, want))
+		case DialectSQLite:
+			want := "NOT NULL"
+			if nullable {
+				want = "NULL"
+			}
+			stmts = append(stmts, fmt.Sprintf("-- column %s should be %s, but SQLite cannot change nullability without rebuilding table %s",
+				name, want, table))
+		default:
+			action := "SET NOT NULL"
+			if nullable {
+				action = "DROP NOT NULL"
+			}
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s", d.quotedTable(table), d.quoted(name), action))
+		}
+	}
+
+	return stmts, nil
+}
+
+// Diff using the Default Database type
+func Diff(ctx context.Context, db Querier, table string, dst interface{}) ([]string, error) {
+	return Default.Diff(ctx, db, table, dst)
+}
+
+// expectedColumnType returns the column type CreateTableSQL would use for
+// a field. ok is false if the type cannot be determined.
+func (d *Database) expectedColumnType(field *structField, fieldType reflect.Type) (sqlType string, nullable bool, ok bool) {
+	if field.primaryKey {
+		return d.integerType(fieldType.Kind()), false, true
+	}
+	typer, isTyper := field.meddler.(ColumnTyper)
+	if !isTyper {
+		return "", false, false
+	}
+	sqlType, nullable, err := typer.ColumnType(d, fieldType)
+	if err != nil {
+		return "", false, false
+	}
+	return sqlType, nullable, true
+}
+
+// tableColumns reads the column list of a table from the database catalog.
+func (d *Database) tableColumns(ctx context.Context, db Querier, table string) ([]dbColumn, error) {
+	var cols []dbColumn
+
+	if d.Dialect == DialectSQLite {
+		q := fmt.Sprintf("PRAGMA table_info(%s)", d.quotedTable(table))
+		rows, err := db.QueryContext(ctx, q)
+		if err != nil {
+			return nil, d.queryError("ValidateStruct", table, q, nil, err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var cid, notNull, pk int
+			var name, sqlType string
+			var dflt sql.NullString
+			if err := rows.Scan(&cid, &name, &sqlType, &notNull, &dflt, &pk); err != nil {
+				return nil, err
+			}
+			cols = append(cols, dbColumn{name: name, sqlType: sqlType, notNull: notNull != 0, hasDefault: dflt.Valid || pk != 0})
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	} else {
+		schema := "current_schema()"
+		switch d.Dialect {
+		case DialectMySQL:
+			schema = "DATABASE()"
+		case DialectSQLServer:
+			schema = "SCHEMA_NAME()"
+		}
+		name := table
+		var args []interface{}
+		if i := strings.LastIndex(table, "."); i >= 0 {
+			schema = d.placeholder(2)
+			name = table[i+1:]
+			args = append(args, table[:i])
+		}
+		args = append([]interface{}{name}, args...)
+
+		hasDefault := "column_default IS NOT NULL"
+		switch d.Dialect {
+		case DialectMySQL:
+			// auto_increment columns have no default in MySQL's catalog
+			hasDefault += " OR extra LIKE '%auto_increment%'"
+		case DialectSQLServer:
+			// nor do identity columns in SQL Server's, which also has no
+			// boolean expressions in a select list
+			hasDefault = "CASE WHEN column_default IS NOT NULL" +
+				" OR COLUMNPROPERTY(OBJECT_ID(QUOTENAME(table_schema) + '.' + QUOTENAME(table_name)), column_name, 'IsIdentity') = 1" +
+				" THEN 1 ELSE 0 END"
+		}
+		q := "SELECT column_name, data_type, is_nullable, " + hasDefault + " FROM information_schema.columns" +
+			" WHERE table_name = " + d.placeholder(1) + " AND table_schema = " + schema + " ORDER BY ordinal_position"
+		rows, err := db.QueryContext(ctx, q, args...)
+		if err != nil {
+			return nil, d.queryError("ValidateStruct", table, q, args, err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var name, sqlType, nullable string
+			var hasDefault bool
+			if err := rows.Scan(&name, &sqlType, &nullable, &hasDefault); err != nil {
+				return nil, err
+			}
+			cols = append(cols, dbColumn{name: name, sqlType: sqlType, notNull: nullable == "NO", hasDefault: hasDefault})
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("meddler.ValidateStruct: table %s not found", table)
+	}
+	return cols, nil
+}
+
+// typeFamily classifies a column type loosely so that equivalent spellings
+// (INT4, integer, BIGINT UNSIGNED, ...) compare equal. It returns the empty
+// string for types it does not recognize.
+func typeFamily(sqlType string) string {
+	t := strings.ToUpper(sqlType)
+	switch {
+	case strings.Contains(t, "GEOM"), strings.Contains(t, "POINT"):
+		return "geometry"
+	case strings.Contains(t, "BOOL"):
+		return "bool"
+	case strings.Contains(t, "INT"), strings.Contains(t, "SERIAL"):
+		return "integer"
+	case strings.Contains(t, "CHAR"), strings.Contains(t, "TEXT"), strings.Contains(t, "CLOB"):
+		return "text"
+	case strings.Contains(t, "BLOB"), strings.Contains(t, "BYTEA"), strings.Contains(t, "BINARY"), strings.Contains(t, "JSON"):
+		return "blob"
+	case strings.Contains(t, "REAL"), strings.Contains(t, "FLOA"), strings.Contains(t, "DOUB"),
+		strings.Contains(t, "NUMERIC"), strings.Contains(t, "DECIMAL"):
+		return "real"
+	case strings.Contains(t, "DATE"), strings.Contains(t, "TIME"):
+		return "time"
+	}
+	return ""
+}
+
+// compatibleTypes reports whether a column of type actual can hold values
+// meant for a column of type expected.
+func compatibleTypes(expected, actual string) bool {
+	e, a := typeFamily(expected), typeFamily(actual)
+	if e == "" || a == "" || e == a {
+		return true
+	}
+	group := func(f string) string {
+		switch f {
+		case "bool":
+			return "integer"
+		case "text":
+			return "blob"
+		}
+		return f
+	}
+	return group(e) == group(a)
+}
@@ -0,0 +1,156 @@
+package meddlerx
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateStruct(t *testing.T) {
+	once.Do(setup)
+
+	if err := SQLite.ValidateStruct(testCtx, db, "person", new(Person)); err != nil {
+		t.Errorf("ValidateStruct on matching table: %v", err)
+	}
+
+	type drifted struct {
+		ID      int64  `meddler:"id,pk"`
+		Email   string `meddler:"Email"`
+		Age     string `meddler:"Age"`
+		Missing string `meddler:"missing"`
+	}
+	err := SQLite.ValidateStruct(testCtx, db, "person", new(drifted))
+	var se *SchemaError
+	if !errors.As(err, &se) {
+		t.Fatalf("ValidateStruct on drifted struct: want *SchemaError, got %v", err)
+	}
+	expected := []string{
+		"column Age has type INTEGER, expected TEXT",
+		"column missing is missing",
+		"column name is NOT NULL without a default but is not mapped",
+		"column opened is NOT NULL without a default but is not mapped",
+	}
+	if len(se.Problems) != len(expected) {
+		t.Fatalf("ValidateStruct: expected %d problems, got %d: %v", len(expected), len(se.Problems), se.Problems)
+	}
+	for i := range expected {
+		if se.Problems[i] != expected[i] {
+			t.Errorf("ValidateStruct problem %d: expected %q, got %q", i, expected[i], se.Problems[i])
+		}
+	}
+
+	if err := SQLite.ValidateStruct(testCtx, db, "no_such_table", new(Person)); err == nil {
+		t.Errorf("ValidateStruct on missing table: expected err, got nil")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	once.Do(setup)
+
+	stmts, err := SQLite.Diff(testCtx, db, "person", new(Person))
+	if err != nil {
+		t.Fatalf("Diff error: %v", err)
+	}
+	if len(stmts) != 0 {
+		t.Errorf("Diff on matching table: expected no statements, got %v", stmts)
+	}
+
+	type grown struct {
+		ID       int64  `meddler:"id,pk"`
+		Name     string `meddler:"name"`
+		Age      int    `meddler:"Age"`
+		Nickname string `meddler:"nickname,zeroisnull"`
+	}
+	stmts, err = SQLite.Diff(testCtx, db, "person", new(grown))
+	if err != nil {
+		t.Fatalf("Diff error: %v", err)
+	}
+	expected := []string{
+		`-- column Age should be NOT NULL, but SQLite cannot change nullability without rebuilding table person`,
+		`ALTER TABLE "person" ADD COLUMN "nickname" TEXT`,
+	}
+	if len(stmts) != len(expected) {
+		t.Fatalf("Diff: expected %d statements, got %v", len(expected), stmts)
+	}
+	for i := range expected {
+		if stmts[i] != expected[i] {
+			t.Errorf("Diff statement %d: expected %q, got %q", i, expected[i], stmts[i])
+		}
+	}
+
+	// make sure the suggested ADD COLUMN actually applies
+	if _, err := db.Exec("create table person_diff as select * from person"); err != nil {
+		t.Fatalf("copying person table: %v", err)
+	}
+	defer db.Exec("drop table person_diff")
+	stmts, err = SQLite.Diff(testCtx, db, "person_diff", new(grown))
+	if err != nil {
+		t.Fatalf("Diff error: %v", err)
+	}
+	if _, err := db.Exec(stmts[len(stmts)-1]); err != nil {
+		t.Errorf("applying %q: %v", stmts[len(stmts)-1], err)
+	}
+}
+
+func TestTableColumnsQuery(t *testing.T) {
+	once.Do(setup)
+
+	// SQL Server has no current_schema() and no boolean select expressions
+	q := new(recordingQuerier)
+	SQLServer.ValidateStruct(testCtx, q, "person", new(Person))
+	if !strings.Contains(q.query, "table_schema = SCHEMA_NAME()") || !strings.Contains(q.query, "CASE WHEN column_default IS NOT NULL") {
+		t.Errorf("ValidateStruct for SQL Server: unexpected query %s", q.query)
+	}
+	PostgreSQL.ValidateStruct(testCtx, q, "person", new(Person))
+	if !strings.Contains(q.query, "table_schema = current_schema()") {
+		t.Errorf("ValidateStruct for PostgreSQL: unexpected query %s", q.query)
+	}
+}
+
+func TestDiffDialects(t *testing.T) {
+	type grown struct {
+		ID       int64  `meddler:"id,pk"`
+		Name     string `meddler:"name"`
+		Nickname string `meddler:"nickname,zeroisnull"`
+		Rank     int    `meddler:"rank"`
+	}
+	data, err := getFields(reflect.TypeOf(new(grown)))
+	if err != nil {
+		t.Fatalf("getFields error: %v", err)
+	}
+	live := []dbColumn{{name: "id", notNull: true, hasDefault: true}, {name: "name"}}
+
+	tests := []struct {
+		d        *Database
+		expected []string
+	}{
+		{PostgreSQL, []string{
+			`ALTER TABLE "person" ALTER COLUMN "name" SET NOT NULL`,
+			`ALTER TABLE "person" ADD COLUMN "nickname" TEXT`,
+			`ALTER TABLE "person" ADD COLUMN "rank" BIGINT`,
+			`-- column rank was added as nullable; fill it in for existing rows, then make it NOT NULL`,
+		}},
+		{SQLServer, []string{
+			`ALTER TABLE "person" ALTER COLUMN "name" TEXT NOT NULL`,
+			`ALTER TABLE "person" ADD "nickname" TEXT`,
+			`ALTER TABLE "person" ADD "rank" BIGINT`,
+			`-- column rank was added as nullable; fill it in for existing rows, then make it NOT NULL`,
+		}},
+		{ANSI, []string{
+			`ALTER TABLE "person" ALTER COLUMN "name" SET NOT NULL`,
+			`ALTER TABLE "person" ADD COLUMN "nickname" TEXT`,
+			`ALTER TABLE "person" ADD COLUMN "rank" BIGINT`,
+			`-- column rank was added as nullable; fill it in for existing rows, then make it NOT NULL`,
+		}},
+	}
+	for _, test := range tests {
+		stmts, err := test.d.diffStatements("person", data, reflect.TypeOf(grown{}), live)
+		if err != nil {
+			t.Fatalf("Diff for %s error: %v", test.d.Dialect, err)
+		}
+		if !reflect.DeepEqual(stmts, test.expected) {
+			t.Errorf("Diff for %s: expected\n%s\ngot\n%s", test.d.Dialect, strings.Join(test.expected, "\n"), strings.Join(stmts, "\n"))
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package meddlerx
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SelectColumns maps a requested set of fields, such as the selection of a
+// GraphQL resolver or a sparse fieldset parameter, to the columns of dst.
+// Fields may be named by column, by Go field name, or by the name in the
+// field's json tag, which is usually what API clients see. It returns the
+// quoted column list for a SELECT, in struct order and always including
+// the primary key, and a FieldMask of the same columns for LoadWhere and
+// LoadAllWhere:
+//
+//	cols, mask, err := meddlerx.SelectColumns(new(Person), []string{"name", "email"})
+//	err = meddlerx.LoadAllWhere(ctx, db, "person", &people, mask)
+//
+// Scanning a result that has only these columns leaves the other fields of
+// dst at their zero values. Unknown names are an error.
+func (d *Database) SelectColumns(dst interface{}, fields []string) (string, FieldMask, error) {
+	dstType := reflect.TypeOf(dst)
+	data, err := getFields(dstType)
+	if err != nil {
+		return "", nil, err
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range fields {
+		column, err := data.resolve(dstType, name)
+		if err != nil {
+			if column = data.jsonColumn(dstType, name); column == "" {
+				return "", nil, fmt.Errorf("meddler.SelectColumns: %w", err)
+			}
+		}
+		wanted[column] = true
+	}
+
+	var mask FieldMask
+	var quoted []string
+	for _, column := range data.columns {
+		if wanted[column] || column == data.pk {
+			mask = append(mask, column)
+			quoted = append(quoted, d.quoted(column))
+		}
+	}
+	return strings.Join(quoted, ","), mask, nil
+}
+
+// SelectColumns using the Default Database type
+func SelectColumns(dst interface{}, fields []string) (string, FieldMask, error) {
+	return Default.SelectColumns(dst, fields)
+}
+
+// jsonColumn returns the column whose field has name in its json tag, or ""
+// if there is none.
+func (data *structData) jsonColumn(srcType reflect.Type, name string) string {
+	structType := srcType.Elem()
+	for _, column := range data.columns {
+		tag := structType.FieldByIndex(data.fields[column].index).Tag.Get("json")
+		if tagName, _, _ := strings.Cut(tag, ","); tagName != "" && tagName != "-" && tagName == name {
+			return column
+		}
+	}
+	return ""
+}
@@ -0,0 +1,55 @@
+package meddlerx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type apiPerson struct {
+	ID        int64  `meddler:"id,pk" json:"id"`
+	FirstName string `meddler:"name" json:"firstName"`
+	Email     string `json:"email,omitempty"`
+	Age       int    `meddler:"Age,zeroisnull" json:"-"`
+}
+
+func TestSelectColumns(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	cols, mask, err := SQLite.SelectColumns(new(apiPerson), []string{"email", "firstName", "email"})
+	if err != nil {
+		t.Fatalf("SelectColumns error: %v", err)
+	}
+	if expected := `"id","name","Email"`; cols != expected {
+		t.Errorf("expected columns %s, got %s", expected, cols)
+	}
+	if expected := (FieldMask{"id", "name", "Email"}); !reflect.DeepEqual(mask, expected) {
+		t.Errorf("expected mask %v, got %v", expected, mask)
+	}
+
+	var people []*apiPerson
+	if err := SQLite.LoadAllWhere(testCtx, db, "person", &people, mask, OrderBy("id")); err != nil {
+		t.Fatalf("LoadAllWhere error: %v", err)
+	}
+	if len(people) != 2 || people[0].FirstName != "Alice" || people[0].Email != alice.Email || people[0].Age != 0 {
+		t.Errorf("masked load got %+v", people)
+	}
+
+	var p apiPerson
+	if err := SQLite.QueryRow(testCtx, db, &p, "SELECT "+cols+` FROM "person" WHERE "id" = ?`, people[1].ID); err != nil {
+		t.Fatalf("QueryRow error: %v", err)
+	}
+	if p.FirstName != "Bob" || p.Age != 0 {
+		t.Errorf("masked query got %+v", p)
+	}
+
+	cols, _, err = MySQL.SelectColumns(new(apiPerson), []string{"Age"})
+	if err != nil || cols != "`id`,`Age`" {
+		t.Errorf("selecting a column hidden from json: got %s, %v", cols, err)
+	}
+	if _, _, err := SQLite.SelectColumns(new(apiPerson), []string{"phone"}); err == nil || !strings.Contains(err.Error(), "phone") {
+		t.Errorf("expected an error for an unknown field, got %v", err)
+	}
+}
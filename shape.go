@@ -0,0 +1,139 @@
+package meddlerx
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// shapeSource checks that src is a slice (or pointer to a slice) of
+// structs or pointers to structs, and returns it along with the field
+// metadata and the index path of the named field.
+func shapeSource(op string, src interface{}, field string) (reflect.Value, []int, error) {
+	srcVal := reflect.ValueOf(src)
+	if srcVal.Kind() == reflect.Ptr && srcVal.Elem().Kind() == reflect.Slice {
+		srcVal = srcVal.Elem()
+	}
+	if srcVal.Kind() != reflect.Slice {
+		return srcVal, nil, fmt.Errorf("meddler.%s: source must be a slice, found %T", op, src)
+	}
+	eltType := srcVal.Type().Elem()
+	ptrType := eltType
+	if eltType.Kind() == reflect.Struct {
+		ptrType = reflect.PtrTo(eltType)
+	}
+	if ptrType.Kind() != reflect.Ptr || ptrType.Elem().Kind() != reflect.Struct {
+		return srcVal, nil, fmt.Errorf("meddler.%s: source elements must be structs or pointers to structs, found %T", op, src)
+	}
+	data, err := getFields(ptrType)
+	if err != nil {
+		return srcVal, nil, err
+	}
+	column, err := data.resolve(ptrType, field)
+	if err != nil {
+		return srcVal, nil, fmt.Errorf("meddler.%s: %w", op, err)
+	}
+	return srcVal, data.fields[column].index, nil
+}
+
+// shapeKey returns the value of the field at index in elt, converted to
+// keyType. Only conversions between numeric types, or between string types,
+// are made; Go would also turn an integer into a string holding that rune.
+func shapeKey(op string, elt reflect.Value, index []int, keyType reflect.Type) (reflect.Value, error) {
+	if elt.Kind() == reflect.Ptr {
+		elt = elt.Elem()
+	}
+	key := elt.FieldByIndex(index)
+	if key.Type().AssignableTo(keyType) {
+		return key, nil
+	}
+	from, to := key.Kind(), keyType.Kind()
+	sameKind := (isNumericKind(from) && isNumericKind(to)) || (from == reflect.String && to == reflect.String)
+	if !sameKind || !key.Type().ConvertibleTo(keyType) {
+		return key, fmt.Errorf("meddler.%s: cannot use %s as a %s map key", op, key.Type(), keyType)
+	}
+	return key.Convert(keyType), nil
+}
+
+// isNumericKind reports whether k is an integer or floating-point kind.
+func isNumericKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// IndexBy builds a map from the elements of src keyed by the named field,
+// which may be given by Go field name or column name. src is a slice of
+// structs or of pointers to structs, as filled in by QueryAll, and dst a
+// pointer to a map whose values have the element type, e.g.
+//
+//	var byEmail map[string]*Person
+//	err := meddlerx.IndexBy(people, "Email", &byEmail)
+//
+// Later elements replace earlier ones with the same key.
+func IndexBy(src interface{}, field string, dst interface{}) error {
+	srcVal, index, err := shapeSource("IndexBy", src, field)
+	if err != nil {
+		return err
+	}
+	mapVal, err := shapeMap("IndexBy", dst, srcVal.Type().Elem())
+	if err != nil {
+		return err
+	}
+	for i := 0; i < srcVal.Len(); i++ {
+		elt := srcVal.Index(i)
+		key, err := shapeKey("IndexBy", elt, index, mapVal.Type().Key())
+		if err != nil {
+			return err
+		}
+		mapVal.SetMapIndex(key, elt)
+	}
+	return nil
+}
+
+// GroupBy is like IndexBy, but collects all the elements with the same key,
+// in their original order. dst is a pointer to a map whose values are
+// slices of the element type, e.g. *map[int64][]*Order.
+func GroupBy(src interface{}, field string, dst interface{}) error {
+	srcVal, index, err := shapeSource("GroupBy", src, field)
+	if err != nil {
+		return err
+	}
+	mapVal, err := shapeMap("GroupBy", dst, reflect.SliceOf(srcVal.Type().Elem()))
+	if err != nil {
+		return err
+	}
+	for i := 0; i < srcVal.Len(); i++ {
+		elt := srcVal.Index(i)
+		key, err := shapeKey("GroupBy", elt, index, mapVal.Type().Key())
+		if err != nil {
+			return err
+		}
+		group := mapVal.MapIndex(key)
+		if !group.IsValid() {
+			group = reflect.Zero(mapVal.Type().Elem())
+		}
+		mapVal.SetMapIndex(key, reflect.Append(group, elt))
+	}
+	return nil
+}
+
+// shapeMap checks that dst is a pointer to a map with values of type
+// valType, allocating the map if needed.
+func shapeMap(op string, dst interface{}, valType reflect.Type) (reflect.Value, error) {
+	dstVal := reflect.ValueOf(dst)
+	if dstVal.Kind() != reflect.Ptr || dstVal.IsNil() || dstVal.Elem().Kind() != reflect.Map {
+		return dstVal, fmt.Errorf("meddler.%s: destination must be a pointer to a map, found %T", op, dst)
+	}
+	mapVal := dstVal.Elem()
+	if mapVal.Type().Elem() != valType {
+		return mapVal, fmt.Errorf("meddler.%s: destination map values must be %s, found %T", op, valType, dst)
+	}
+	if mapVal.IsNil() {
+		mapVal.Set(reflect.MakeMap(mapVal.Type()))
+	}
+	return mapVal, nil
+}
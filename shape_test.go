@@ -0,0 +1,67 @@
+package meddlerx
+
+import (
+	"testing"
+)
+
+func TestIndexBy(t *testing.T) {
+	people := []*Person{
+		{ID: 1, Name: "Alice", Email: "alice@alice.com", Age: 30},
+		{ID: 2, Name: "Bob", Email: "bob@bob.com", Age: 30},
+	}
+
+	var byEmail map[string]*Person
+	if err := IndexBy(people, "Email", &byEmail); err != nil {
+		t.Fatalf("IndexBy error: %v", err)
+	}
+	if len(byEmail) != 2 || byEmail["bob@bob.com"] != people[1] {
+		t.Errorf("IndexBy: got %v", byEmail)
+	}
+
+	// by column name, from a slice of values
+	values := []Person{*people[0], *people[1]}
+	var byName map[string]Person
+	if err := IndexBy(&values, "name", &byName); err != nil {
+		t.Fatalf("IndexBy error: %v", err)
+	}
+	if byName["Alice"].ID != 1 {
+		t.Errorf("IndexBy by column: got %v", byName)
+	}
+
+	var wrong map[string]Person
+	if err := IndexBy(people, "Email", &wrong); err == nil {
+		t.Errorf("IndexBy with mismatched values: expected an error")
+	}
+	if err := IndexBy(people, "Ephemeral", &byEmail); err == nil {
+		t.Errorf("IndexBy on unmapped field: expected an error")
+	}
+	var byTime map[string]*Person
+	if err := IndexBy(people, "Opened", &byTime); err == nil {
+		t.Errorf("IndexBy with unconvertible key: expected an error")
+	}
+	// Go would convert an integer to the string for that rune
+	var byIDString map[string]*Person
+	if err := IndexBy(people, "ID", &byIDString); err == nil {
+		t.Errorf("IndexBy with an int field and string keys: expected an error, got %v", byIDString)
+	}
+	var byInt32 map[int32]*Person
+	if err := IndexBy(people, "ID", &byInt32); err != nil || byInt32[2] != people[1] {
+		t.Errorf("IndexBy with int32 keys: got %v, %v", byInt32, err)
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	people := []*Person{
+		{ID: 1, Name: "Alice", Age: 30},
+		{ID: 2, Name: "Bob", Age: 40},
+		{ID: 3, Name: "Carol", Age: 30},
+	}
+
+	var byAge map[int64][]*Person
+	if err := GroupBy(people, "Age", &byAge); err != nil {
+		t.Fatalf("GroupBy error: %v", err)
+	}
+	if len(byAge) != 2 || len(byAge[30]) != 2 || byAge[30][1] != people[2] || len(byAge[40]) != 1 {
+		t.Errorf("GroupBy: got %v", byAge)
+	}
+}
@@ -0,0 +1,206 @@
+package meddlerx
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sync"
+)
+
+type shardKeyKey struct{}
+
+// WithShardKey returns a context that carries key, the shard key used by a
+// ShardedDatabase when it cannot take one from the record, as for Load and
+// QueryAll.
+func WithShardKey(ctx context.Context, key interface{}) context.Context {
+	return context.WithValue(ctx, shardKeyKey{}, key)
+}
+
+// ShardedDatabase routes operations to one of several Queriers, one per
+// shard, by a shard key such as a customer ID. Writes take the key from the
+// record's KeyColumn field, falling back on the key in the context set by
+// WithShardKey; reads use the key in the context. QueryAll without a key
+// in the context runs on every shard and merges the results.
+//
+// The Cache of the Database and any identity map in the context key rows
+// by table and primary key only, not by shard. If they are used, primary
+// keys must be unique across all shards, e.g. drawn from a shared
+// sequence or with a distinct range per shard; otherwise Load can return a
+// row from another shard that has the same key.
+type ShardedDatabase struct {
+	// Database is the Database used on every shard. If nil, Default is used.
+	Database *Database
+
+	// Shards are the Queriers for the shards, typically one *sql.DB each.
+	Shards []Querier
+
+	// KeyColumn is the column of the shard key in records.
+	KeyColumn string
+
+	// Route maps a shard key to the index of its shard. If nil, integer
+	// keys are taken modulo the number of shards, and string keys are
+	// hashed first.
+	Route func(key interface{}) (int, error)
+}
+
+func (s *ShardedDatabase) database() *Database {
+	if s.Database == nil {
+		return Default
+	}
+	return s.Database
+}
+
+// Shard returns the Querier for the shard that key belongs to.
+func (s *ShardedDatabase) Shard(key interface{}) (Querier, error) {
+	if len(s.Shards) == 0 {
+		return nil, fmt.Errorf("meddler.ShardedDatabase: no shards")
+	}
+	route := s.Route
+	if route == nil {
+		route = s.defaultRoute
+	}
+	i, err := route(key)
+	if err != nil {
+		return nil, fmt.Errorf("meddler.ShardedDatabase: %w", err)
+	}
+	if i < 0 || i >= len(s.Shards) {
+		return nil, fmt.Errorf("meddler.ShardedDatabase: shard key %v routed to shard %d of %d", key, i, len(s.Shards))
+	}
+	return s.Shards[i], nil
+}
+
+func (s *ShardedDatabase) defaultRoute(key interface{}) (int, error) {
+	n := uint64(len(s.Shards))
+	v := reflect.ValueOf(key)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(uint64(v.Int()) % n), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(v.Uint() % n), nil
+	case reflect.String:
+		h := fnv.New64a()
+		h.Write([]byte(v.String()))
+		return int(h.Sum64() % n), nil
+	}
+	return 0, fmt.Errorf("unsupported shard key type %T", key)
+}
+
+// shardFor returns the shard for src, or for the key in ctx if src is nil
+// or has no KeyColumn field.
+func (s *ShardedDatabase) shardFor(ctx context.Context, op string, src interface{}) (Querier, error) {
+	if src != nil && s.KeyColumn != "" {
+		data, err := getFields(reflect.TypeOf(src))
+		if err != nil {
+			return nil, err
+		}
+		if field, present := data.fields[s.KeyColumn]; present {
+			return s.Shard(reflect.ValueOf(src).Elem().FieldByIndex(field.index).Interface())
+		}
+	}
+	key := ctx.Value(shardKeyKey{})
+	if key == nil {
+		return nil, fmt.Errorf("meddler.ShardedDatabase.%s: no shard key in record or context", op)
+	}
+	return s.Shard(key)
+}
+
+// Load loads a record by primary key from the shard for the key in ctx.
+// With a Cache or identity map, pk must be unique across shards; see
+// ShardedDatabase.
+func (s *ShardedDatabase) Load(ctx context.Context, table string, dst interface{}, pk int64) error {
+	db, err := s.shardFor(ctx, "Load", nil)
+	if err != nil {
+		return err
+	}
+	return s.database().Load(ctx, db, table, dst, pk)
+}
+
+// Insert inserts a record into the shard for its shard key.
+func (s *ShardedDatabase) Insert(ctx context.Context, table string, src interface{}, opts ...WriteOption) error {
+	db, err := s.shardFor(ctx, "Insert", src)
+	if err != nil {
+		return err
+	}
+	return s.database().Insert(ctx, db, table, src, opts...)
+}
+
+// Update updates a record in the shard for its shard key.
+func (s *ShardedDatabase) Update(ctx context.Context, table string, src interface{}, opts ...WriteOption) error {
+	db, err := s.shardFor(ctx, "Update", src)
+	if err != nil {
+		return err
+	}
+	return s.database().Update(ctx, db, table, src, opts...)
+}
+
+// Save saves a record in the shard for its shard key.
+func (s *ShardedDatabase) Save(ctx context.Context, table string, src interface{}, opts ...WriteOption) error {
+	db, err := s.shardFor(ctx, "Save", src)
+	if err != nil {
+		return err
+	}
+	return s.database().Save(ctx, db, table, src, opts...)
+}
+
+// Delete deletes a record from the shard for its shard key.
+func (s *ShardedDatabase) Delete(ctx context.Context, table string, src interface{}, opts ...WriteOption) error {
+	db, err := s.shardFor(ctx, "Delete", src)
+	if err != nil {
+		return err
+	}
+	return s.database().Delete(ctx, db, table, src, opts...)
+}
+
+// QueryAll runs the query on the shard for the key in ctx. If ctx has no
+// shard key, it runs the query on every shard concurrently and appends the
+// results to dst in shard order, so any ORDER BY only holds within each
+// shard.
+func (s *ShardedDatabase) QueryAll(ctx context.Context, dst interface{}, query string, args ...interface{}) error {
+	if ctx.Value(shardKeyKey{}) != nil {
+		db, err := s.shardFor(ctx, "QueryAll", nil)
+		if err != nil {
+			return err
+		}
+		return s.database().QueryAll(ctx, db, dst, query, args...)
+	}
+
+	dstVal := reflect.ValueOf(dst)
+	if dstVal.Kind() != reflect.Ptr || dstVal.IsNil() || dstVal.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("meddler.ShardedDatabase.QueryAll: destination must be a pointer to a slice, found %T", dst)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	results := make([]reflect.Value, len(s.Shards))
+	for i, db := range s.Shards {
+		results[i] = reflect.New(dstVal.Elem().Type())
+		wg.Add(1)
+		go func(db Querier, part interface{}) {
+			defer wg.Done()
+			if err := s.database().QueryAll(ctx, db, part, query, args...); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}(db, results[i].Interface())
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+
+	merged := dstVal.Elem()
+	for _, part := range results {
+		merged = reflect.AppendSlice(merged, part.Elem())
+	}
+	dstVal.Elem().Set(merged)
+	return nil
+}
@@ -0,0 +1,70 @@
+package meddlerx
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestShardedDatabase(t *testing.T) {
+	var shards []Querier
+	for i := 0; i < 2; i++ {
+		shard, err := sql.Open("sqlite3", ":memory:")
+		if err != nil {
+			t.Fatalf("Open error: %v", err)
+		}
+		defer shard.Close()
+		// one connection, so every query sees the same in-memory database
+		shard.SetMaxOpenConns(1)
+		if _, err := shard.Exec(schema1); err != nil {
+			t.Fatalf("DB error: %v", err)
+		}
+		shards = append(shards, shard)
+	}
+	s := &ShardedDatabase{Database: SQLite, Shards: shards, KeyColumn: "Age"}
+
+	for _, p := range []*Person{{Name: "Alice", Age: 10}, {Name: "Bob", Age: 11}, {Name: "Carol", Age: 12}} {
+		if err := s.Insert(testCtx, "person", p); err != nil {
+			t.Fatalf("Insert error: %v", err)
+		}
+	}
+
+	var names string
+	if err := shards[0].(*sql.DB).QueryRow("select group_concat(name) from (select name from person order by name)").Scan(&names); err != nil {
+		t.Fatalf("DB error: %v", err)
+	}
+	if names != "Alice,Carol" {
+		t.Errorf("Insert: expected Alice and Carol on shard 0, got %s", names)
+	}
+
+	// Bob is the first row on shard 1
+	elt := new(Person)
+	if err := s.Load(WithShardKey(testCtx, 11), "person", elt, 1); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if elt.Name != "Bob" {
+		t.Errorf("Load: expected Bob, got %s", elt.Name)
+	}
+	if err := s.Load(testCtx, "person", elt, 1); err == nil {
+		t.Errorf("Load without a shard key: expected err, got nil")
+	}
+
+	var people []*Person
+	if err := s.QueryAll(testCtx, &people, "select * from person"); err != nil {
+		t.Fatalf("QueryAll error: %v", err)
+	}
+	if len(people) != 3 {
+		t.Errorf("QueryAll across shards: expected 3 rows, got %d", len(people))
+	}
+	people = nil
+	if err := s.QueryAll(WithShardKey(testCtx, 12), &people, "select * from person"); err != nil {
+		t.Fatalf("QueryAll error: %v", err)
+	}
+	if len(people) != 2 {
+		t.Errorf("QueryAll on one shard: expected 2 rows, got %d", len(people))
+	}
+
+	s.Route = func(key interface{}) (int, error) { return 5, nil }
+	if err := s.Insert(testCtx, "person", &Person{Name: "Dave", Age: 1}); err == nil {
+		t.Errorf("Insert routed to a missing shard: expected err, got nil")
+	}
+}
@@ -0,0 +1,63 @@
+package meddlerx
+
+import (
+	"context"
+	"time"
+)
+
+// ScanStats describes one call to ScanAll or QueryAll (or their variants),
+// as reported to the OnScan callback of a Database.
+type ScanStats struct {
+	// Query is the SQL text for QueryAll, and empty for ScanAll.
+	Query string
+
+	// Rows is the number of rows scanned into the destination.
+	Rows int
+
+	// Columns is the number of columns in the result, and Matched the
+	// number of those that map to a struct field.
+	Columns int
+	Matched int
+
+	// Driver is the time spent in rows.Next and rows.Scan, and Total the
+	// time for the whole scan. The difference is mostly reflection and
+	// meddler overhead.
+	Driver time.Duration
+	Total  time.Duration
+
+	// Err is the error the scan returned, if any.
+	Err error
+}
+
+// Reflection returns the time spent outside the driver.
+func (s *ScanStats) Reflection() time.Duration {
+	return s.Total - s.Driver
+}
+
+// now returns the current time if stats are being collected.
+func (s *ScanStats) now() time.Time {
+	if s == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// addDriver adds the time since start to the driver time.
+func (s *ScanStats) addDriver(start time.Time) {
+	if s != nil {
+		s.Driver += time.Since(start)
+	}
+}
+
+type scanQueryKey struct{}
+
+// withScanQuery records the query whose rows are being scanned, for
+// ScanStats and the scan plan cache.
+func withScanQuery(ctx context.Context, query string) context.Context {
+	return context.WithValue(ctx, scanQueryKey{}, query)
+}
+
+func scanQuery(ctx context.Context) string {
+	query, _ := ctx.Value(scanQueryKey{}).(string)
+	return query
+}
@@ -0,0 +1,45 @@
+package meddlerx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestOnScan(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	var got []ScanStats
+	d := SQLite.Clone()
+	d.OnScan = func(ctx context.Context, stats *ScanStats) {
+		got = append(got, *stats)
+	}
+
+	query := "select id, name, 1 as unmapped from person"
+	var people []*Person
+	if err := d.QueryAll(testCtx, db, &people, query); err != nil {
+		t.Fatalf("QueryAll error: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("OnScan: expected 1 call, got %d", len(got))
+	}
+	stats := got[0]
+	if stats.Query != query || stats.Rows != 2 || stats.Columns != 3 || stats.Matched != 2 || stats.Err != nil {
+		t.Errorf("OnScan: got %+v", stats)
+	}
+	if stats.Driver <= 0 || stats.Total < stats.Driver || stats.Reflection() < 0 {
+		t.Errorf("OnScan: bad timings %+v", stats)
+	}
+
+	rows, err := db.Query("select * from person")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if err := d.ScanAll(rows, &people); err != nil {
+		t.Fatalf("ScanAll error: %v", err)
+	}
+	if len(got) != 2 || got[1].Query != "" || got[1].Rows != 2 {
+		t.Errorf("OnScan after ScanAll: got %+v", got)
+	}
+}
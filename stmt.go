@@ -0,0 +1,133 @@
+package meddlerx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type statementTimeoutKey struct{}
+
+// WithStatementTimeout returns a context that overrides the StatementTimeout
+// of the Database for calls made with it. A timeout of zero disables the
+// limit for those calls.
+func WithStatementTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, statementTimeoutKey{}, timeout)
+}
+
+// statementTimeout returns the timeout that applies to a call made with ctx.
+func (d *Database) statementTimeout(ctx context.Context) time.Duration {
+	if timeout, ok := ctx.Value(statementTimeoutKey{}).(time.Duration); ok {
+		return timeout
+	}
+	return d.StatementTimeout
+}
+
+// begin prepares a call to db made on behalf of one meddler operation, op,
+// on table (which may be empty). It applies the statement timeout:
+// PostgreSQL transactions get SET LOCAL statement_timeout, MySQL SELECT
+// statements get a MAX_EXECUTION_TIME hint, and everything else runs under a
+// context deadline. It also adds the QueryTags comment, if any. The returned
+// cancel function must be called when the operation is finished. Nested
+// calls are passed through untouched, even when forTable has given them a
+// different Database, so the outermost operation's settings apply.
+func (d *Database) begin(ctx context.Context, db Querier, op, table string) (context.Context, Querier, context.CancelFunc) {
+	if _, ok := db.(*stmtQuerier); ok {
+		return ctx, db, func() {}
+	}
+	timeout := d.statementTimeout(ctx)
+	var comment string
+	if d.QueryTags != nil {
+		comment = sqlComment(d.QueryTags(ctx, op, table))
+	}
+	if timeout <= 0 && comment == "" {
+		return ctx, db, func() {}
+	}
+
+	sq := &stmtQuerier{d: d, db: db, timeout: timeout, comment: comment}
+	if timeout <= 0 {
+		return ctx, sq, func() {}
+	}
+	if inTx(db) && d.Dialect == DialectPostgreSQL {
+		// the server enforces the limit, and cancelling the context would
+		// only abort the transaction a second time
+		sq.setLocal = true
+		return ctx, sq, func() {}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, sq, cancel
+}
+
+// stmtQuerier wraps the Querier for a single operation and rewrites or
+// prefixes the statements it sends.
+type stmtQuerier struct {
+	d        *Database
+	db       Querier
+	timeout  time.Duration
+	setLocal bool   // send SET LOCAL statement_timeout before the next statement
+	comment  string // appended to every statement
+}
+
+// prepare applies the statement timeout and comment to query.
+func (sq *stmtQuerier) prepare(ctx context.Context, query string) (string, error) {
+	if sq.timeout > 0 {
+		var err error
+		if query, err = sq.prepareTimeout(ctx, query); err != nil {
+			return "", err
+		}
+	}
+	if sq.comment != "" {
+		query = appendComment(query, sq.comment)
+	}
+	return query, nil
+}
+
+// prepareTimeout applies the statement timeout to query.
+func (sq *stmtQuerier) prepareTimeout(ctx context.Context, query string) (string, error) {
+	ms := sq.timeout.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	if sq.setLocal {
+		q := fmt.Sprintf("SET LOCAL statement_timeout = %d", ms)
+		if _, err := sq.db.ExecContext(ctx, q); err != nil {
+			return "", sq.d.queryError("StatementTimeout", "", q, nil, err)
+		}
+		sq.setLocal = false
+	}
+	if sq.d.Dialect == DialectMySQL {
+		trimmed := strings.TrimLeft(query, " \t\r\n")
+		if len(trimmed) >= 6 && strings.EqualFold(trimmed[:6], "SELECT") {
+			query = fmt.Sprintf("SELECT /*+ MAX_EXECUTION_TIME(%d) */%s", ms, trimmed[6:])
+		}
+	}
+	return query, nil
+}
+
+func (sq *stmtQuerier) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	query, err := sq.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return sq.db.QueryContext(ctx, query, args...)
+}
+
+func (sq *stmtQuerier) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	prepared, err := sq.prepare(ctx, query)
+	if err != nil {
+		// there is no way to build a *sql.Row holding an error, so send
+		// the statement as it is and let it fail in the same transaction
+		return sq.db.QueryRowContext(ctx, query, args...)
+	}
+	return sq.db.QueryRowContext(ctx, prepared, args...)
+}
+
+func (sq *stmtQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	query, err := sq.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return sq.db.ExecContext(ctx, query, args...)
+}
@@ -0,0 +1,64 @@
+package meddlerx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStatementTimeout(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	type count struct {
+		N int64 `meddler:"n"`
+	}
+	const slow = `WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x+1 FROM c WHERE x < 1000000000)
+		SELECT count(*) AS n FROM c`
+
+	limited := *SQLite
+	limited.StatementTimeout = 20 * time.Millisecond
+	err := limited.QueryRow(testCtx, db, new(count), slow)
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) && err.Error() != "interrupted" {
+		t.Errorf("slow query with StatementTimeout: expected deadline error, got %v", err)
+	}
+
+	// quick statements are unaffected
+	var people []*Person
+	if err := limited.QueryAll(testCtx, db, &people, "select * from person"); err != nil || len(people) != 2 {
+		t.Errorf("QueryAll with StatementTimeout: got %d rows, err %v", len(people), err)
+	}
+
+	// a per-call timeout overrides the Database setting
+	ctx := WithStatementTimeout(testCtx, 20*time.Millisecond)
+	if err := SQLite.QueryRow(ctx, db, new(count), slow); err == nil {
+		t.Errorf("slow query with WithStatementTimeout: expected err, got nil")
+	}
+}
+
+func TestStatementTimeoutHint(t *testing.T) {
+	once.Do(setup)
+
+	mysql := *MySQL
+	mysql.StatementTimeout = 1500 * time.Millisecond
+	rec := new(recordingQuerier)
+	var people []*Person
+	mysql.QueryAll(testCtx, rec, &people, "  select * from person")
+	if expected := "SELECT /*+ MAX_EXECUTION_TIME(1500) */ * from person"; rec.query != expected {
+		t.Errorf("MySQL statement timeout: expected %s, got %s", expected, rec.query)
+	}
+
+	// other statements are left alone
+	mysql.Delete(testCtx, rec, "person", &Person{ID: 1})
+	if expected := "DELETE FROM `person` WHERE `id`=?"; rec.query != expected {
+		t.Errorf("MySQL statement timeout: expected %s, got %s", expected, rec.query)
+	}
+
+	// and nothing is added when the timeout is disabled for the call
+	mysql.QueryAll(WithStatementTimeout(testCtx, 0), rec, &people, "select * from person")
+	if expected := "select * from person"; rec.query != expected {
+		t.Errorf("disabled statement timeout: expected %s, got %s", expected, rec.query)
+	}
+}
@@ -0,0 +1,50 @@
+package meddlerx
+
+import "fmt"
+
+// TableConfig overrides Database settings for one table, so a single
+// Database can serve tables that live in other schemas or behind foreign
+// data wrappers and federated engines with their own quoting rules:
+//
+//	d := meddlerx.PostgreSQL.Clone()
+//	d.Tables = map[string]meddlerx.TableConfig{
+//		"ledger":   {Schema: "accounts"},
+//		"rates":    {ReadOnly: true},
+//		"legacy_p": {Quote: "`"},
+//	}
+type TableConfig struct {
+	// Quote, if set, replaces the Database's quote character for the table
+	// name and, in operations on the table, its columns.
+	Quote string
+
+	// Schema, if set, qualifies the table name in generated SQL, unless
+	// the name is already qualified.
+	Schema string
+
+	// ReadOnly makes writes to the table fail with ErrReadOnly.
+	ReadOnly bool
+}
+
+// forTable returns d with the Tables overrides for table applied to the
+// rest of the Database, or d itself if there are none.
+func (d *Database) forTable(table string) *Database {
+	config, present := d.Tables[table]
+	if !present || config.Quote == "" || config.Quote == d.Quote {
+		return d
+	}
+	clone := *d
+	clone.Quote = config.Quote
+	return &clone
+}
+
+// writable returns an error wrapping ErrReadOnly if op may not write to
+// table.
+func (d *Database) writable(op, table string) error {
+	if d.ReadOnly {
+		return fmt.Errorf("meddler.%s: %w", op, ErrReadOnly)
+	}
+	if d.Tables[table].ReadOnly {
+		return fmt.Errorf("meddler.%s: table %s: %w", op, table, ErrReadOnly)
+	}
+	return nil
+}
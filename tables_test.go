@@ -0,0 +1,119 @@
+package meddlerx
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTableConfig(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	d := SQLite.Clone()
+	d.Tables = map[string]TableConfig{
+		"person":      {Schema: "main", Quote: "`"},
+		"main.person": {ReadOnly: true},
+		"archive":     {Schema: "old"},
+	}
+
+	tests := []struct {
+		table, expected string
+	}{
+		{"person", "`main`.`person`"},
+		{"main.person", `"main"."person"`},
+		{"archive", `"old"."archive"`},
+		{"other", `"other"`},
+	}
+	for _, test := range tests {
+		if got := d.quotedTable(test.table); got != test.expected {
+			t.Errorf("quotedTable(%s): expected %s, got %s", test.table, test.expected, got)
+		}
+	}
+
+	q, err := d.forTable("person").selectQuery("LoadAllWhere", "person", reflect.TypeOf(new(Person)), newQueryOptions([]QueryOption{Fields("name")}))
+	if err != nil {
+		t.Fatalf("selectQuery error: %v", err)
+	}
+	if expected := "SELECT `id`,`name` FROM `main`.`person`"; q != expected {
+		t.Errorf("expected %s, got %s", expected, q)
+	}
+	if d.forTable("other") != d {
+		t.Errorf("expected forTable to return the Database itself for a table without overrides")
+	}
+
+	// the overrides are used for real queries
+	var people []*Person
+	if err := d.LoadAllWhere(testCtx, db, "person", &people, OrderBy("id")); err != nil {
+		t.Fatalf("LoadAllWhere error: %v", err)
+	}
+	if len(people) != 2 || people[0].Name != "Alice" {
+		t.Fatalf("LoadAllWhere got %v", people)
+	}
+	p := people[0]
+	p.Email = "alice@example.org"
+	if err := d.Update(testCtx, db, "person", p); err != nil {
+		t.Errorf("Update error: %v", err)
+	}
+
+	// writes to a read-only table fail, while reads succeed
+	p.Email = "alice@example.net"
+	if err := d.Update(testCtx, db, "main.person", p); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Update of a read-only table: expected ErrReadOnly, got %v", err)
+	}
+	if err := d.Insert(testCtx, db, "main.person", &Person{Name: "Carol"}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Insert into a read-only table: expected ErrReadOnly, got %v", err)
+	}
+	if err := d.DeleteAll(testCtx, db, "main.person"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("DeleteAll on a read-only table: expected ErrReadOnly, got %v", err)
+	}
+	loaded := new(Person)
+	if err := d.Load(testCtx, db, "main.person", loaded, p.ID); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if loaded.Email != "alice@example.org" {
+		t.Errorf("expected email alice@example.org, got %s", loaded.Email)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	once.Do(setup)
+	insertAliceBob(t)
+	defer db.Exec("delete from person")
+
+	replica := SQLite.Clone(WithReadOnly(true))
+	if SQLite.ReadOnly || !replica.ReadOnly {
+		t.Fatalf("WithReadOnly: expected only the clone to be read-only")
+	}
+
+	people := []*Person{}
+	if err := replica.LoadAllWhere(testCtx, db, "person", &people, OrderBy("id")); err != nil || len(people) != 2 {
+		t.Fatalf("LoadAllWhere through a read-only Database: got %d rows, %v", len(people), err)
+	}
+	p := people[0]
+
+	writes := map[string]func() error{
+		"Insert":    func() error { return replica.Insert(testCtx, db, "person", &Person{Name: "Carol"}) },
+		"Update":    func() error { return replica.Update(testCtx, db, "person", p) },
+		"Save":      func() error { return replica.Save(testCtx, db, "person", p) },
+		"Delete":    func() error { return replica.Delete(testCtx, db, "person", p) },
+		"DeleteAll": func() error { return replica.DeleteAll(testCtx, db, "person") },
+		"SaveAll":   func() error { return replica.SaveAll(testCtx, db, "person", people) },
+		"Upsert": func() error {
+			_, err := replica.Upsert(testCtx, db, "person", &Person{Name: "Carol"}, "id")
+			return err
+		},
+		"Batch": func() error { return replica.NewBatch().Update("person", p).Flush(testCtx, db) },
+	}
+	for op, write := range writes {
+		if err := write(); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("%s through a read-only Database: expected ErrReadOnly, got %v", op, err)
+		}
+	}
+
+	var count int
+	if err := db.QueryRow("select count(*) from person").Scan(&count); err != nil || count != 2 {
+		t.Errorf("expected the table to be unchanged with 2 rows, got %d, %v", count, err)
+	}
+}
@@ -0,0 +1,169 @@
+package meddlerx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// IsUniqueViolation reports whether err, or any error it wraps, is a
+// unique or primary key constraint violation. It recognizes the errors of
+// the common drivers without importing them: PostgreSQL SQLSTATE 23505
+// (lib/pq and pgx), MySQL error 1062, SQL Server errors 2627 and 2601, and
+// SQLite's constraint codes 2067 and 1555, falling back on the usual
+// message texts.
+func IsUniqueViolation(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if uniqueViolation(err) {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueViolation(err error) bool {
+	if state, ok := err.(interface{ SQLState() string }); ok && state.SQLState() == "23505" {
+		return true
+	}
+
+	v := reflect.ValueOf(err)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Struct {
+		if code := v.FieldByName("Code"); code.IsValid() && code.Kind() == reflect.String && code.String() == "23505" {
+			return true
+		}
+		if number := v.FieldByName("Number"); number.IsValid() {
+			switch number.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				switch number.Int() {
+				case 1062, 2627, 2601:
+					return true
+				}
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				switch number.Uint() {
+				case 1062, 2627, 2601:
+					return true
+				}
+			}
+		}
+		if code := v.FieldByName("ExtendedCode"); code.IsValid() && code.Kind() == reflect.Int {
+			switch code.Int() {
+			case 2067, 1555:
+				return true
+			}
+		}
+	}
+
+	msg := err.Error()
+	return strings.Contains(msg, "UNIQUE constraint failed") ||
+		strings.Contains(msg, "Duplicate entry") ||
+		strings.Contains(msg, "duplicate key value violates unique constraint")
+}
+
+// SaveOrUpdateOn inserts src, and if that violates a unique constraint,
+// updates the existing row identified by the uniqueCols columns instead.
+// It is an upsert for databases or versions without ON CONFLICT support.
+// inserted reports which happened; in both cases the primary key of src, if
+// it has one, is set to that of the row. In a transaction, the insert is
+// attempted under a savepoint so that its failure does not abort the
+// transaction. Like Upsert, it fails for a table that keeps History.
+func (d *Database) SaveOrUpdateOn(ctx context.Context, db Querier, table string, src interface{}, uniqueCols ...string) (inserted bool, err error) {
+	if err := d.writable("SaveOrUpdateOn", table); err != nil {
+		return false, err
+	}
+	d = d.forTable(table)
+	if len(uniqueCols) == 0 {
+		return false, fmt.Errorf("meddler.SaveOrUpdateOn: no unique columns given")
+	}
+	if d.History[table] {
+		return false, fmt.Errorf("meddler.SaveOrUpdateOn: table %s keeps history; use Load and Save instead", table)
+	}
+	savepoints := inTx(db)
+	forgetTable(ctx, table)
+	ctx, db, done := d.begin(ctx, db, "SaveOrUpdateOn", table)
+	defer done()
+
+	pkName, pkValue, err := d.PrimaryKey(src)
+	if err != nil {
+		return false, err
+	}
+	if savepoints {
+		if err := d.savepoint(ctx, db, "meddler_upsert", "SAVEPOINT", "SAVE TRANSACTION"); err != nil {
+			return false, err
+		}
+	}
+	err = d.insert(ctx, db, table, src, pkName, pkName != "" && pkValue != 0, nil)
+	if err == nil || !IsUniqueViolation(err) {
+		if savepoints && err == nil && d.Dialect != DialectSQLServer {
+			if err := d.savepoint(ctx, db, "meddler_upsert", "RELEASE SAVEPOINT", ""); err != nil {
+				return true, err
+			}
+		}
+		return err == nil, err
+	}
+	if savepoints {
+		if err := d.savepoint(ctx, db, "meddler_upsert", "ROLLBACK TO SAVEPOINT", "ROLLBACK TRANSACTION"); err != nil {
+			return false, err
+		}
+	}
+
+	// update the row that is in the way
+	unique := make(map[string]bool)
+	for _, col := range uniqueCols {
+		unique[col] = true
+	}
+	columns, err := d.writeColumns(src, false, nil)
+	if err != nil {
+		return false, err
+	}
+	var set []string
+	for _, col := range columns {
+		if !unique[col] {
+			set = append(set, col)
+		}
+	}
+	values, err := d.SomeValues(src, append(set, uniqueCols...))
+	if err != nil {
+		return false, err
+	}
+	pairs := make([]string, len(set))
+	for i, col := range set {
+		pairs[i] = fmt.Sprintf("%s=%s", d.quoted(col), d.placeholder(i+1))
+	}
+	where := make([]string, len(uniqueCols))
+	for i, col := range uniqueCols {
+		where[i] = fmt.Sprintf("%s=%s", d.quoted(col), d.placeholder(len(set)+i+1))
+	}
+	if len(set) > 0 {
+		q := fmt.Sprintf("UPDATE %s SET %s WHERE %s", d.quotedTable(table), strings.Join(pairs, ","), strings.Join(where, " AND "))
+		if _, err := db.ExecContext(ctx, q, values...); err != nil {
+			return false, d.queryError("SaveOrUpdateOn", table, q, values, err)
+		}
+	}
+	if pkName == "" {
+		return false, d.written(ctx, db, OpUpdate, "SaveOrUpdateOn", table, src, nil, nil, nil)
+	}
+
+	// find the key of the row that was updated
+	for i := range where {
+		where[i] = fmt.Sprintf("%s=%s", d.quoted(uniqueCols[i]), d.placeholder(i+1))
+	}
+	q := fmt.Sprintf("SELECT %s FROM %s WHERE %s", d.quoted(pkName), d.quotedTable(table), strings.Join(where, " AND "))
+	var pk int64
+	if err := db.QueryRowContext(ctx, q, values[len(set):]...).Scan(&pk); err != nil {
+		return false, d.queryError("SaveOrUpdateOn", table, q, values[len(set):], err)
+	}
+	if err := d.SetPrimaryKey(src, pk); err != nil {
+		return false, fmt.Errorf("meddler.SaveOrUpdateOn: Error saving updated pk: %w", err)
+	}
+	return false, d.written(ctx, db, OpUpdate, "SaveOrUpdateOn", table, src, nil, nil, nil)
+}
+
+// SaveOrUpdateOn using the Default Database type
+func SaveOrUpdateOn(ctx context.Context, db Querier, table string, src interface{}, uniqueCols ...string) (bool, error) {
+	return Default.SaveOrUpdateOn(ctx, db, table, src, uniqueCols...)
+}
@@ -0,0 +1,85 @@
+package meddlerx
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// fakePgError looks like the error types of lib/pq and pgx.
+type fakePgError struct {
+	Code string
+}
+
+func (err *fakePgError) Error() string { return "pq: error" }
+
+// fakeMySQLError looks like the error type of go-sql-driver/mysql.
+type fakeMySQLError struct {
+	Number  uint16
+	Message string
+}
+
+func (err *fakeMySQLError) Error() string { return err.Message }
+
+func TestIsUniqueViolation(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{errors.New("boom"), false},
+		{&fakePgError{Code: "23505"}, true},
+		{&fakePgError{Code: "23503"}, false},
+		{fmt.Errorf("wrapped: %w", &fakeMySQLError{Number: 1062, Message: "x"}), true},
+		{&fakeMySQLError{Number: 1064, Message: "syntax"}, false},
+		{errors.New("UNIQUE constraint failed: event.key"), true},
+	}
+	for _, test := range tests {
+		if got := IsUniqueViolation(test.err); got != test.expected {
+			t.Errorf("IsUniqueViolation(%v): expected %v, got %v", test.err, test.expected, got)
+		}
+	}
+
+	// and a real one from the driver
+	setupEvents(t)
+	defer db.Exec("drop table event")
+	if err := SQLite.Insert(testCtx, db, "event", &event{Key: "a"}); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	err := SQLite.Insert(testCtx, db, "event", &event{Key: "a"})
+	if !IsUniqueViolation(err) {
+		t.Errorf("IsUniqueViolation(%v): expected true", err)
+	}
+}
+
+func TestSaveOrUpdateOn(t *testing.T) {
+	setupEvents(t)
+	defer db.Exec("drop table event")
+
+	first := &event{Key: "a", Payload: "first"}
+	inserted, err := SQLite.SaveOrUpdateOn(testCtx, db, "event", first, "key")
+	if err != nil || !inserted || first.ID == 0 {
+		t.Fatalf("SaveOrUpdateOn new row: got inserted=%v id=%d err=%v", inserted, first.ID, err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Begin error: %v", err)
+	}
+	second := &event{Key: "a", Payload: "second"}
+	inserted, err = SQLite.SaveOrUpdateOn(testCtx, tx, "event", second, "key")
+	if err != nil || inserted || second.ID != first.ID {
+		t.Fatalf("SaveOrUpdateOn existing row: got inserted=%v id=%d err=%v", inserted, second.ID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit error: %v", err)
+	}
+
+	var events []*event
+	if err := SQLite.QueryAll(testCtx, db, &events, "select * from event"); err != nil {
+		t.Fatalf("QueryAll error: %v", err)
+	}
+	if len(events) != 1 || events[0].Payload != "second" {
+		t.Errorf("SaveOrUpdateOn: expected one updated row, got %+v", events)
+	}
+}
@@ -0,0 +1,296 @@
+package meddlerx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// InsertIgnore inserts src unless doing so would violate a unique or
+// primary key constraint, in which case the row is silently skipped.
+// inserted reports whether a row was actually written. When it was, and
+// src has a zero primary key, the key is set from the database as with
+// Insert. A non-zero primary key is written as given.
+//
+// PostgreSQL and SQLite use INSERT ... ON CONFLICT DO NOTHING, and MySQL
+// uses INSERT IGNORE (which also downgrades some other errors to warnings).
+// SQL Server and ANSI have neither, and InsertIgnore fails for them; use
+// SaveOrUpdateOn or check IsUniqueViolation on an Insert instead.
+func (d *Database) InsertIgnore(ctx context.Context, db Querier, table string, src interface{}) (inserted bool, err error) {
+	if err := d.writable("InsertIgnore", table); err != nil {
+		return false, err
+	}
+	if d.Dialect == DialectSQLServer || d.Dialect == DialectANSI {
+		return false, fmt.Errorf("meddler.InsertIgnore: not supported for the %s dialect", d.Dialect)
+	}
+	d = d.forTable(table)
+	ctx, db, done := d.begin(ctx, db, "InsertIgnore", table)
+	defer done()
+	defer func() {
+		if err == nil && inserted {
+			err = d.written(ctx, db, OpInsert, "InsertIgnore", table, src, nil, nil, nil)
+		}
+	}()
+
+	pkName, pkValue, err := d.PrimaryKey(src)
+	if err != nil {
+		return false, err
+	}
+	includePk := pkName != "" && pkValue != 0
+
+	q, values, err := d.insertQuery(table, src, includePk, nil)
+	if err != nil {
+		return false, err
+	}
+	if d.Dialect == DialectMySQL {
+		q = "INSERT IGNORE" + strings.TrimPrefix(q, "INSERT")
+	} else {
+		q += " ON CONFLICT DO NOTHING"
+	}
+
+	if d.UseReturningToGetID && pkName != "" && !includePk {
+		q += " RETURNING " + d.quoted(pkName)
+		var newPk int64
+		err := db.QueryRowContext(ctx, q, values...).Scan(&newPk)
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		if err != nil {
+			return false, d.queryError("InsertIgnore", table, q, values, err)
+		}
+		if err = d.SetPrimaryKey(src, newPk); err != nil {
+			return false, fmt.Errorf("meddler.InsertIgnore: Error saving updated pk: %w", err)
+		}
+		return true, nil
+	}
+
+	result, err := db.ExecContext(ctx, q, values...)
+	if err != nil {
+		return false, d.queryError("InsertIgnore", table, q, values, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, d.queryError("InsertIgnore", table, q, values, err)
+	}
+	if n == 0 {
+		return false, nil
+	}
+	if pkName != "" && !includePk {
+		newPk, err := result.LastInsertId()
+		if err != nil {
+			return true, d.queryError("InsertIgnore", table, q, values, err)
+		}
+		if err = d.SetPrimaryKey(src, newPk); err != nil {
+			return true, fmt.Errorf("meddler.InsertIgnore: Error saving updated pk: %w", err)
+		}
+	}
+	return true, nil
+}
+
+// InsertIgnore using the Default Database type
+func InsertIgnore(ctx context.Context, db Querier, table string, src interface{}) (bool, error) {
+	return Default.InsertIgnore(ctx, db, table, src)
+}
+
+// Upsert inserts src, or updates the existing row if the insert would
+// conflict on conflictCols (the primary key if none are given). inserted
+// reports whether a new row was created, so callers can tell the two cases
+// apart. On return the primary key field of src holds the key of the row
+// that was written.
+//
+// PostgreSQL uses INSERT ... ON CONFLICT DO UPDATE and reads the outcome
+// from xmax in the RETURNING clause. MySQL uses ON DUPLICATE KEY UPDATE,
+// which picks the conflicting key itself and ignores conflictCols; it
+// reports 1 affected row for an insert and 2 (or 0 if nothing changed)
+// for an update. SQLite has no such indicator, so the existing row is
+// looked up first; run Upsert in a transaction there if rows may be
+// inserted concurrently. SQL Server and ANSI have no such clause, and
+// Upsert fails for them; use SaveOrUpdateOn instead.
+//
+// An update by Upsert would replace a row without recording its old
+// version, so Upsert fails for a table that keeps History.
+func (d *Database) Upsert(ctx context.Context, db Querier, table string, src interface{}, conflictCols ...string) (inserted bool, err error) {
+	if err := d.writable("Upsert", table); err != nil {
+		return false, err
+	}
+	if d.Dialect == DialectSQLServer || d.Dialect == DialectANSI {
+		return false, fmt.Errorf("meddler.Upsert: not supported for the %s dialect", d.Dialect)
+	}
+	if d.History[table] {
+		return false, fmt.Errorf("meddler.Upsert: table %s keeps history; use Load and Save instead", table)
+	}
+	d = d.forTable(table)
+	forgetTable(ctx, table)
+	ctx, db, done := d.begin(ctx, db, "Upsert", table)
+	defer done()
+	defer func() {
+		if err == nil {
+			op := OpUpdate
+			if inserted {
+				op = OpInsert
+			}
+			err = d.written(ctx, db, op, "Upsert", table, src, nil, nil, nil)
+		}
+	}()
+
+	pkName, pkValue, err := d.PrimaryKey(src)
+	if err != nil {
+		return false, err
+	}
+	if len(conflictCols) == 0 {
+		if pkName == "" {
+			return false, fmt.Errorf("meddler.Upsert: %w", ErrNoPrimaryKey)
+		}
+		conflictCols = []string{pkName}
+	}
+	includePk := pkName != "" && pkValue != 0
+
+	q, values, err := d.insertQuery(table, src, includePk, nil)
+	if err != nil {
+		return false, err
+	}
+
+	// every written column that is not part of the conflict target is updated
+	columns, err := d.writeColumns(src, false, nil)
+	if err != nil {
+		return false, err
+	}
+	conflict := make(map[string]bool)
+	var quotedConflict []string
+	for _, col := range conflictCols {
+		conflict[col] = true
+		quotedConflict = append(quotedConflict, d.quoted(col))
+	}
+	var updates []string
+	for _, col := range columns {
+		if conflict[col] {
+			continue
+		}
+		if d.Dialect == DialectMySQL {
+			updates = append(updates, fmt.Sprintf("%s=VALUES(%s)", d.quoted(col), d.quoted(col)))
+		} else {
+			updates = append(updates, fmt.Sprintf("%s=excluded.%s", d.quoted(col), d.quoted(col)))
+		}
+	}
+	if len(updates) == 0 {
+		// nothing to change, but the row must still be touched so that
+		// the conflict is reported as an update
+		first := d.quoted(conflictCols[0])
+		if d.Dialect == DialectMySQL {
+			updates = append(updates, fmt.Sprintf("%s=%s", first, first))
+		} else {
+			updates = append(updates, fmt.Sprintf("%s=excluded.%s", first, first))
+		}
+	}
+
+	switch d.Dialect {
+	case DialectPostgreSQL:
+		q += fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s RETURNING ", strings.Join(quotedConflict, ","), strings.Join(updates, ","))
+		if pkName == "" {
+			q += "(xmax = 0)"
+			if err := db.QueryRowContext(ctx, q, values...).Scan(&inserted); err != nil {
+				return false, d.queryError("Upsert", table, q, values, err)
+			}
+			return inserted, nil
+		}
+		q += d.quoted(pkName) + ", (xmax = 0)"
+		var pk int64
+		if err := db.QueryRowContext(ctx, q, values...).Scan(&pk, &inserted); err != nil {
+			return false, d.queryError("Upsert", table, q, values, err)
+		}
+		if err := d.SetPrimaryKey(src, pk); err != nil {
+			return inserted, fmt.Errorf("meddler.Upsert: Error saving updated pk: %w", err)
+		}
+		return inserted, nil
+
+	case DialectMySQL:
+		if pkName != "" {
+			// make LastInsertId report the key of an updated row as well
+			updates = append(updates, fmt.Sprintf("%s=LAST_INSERT_ID(%s)", d.quoted(pkName), d.quoted(pkName)))
+		}
+		q += " ON DUPLICATE KEY UPDATE " + strings.Join(updates, ",")
+		result, err := db.ExecContext(ctx, q, values...)
+		if err != nil {
+			return false, d.queryError("Upsert", table, q, values, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return false, d.queryError("Upsert", table, q, values, err)
+		}
+		inserted = n == 1
+		if pkName != "" {
+			pk, err := result.LastInsertId()
+			if err != nil {
+				return inserted, d.queryError("Upsert", table, q, values, err)
+			}
+			if pk != 0 {
+				if err := d.SetPrimaryKey(src, pk); err != nil {
+					return inserted, fmt.Errorf("meddler.Upsert: Error saving updated pk: %w", err)
+				}
+			}
+		}
+		return inserted, nil
+	}
+
+	// look for the conflicting row first
+	conflictValues, err := d.SomeValues(src, conflictCols)
+	if err != nil {
+		return false, err
+	}
+	var where []string
+	for i, col := range quotedConflict {
+		where = append(where, fmt.Sprintf("%s=%s", col, d.placeholder(i+1)))
+	}
+	selected := "1"
+	if pkName != "" {
+		selected = d.quoted(pkName)
+	}
+	check := fmt.Sprintf("SELECT %s FROM %s WHERE %s", selected, d.quotedTable(table), strings.Join(where, " AND "))
+	var existing int64
+	err = db.QueryRowContext(ctx, check, conflictValues...).Scan(&existing)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		inserted = true
+	case err != nil:
+		return false, d.queryError("Upsert", table, check, conflictValues, err)
+	}
+
+	q += fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(quotedConflict, ","), strings.Join(updates, ","))
+	if d.UseReturningToGetID && pkName != "" {
+		q += " RETURNING " + d.quoted(pkName)
+		var pk int64
+		if err := db.QueryRowContext(ctx, q, values...).Scan(&pk); err != nil {
+			return false, d.queryError("Upsert", table, q, values, err)
+		}
+		if err := d.SetPrimaryKey(src, pk); err != nil {
+			return inserted, fmt.Errorf("meddler.Upsert: Error saving updated pk: %w", err)
+		}
+		return inserted, nil
+	}
+	result, err := db.ExecContext(ctx, q, values...)
+	if err != nil {
+		return false, d.queryError("Upsert", table, q, values, err)
+	}
+	if pkName == "" {
+		return inserted, nil
+	}
+	pk := existing
+	if inserted && !includePk {
+		if pk, err = result.LastInsertId(); err != nil {
+			return inserted, d.queryError("Upsert", table, q, values, err)
+		}
+	}
+	if pk != 0 {
+		if err := d.SetPrimaryKey(src, pk); err != nil {
+			return inserted, fmt.Errorf("meddler.Upsert: Error saving updated pk: %w", err)
+		}
+	}
+	return inserted, nil
+}
+
+// Upsert using the Default Database type
+func Upsert(ctx context.Context, db Querier, table string, src interface{}, conflictCols ...string) (bool, error) {
+	return Default.Upsert(ctx, db, table, src, conflictCols...)
+}
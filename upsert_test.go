@@ -0,0 +1,156 @@
+package meddlerx
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+type event struct {
+	ID      int64  `meddler:"id,pk"`
+	Key     string `meddler:"key"`
+	Payload string `meddler:"payload"`
+}
+
+const eventSchema = `create table event (
+	id integer primary key,
+	key text not null unique,
+	payload text not null
+)`
+
+// recordingQuerier remembers the last query it was given and passes it on
+// to the test database.
+type recordingQuerier struct {
+	query string
+}
+
+func (r *recordingQuerier) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	r.query = query
+	return db.QueryContext(ctx, query, args...)
+}
+
+func (r *recordingQuerier) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	r.query = query
+	return db.QueryRowContext(ctx, query, args...)
+}
+
+func (r *recordingQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	r.query = query
+	return db.ExecContext(ctx, query, args...)
+}
+
+func setupEvents(t *testing.T) {
+	once.Do(setup)
+	if _, err := db.Exec(eventSchema); err != nil {
+		t.Fatalf("creating event table: %v", err)
+	}
+}
+
+func TestInsertIgnore(t *testing.T) {
+	setupEvents(t)
+	defer db.Exec("drop table event")
+
+	first := &event{Key: "a", Payload: "first"}
+	inserted, err := SQLite.InsertIgnore(testCtx, db, "event", first)
+	if err != nil || !inserted {
+		t.Fatalf("InsertIgnore new row: got inserted=%v err=%v", inserted, err)
+	}
+	if first.ID != 1 {
+		t.Errorf("InsertIgnore: expected pk 1, got %d", first.ID)
+	}
+
+	dup := &event{Key: "a", Payload: "second"}
+	inserted, err = SQLite.InsertIgnore(testCtx, db, "event", dup)
+	if err != nil || inserted {
+		t.Errorf("InsertIgnore duplicate: got inserted=%v err=%v", inserted, err)
+	}
+	if dup.ID != 0 {
+		t.Errorf("InsertIgnore duplicate: pk should be left alone, got %d", dup.ID)
+	}
+
+	// explicit keys are written as given, and conflicts on them are skipped too
+	explicit := &event{ID: 7, Key: "b", Payload: "explicit"}
+	if inserted, err = SQLite.InsertIgnore(testCtx, db, "event", explicit); err != nil || !inserted {
+		t.Errorf("InsertIgnore explicit pk: got inserted=%v err=%v", inserted, err)
+	}
+	explicit.Key = "c"
+	if inserted, err = SQLite.InsertIgnore(testCtx, db, "event", explicit); err != nil || inserted {
+		t.Errorf("InsertIgnore duplicate pk: got inserted=%v err=%v", inserted, err)
+	}
+
+	var count int
+	db.QueryRow("select count(*) from event").Scan(&count)
+	if count != 2 {
+		t.Errorf("expected 2 events, found %d", count)
+	}
+
+	for _, d := range []*Database{SQLServer, ANSI} {
+		if _, err := d.InsertIgnore(testCtx, new(recordingQuerier), "event", &event{Key: "c"}); err == nil {
+			t.Errorf("InsertIgnore for %s: expected an unsupported dialect error", d.Dialect)
+		}
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	setupEvents(t)
+	defer db.Exec("drop table event")
+
+	first := &event{Key: "a", Payload: "first"}
+	inserted, err := SQLite.Upsert(testCtx, db, "event", first, "key")
+	if err != nil || !inserted {
+		t.Fatalf("Upsert new row: got inserted=%v err=%v", inserted, err)
+	}
+	if first.ID == 0 {
+		t.Errorf("Upsert new row: pk not set")
+	}
+
+	second := &event{Key: "a", Payload: "second"}
+	inserted, err = SQLite.Upsert(testCtx, db, "event", second, "key")
+	if err != nil || inserted {
+		t.Fatalf("Upsert existing row: got inserted=%v err=%v", inserted, err)
+	}
+	if second.ID != first.ID {
+		t.Errorf("Upsert existing row: expected pk %d, got %d", first.ID, second.ID)
+	}
+
+	// conflict on the primary key by default
+	second.Payload = "third"
+	if inserted, err = SQLite.Upsert(testCtx, db, "event", second); err != nil || inserted {
+		t.Errorf("Upsert by pk: got inserted=%v err=%v", inserted, err)
+	}
+
+	elt := new(event)
+	if err := SQLite.Load(testCtx, db, "event", elt, first.ID); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if elt.Payload != "third" {
+		t.Errorf("Upsert: expected payload third, got %s", elt.Payload)
+	}
+}
+
+func TestUpsertQueries(t *testing.T) {
+	// PostgreSQL reads the outcome from RETURNING, so check the generated SQL
+	// against a querier that records it
+	once.Do(setup)
+	rec := &recordingQuerier{}
+	PostgreSQL.Upsert(testCtx, rec, "event", &event{Key: "a"}, "key")
+	expected := `INSERT INTO "event" ("key","payload") VALUES ($1,$2) ON CONFLICT ("key") DO UPDATE SET "payload"=excluded."payload" RETURNING "id", (xmax = 0)`
+	if rec.query != expected {
+		t.Errorf("PostgreSQL Upsert:\nexpected %s\ngot      %s", expected, rec.query)
+	}
+
+	rec = &recordingQuerier{}
+	MySQL.Upsert(testCtx, rec, "event", &event{Key: "a"})
+	expected = "INSERT INTO `event` (`key`,`payload`) VALUES (?,?) ON DUPLICATE KEY UPDATE `key`=VALUES(`key`),`payload`=VALUES(`payload`),`id`=LAST_INSERT_ID(`id`)"
+	if rec.query != expected {
+		t.Errorf("MySQL Upsert:\nexpected %s\ngot      %s", expected, rec.query)
+	}
+
+	// SQL Server and ANSI have no ON CONFLICT, so nothing is sent
+	for _, d := range []*Database{SQLServer, ANSI} {
+		rec = &recordingQuerier{}
+		if _, err := d.Upsert(testCtx, rec, "event", &event{Key: "a"}, "key"); err == nil || rec.query != "" {
+			t.Errorf("Upsert for %s: expected an unsupported dialect error before any query, got %v, %q", d.Dialect, err, rec.query)
+		}
+	}
+}